@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/navionguy/basicwasm/token"
 )
@@ -124,6 +125,11 @@ func (bi *BuiltinExpression) TokenLiteral() string { return strings.ToUpper(bi.T
 func (bi *BuiltinExpression) String() string {
 	var out bytes.Buffer
 
+	// DATE$ and friends don't take parameters
+	if bi.Params == nil {
+		return bi.TokenLiteral()
+	}
+
 	out.WriteString(bi.TokenLiteral() + "(")
 
 	for i, p := range bi.Params {
@@ -369,6 +375,23 @@ func (eof *EOFExpression) expressionNode()      {}
 func (eof *EOFExpression) TokenLiteral() string { return strings.ToUpper(eof.Token.Literal) }
 func (eof *EOFExpression) String() string       { return "" }
 
+// EraseStatement removes arrays so they can be DIMmed again
+type EraseStatement struct {
+	Token token.Token
+	Vars  []*Identifier
+}
+
+func (ers *EraseStatement) statementNode()       {}
+func (ers *EraseStatement) TokenLiteral() string { return strings.ToUpper(ers.Token.Literal) }
+func (ers *EraseStatement) String() string {
+	names := []string{}
+	for _, v := range ers.Vars {
+		names = append(names, v.String())
+	}
+
+	return ers.TokenLiteral() + " " + strings.Join(names, ", ")
+}
+
 // signal that an error has occurred
 type ErrorStatement struct {
 	Token  token.Token
@@ -386,6 +409,36 @@ func (err *ErrorStatement) String() string {
 	return out.String()
 }
 
+// FieldStatement maps string variables onto a random file's record buffer
+type FieldStatement struct {
+	Token   token.Token
+	FileNum *FileNumber  // file whose buffer is being mapped
+	Fields  []*FieldItem // widths and variables, in record order
+}
+
+// FieldItem is a single "width AS var$" clause
+type FieldItem struct {
+	Width Expression
+	Var   *Identifier
+}
+
+func (fld *FieldStatement) statementNode()       {}
+func (fld *FieldStatement) TokenLiteral() string { return strings.ToUpper(fld.Token.Literal) }
+func (fld *FieldStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fld.TokenLiteral() + " ")
+	if fld.FileNum != nil {
+		out.WriteString(fld.FileNum.String())
+	}
+
+	for _, item := range fld.Fields {
+		out.WriteString(", " + item.Width.String() + " AS " + item.Var.String())
+	}
+
+	return out.String()
+}
+
 // FileNumber holds the I/O identity of an open file
 type FileNumber struct {
 	Token token.Token
@@ -405,6 +458,15 @@ func (fn *FileNumber) String() string {
 	return out.String()
 }
 
+// writeFilePrefix adds the "#n, " that directs a statement to a file
+func writeFilePrefix(out *bytes.Buffer, fn *FileNumber) {
+	if fn == nil {
+		return
+	}
+
+	out.WriteString(fn.String() + ", ")
+}
+
 // FilesCommand gets list of files from basic server
 type FilesCommand struct {
 	Token token.Token
@@ -518,6 +580,102 @@ func (kset *KeySettings) String() string {
 	return out.String()
 }
 
+// trap states set by KEY(n) and TIMER statements
+const (
+	TrapOff  = iota // events are ignored
+	TrapOn          // events jump to the trap line
+	TrapStop        // events are remembered until the trap is turned on
+)
+
+// EventTrap holds the state of one ON KEY or ON TIMER trap
+type EventTrap struct {
+	Line    int  // line to GOSUB to when the event happens, 0 if none
+	State   int  // TrapOff, TrapOn or TrapStop
+	Pending bool // event happened but hasn't been handled yet
+	Active  bool // handler is running, RETURN will clear it
+}
+
+// KeyTraps holds the ON KEY settings in the environment settings
+// index 1 to 20 matches the key number used by KEY(n)
+type KeyTraps struct {
+	Keys [21]EventTrap // trap for each key
+	Defs [21]string    // characters sent by user defined keys 15-20
+}
+
+func (kt *KeyTraps) statementNode()       {}
+func (kt *KeyTraps) TokenLiteral() string { return "KEY" }
+func (kt *KeyTraps) String() string {
+	var out bytes.Buffer
+
+	for i := 1; i < len(kt.Keys); i++ {
+		if kt.Keys[i].Line > 0 {
+			out.WriteString(fmt.Sprintf("ON KEY(%d) GOSUB %d\r\n", i, kt.Keys[i].Line))
+		}
+	}
+
+	return out.String()
+}
+
+// TimerTrap holds the ON TIMER settings in the environment settings
+type TimerTrap struct {
+	Trap     EventTrap
+	Interval time.Duration // time between events
+	Next     time.Time     // when the next event happens
+}
+
+func (tt *TimerTrap) statementNode()       {}
+func (tt *TimerTrap) TokenLiteral() string { return "TIMER" }
+func (tt *TimerTrap) String() string {
+	return fmt.Sprintf("ON TIMER(%d) GOSUB %d", int(tt.Interval/time.Second), tt.Trap.Line)
+}
+
+// InputStatement reads values from the keyboard into variables
+type InputStatement struct {
+	Token    token.Token   // "INPUT"
+	FileNum  *FileNumber   // file to read from, nil for the keyboard
+	SameLine bool          // leading ';' leaves cursor on the input line
+	Prompt   string        // optional prompt string
+	QMark    bool          // display "? " after the prompt
+	Vars     []*Identifier // variables to receive the values
+}
+
+func (inp *InputStatement) statementNode()       {}
+func (inp *InputStatement) TokenLiteral() string { return strings.ToUpper(inp.Token.Literal) }
+func (inp *InputStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(inp.TokenLiteral())
+	if inp.SameLine {
+		out.WriteString(";")
+	}
+	out.WriteString(" ")
+	writeFilePrefix(&out, inp.FileNum)
+	writeInputPrompt(&out, inp.Prompt, inp.QMark)
+
+	for i, v := range inp.Vars {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(v.String())
+	}
+
+	return out.String()
+}
+
+// writeInputPrompt adds the prompt string and its seperator
+func writeInputPrompt(out *bytes.Buffer, prompt string, qmark bool) {
+	if len(prompt) == 0 {
+		return
+	}
+
+	out.WriteString(`"` + prompt + `"`)
+	if qmark {
+		out.WriteString("; ")
+		return
+	}
+	out.WriteString(", ")
+}
+
 type KeyStatement struct {
 	Token token.Token  // "KEY"
 	Param Expression   // ON, OFF, 1...
@@ -543,6 +701,36 @@ func (key *KeyStatement) String() string {
 	return out.String()
 }
 
+// KeyTrapStatement turns trapping for a key on, off or stops it
+// KEY(1) ON
+type KeyTrapStatement struct {
+	Token  token.Token // "KEY"
+	Key    Expression  // key number to trap
+	Action string      // "ON", "OFF" or "STOP"
+}
+
+func (kt *KeyTrapStatement) statementNode()       {}
+func (kt *KeyTrapStatement) TokenLiteral() string { return strings.ToUpper(kt.Token.Literal) }
+func (kt *KeyTrapStatement) String() string {
+	return fmt.Sprintf("%s(%s) %s", kt.TokenLiteral(), kt.Key.String(), kt.Action)
+}
+
+// KillStatement deletes one or more files
+// the file name can contain wildcards
+type KillStatement struct {
+	Token token.Token
+	Path  Expression // file(s) to delete
+}
+
+func (kl *KillStatement) statementNode() {}
+
+// TokenLiteral should return KILL
+func (kl *KillStatement) TokenLiteral() string { return strings.ToUpper(kl.Token.Literal) }
+
+func (kl *KillStatement) String() string {
+	return kl.TokenLiteral() + " " + kl.Path.String()
+}
+
 // LetStatement holds the assignment expression
 type LetStatement struct {
 	Token token.Token // the token.LET token Name *Identifier
@@ -569,6 +757,35 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// LineInputStatement reads an entire line into a string variable
+type LineInputStatement struct {
+	Token    token.Token // "LINE INPUT"
+	FileNum  *FileNumber // file to read from, nil for the keyboard
+	SameLine bool        // leading ';' leaves cursor on the input line
+	Prompt   string      // optional prompt string
+	Var      *Identifier // variable to receive the line
+}
+
+func (li *LineInputStatement) statementNode()       {}
+func (li *LineInputStatement) TokenLiteral() string { return strings.ToUpper(li.Token.Literal) }
+func (li *LineInputStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(li.TokenLiteral())
+	if li.SameLine {
+		out.WriteString(";")
+	}
+	out.WriteString(" ")
+	writeFilePrefix(&out, li.FileNum)
+	writeInputPrompt(&out, li.Prompt, true)
+
+	if li.Var != nil {
+		out.WriteString(li.Var.String())
+	}
+
+	return out.String()
+}
+
 // LineNumStmt holds the line number
 type LineNumStmt struct {
 	Token token.Token
@@ -635,6 +852,43 @@ func (lct *LocateStatement) String() string {
 	return "LOCATE " + strings.TrimLeft(stmt, " ")
 }
 
+// LsetStatement left justifies a string into a FIELD variable
+type LsetStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (ls *LsetStatement) statementNode()       {}
+func (ls *LsetStatement) TokenLiteral() string { return strings.ToUpper(ls.Token.Literal) }
+func (ls *LsetStatement) String() string {
+	return ls.TokenLiteral() + " " + ls.Name.String() + " = " + ls.Value.String()
+}
+
+// MidStatement replaces part of a string without changing its length
+// MID$(A$, 3, 2) = "XY"
+type MidStatement struct {
+	Token  token.Token
+	Name   *Identifier // string being changed
+	Start  Expression  // position of the first character to replace
+	Length Expression  // most characters to replace, optional
+	Value  Expression  // replacement characters
+}
+
+func (mid *MidStatement) statementNode()       {}
+func (mid *MidStatement) TokenLiteral() string { return strings.ToUpper(mid.Token.Literal) }
+func (mid *MidStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("MID$(" + mid.Name.String() + ", " + mid.Start.String())
+	if mid.Length != nil {
+		out.WriteString(", " + mid.Length.String())
+	}
+	out.WriteString(") = " + mid.Value.String())
+
+	return out.String()
+}
+
 // ColorPalette maps[GWBasicColor]XTermColor
 type ColorPalette map[int16]int
 
@@ -669,6 +923,37 @@ func (plt *PaletteStatement) String() string {
 	return buf.String()
 }
 
+// MkDirStatement creates a new directory
+type MkDirStatement struct {
+	Token token.Token
+	Path  Expression // directory to create
+}
+
+func (md *MkDirStatement) statementNode() {}
+
+// TokenLiteral should return MKDIR
+func (md *MkDirStatement) TokenLiteral() string { return strings.ToUpper(md.Token.Literal) }
+
+func (md *MkDirStatement) String() string {
+	return md.TokenLiteral() + " " + md.Path.String()
+}
+
+// NameStatement renames a file
+type NameStatement struct {
+	Token   token.Token
+	OldName Expression // current name of the file
+	NewName Expression // what it will be called
+}
+
+func (nm *NameStatement) statementNode() {}
+
+// TokenLiteral should return NAME
+func (nm *NameStatement) TokenLiteral() string { return strings.ToUpper(nm.Token.Literal) }
+
+func (nm *NameStatement) String() string {
+	return nm.TokenLiteral() + " " + nm.OldName.String() + " AS " + nm.NewName.String()
+}
+
 // NewCommand clears the program and variables
 type NewCommand struct {
 	Token token.Token // my Token
@@ -735,6 +1020,34 @@ func (oer *OnErrorGoto) String() string {
 	return out.String()
 }
 
+// OnKeyStatement sets the line to GOSUB to when a key is pressed
+// ON KEY(1) GOSUB 1000
+type OnKeyStatement struct {
+	Token token.Token // "ON"
+	Key   Expression  // key number to trap
+	Jump  int         // line number of the handler, 0 turns it off
+}
+
+func (ok *OnKeyStatement) statementNode()       {}
+func (ok *OnKeyStatement) TokenLiteral() string { return strings.ToUpper(ok.Token.Literal) }
+func (ok *OnKeyStatement) String() string {
+	return fmt.Sprintf("%s KEY(%s) GOSUB %d", ok.TokenLiteral(), ok.Key.String(), ok.Jump)
+}
+
+// OnTimerStatement sets the line to GOSUB to every n seconds
+// ON TIMER(60) GOSUB 1000
+type OnTimerStatement struct {
+	Token    token.Token // "ON"
+	Interval Expression  // seconds between events
+	Jump     int         // line number of the handler, 0 turns it off
+}
+
+func (ot *OnTimerStatement) statementNode()       {}
+func (ot *OnTimerStatement) TokenLiteral() string { return strings.ToUpper(ot.Token.Literal) }
+func (ot *OnTimerStatement) String() string {
+	return fmt.Sprintf("%s TIMER(%s) GOSUB %d", ot.TokenLiteral(), ot.Interval.String(), ot.Jump)
+}
+
 // OnGoStatement handles both GOSUB and GOSUB
 type OnGoStatement struct {
 	Token  token.Token // should be the "GO"
@@ -818,6 +1131,36 @@ func (ds *DataStatement) String() string {
 	return out.String()
 }
 
+// DefTypeStatement gives a default type to variables by first letter
+// DEFINT I-N, DEFSTR S
+type DefTypeStatement struct {
+	Token  token.Token // token.DEFINT, DEFSNG, DEFDBL or DEFSTR
+	TypeID string      // the type character it stands for
+	Ranges [][2]byte   // first and last letter of each range
+}
+
+func (dt *DefTypeStatement) statementNode()       {}
+func (dt *DefTypeStatement) TokenLiteral() string { return dt.Token.Literal }
+
+// String displays the statement
+func (dt *DefTypeStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(strings.ToUpper(dt.Token.Literal) + " ")
+	for i, r := range dt.Ranges {
+		if i != 0 {
+			out.WriteString(", ")
+		}
+		out.WriteByte(r[0])
+		if r[1] != r[0] {
+			out.WriteString("-")
+			out.WriteByte(r[1])
+		}
+	}
+
+	return out.String()
+}
+
 // DimStatement holds the dimension data for an Identifier
 type DimStatement struct {
 	Token token.Token // token.DIM
@@ -1008,6 +1351,18 @@ func (oc *OctalConstant) String() string {
 	return out.String()
 }
 
+// OptionBaseStatement sets the lowest array subscript
+type OptionBaseStatement struct {
+	Token token.Token
+	Base  int16 // 0 or 1
+}
+
+func (ob *OptionBaseStatement) statementNode()       {}
+func (ob *OptionBaseStatement) TokenLiteral() string { return strings.ToUpper(ob.Token.Literal) }
+func (ob *OptionBaseStatement) String() string {
+	return fmt.Sprintf("%s BASE %d", ob.TokenLiteral(), ob.Base)
+}
+
 // OpenStatement opens a data file or com port
 // comes in two flavors
 // OPEN filename [FOR mode][ACCESS access][lock] AS [#]file number [LEN=reclen]
@@ -1015,7 +1370,7 @@ func (oc *OctalConstant) String() string {
 
 type OpenStatement struct {
 	Token    token.Token // OPEN
-	FileName string      // filename to open
+	FileName Expression  // filename to open
 	//	FileNameSep string           // seperator before FileName
 	FileNumber FileNumber       // file number associated with file
 	FileNumSep string           // seperator before FileNum
@@ -1025,6 +1380,7 @@ type OpenStatement struct {
 	Trash      []TrashStatement // Stuff I was unable to parse
 	RecLen     string           // record length for fixed len records
 	Verbose    bool             // true means the long syntax version of open
+	QuotedMode bool             // mode was given as a string, OPEN "O", #1, "file"
 }
 
 func (opn *OpenStatement) statementNode()       {}
@@ -1037,7 +1393,11 @@ func (opn *OpenStatement) String() string {
 	out.WriteString(opn.Token.Literal)
 
 	if opn.Verbose {
-		out.WriteString(` "` + opn.FileName + `"`)
+		if opn.FileName != nil {
+			out.WriteString(` ` + opn.FileName.String())
+		} else {
+			out.WriteString(` ""`)
+		}
 
 		if len(opn.Mode) > 0 {
 			out.WriteString(` FOR ` + opn.Mode)
@@ -1059,7 +1419,10 @@ func (opn *OpenStatement) String() string {
 			out.WriteString(` LEN = ` + opn.RecLen)
 		}
 	} else { // non verbose form
-		if len(opn.Mode) > 0 {
+		// a quoted mode has to stay quoted or it reads as a variable
+		if opn.QuotedMode {
+			out.WriteString(` "` + opn.Mode + `"`)
+		} else if len(opn.Mode) > 0 {
 			out.WriteString(` ` + opn.Mode)
 		}
 
@@ -1073,8 +1436,8 @@ func (opn *OpenStatement) String() string {
 			out.WriteString(opn.FileNumber.String())
 		}
 
-		if len(opn.FileName) > 0 {
-			out.WriteString(`, "` + opn.FileName + `"`)
+		if opn.FileName != nil {
+			out.WriteString(`, ` + opn.FileName.String())
 		}
 
 		if len(opn.RecLen) > 0 {
@@ -1114,6 +1477,27 @@ func (rd *ReadStatement) String() string {
 	return out.String()
 }
 
+// RandomizeStatement starts a new series of random numbers
+// with no seed, the user gets asked for one
+type RandomizeStatement struct {
+	Token token.Token
+	Seed  Expression
+}
+
+func (rs *RandomizeStatement) statementNode() {}
+
+// TokenLiteral returns my literal
+func (rs *RandomizeStatement) TokenLiteral() string { return rs.Token.Literal }
+
+// String sends the original code
+func (rs *RandomizeStatement) String() string {
+	if rs.Seed == nil {
+		return rs.Token.Literal
+	}
+
+	return rs.Token.Literal + " " + rs.Seed.String()
+}
+
 // RestoreStatement resets the DATA constant scanner to
 // either the beginning or to a specified line number
 type RestoreStatement struct {
@@ -1210,6 +1594,11 @@ func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(pe.Operator)
+
+	// NOT needs a space between it and its operand
+	if pe.Operator == token.NOT {
+		out.WriteString(" ")
+	}
 	out.WriteString(pe.Right.String())
 	return out.String()
 }
@@ -1290,21 +1679,52 @@ func (ifs *IfStatement) String() string {
 	}
 	out.WriteString(" THEN")
 	if ifs.Consequence != nil {
-		_, ok := ifs.Consequence.(*EndStatement)
-		if ok {
-			out.WriteString(" ")
-		}
-		out.WriteString(ifs.Consequence.String())
+		out.WriteString(ifBranchString(ifs.Consequence))
 	}
 
 	if ifs.Alternative != nil {
 		out.WriteString(" ELSE")
-		s := ifs.Alternative
-		_, ok := s.(*EndStatement)
-		if ok {
-			out.WriteString(" ")
-		}
-		out.WriteString(s.String())
+		out.WriteString(ifBranchString(ifs.Alternative))
+	}
+
+	return out.String()
+}
+
+// make sure there is a space between THEN/ELSE and the statement
+func ifBranchString(s Statement) string {
+	str := s.String()
+
+	if !strings.HasPrefix(str, " ") {
+		str = " " + str
+	}
+
+	return str
+}
+
+// GetStatement reads a record from a random file into its buffer
+type GetStatement struct {
+	Token   token.Token
+	FileNum *FileNumber
+	Record  Expression // optional record number
+}
+
+func (get *GetStatement) statementNode()       {}
+func (get *GetStatement) TokenLiteral() string { return strings.ToUpper(get.Token.Literal) }
+func (get *GetStatement) String() string {
+	return writeRecordStatement(get.TokenLiteral(), get.FileNum, get.Record)
+}
+
+// writeRecordStatement builds the text for GET and PUT
+func writeRecordStatement(cmd string, fn *FileNumber, rec Expression) string {
+	var out bytes.Buffer
+
+	out.WriteString(cmd + " ")
+	if fn != nil {
+		out.WriteString(fn.String())
+	}
+
+	if rec != nil {
+		out.WriteString(", " + rec.String())
 	}
 
 	return out.String()
@@ -1401,6 +1821,7 @@ func (lst *ListStatement) String() string {
 // PrintStatement holds everything to control the output
 type PrintStatement struct {
 	Token      token.Token
+	FileNum    *FileNumber // file to print to, nil for the screen
 	Items      []Expression
 	Seperators []string
 }
@@ -1415,6 +1836,7 @@ func (pe *PrintStatement) String() string {
 
 	out.WriteString(pe.TokenLiteral())
 	out.WriteString(" ")
+	writeFilePrefix(&out, pe.FileNum)
 
 	for i, s := range pe.Items {
 		out.WriteString(s.String() + pe.Seperators[i])
@@ -1423,6 +1845,19 @@ func (pe *PrintStatement) String() string {
 	return out.String()
 }
 
+// PutStatement writes the buffer of a random file out as a record
+type PutStatement struct {
+	Token   token.Token
+	FileNum *FileNumber
+	Record  Expression // optional record number
+}
+
+func (put *PutStatement) statementNode()       {}
+func (put *PutStatement) TokenLiteral() string { return strings.ToUpper(put.Token.Literal) }
+func (put *PutStatement) String() string {
+	return writeRecordStatement(put.TokenLiteral(), put.FileNum, put.Record)
+}
+
 // RemStatement holds a comment about the program
 type RemStatement struct {
 	Token   token.Token
@@ -1459,6 +1894,34 @@ func (resm *ResumeStatement) String() string {
 	return out.String()
 }
 
+// RsetStatement right justifies a string into a FIELD variable
+type RsetStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (rs *RsetStatement) statementNode()       {}
+func (rs *RsetStatement) TokenLiteral() string { return strings.ToUpper(rs.Token.Literal) }
+func (rs *RsetStatement) String() string {
+	return rs.TokenLiteral() + " " + rs.Name.String() + " = " + rs.Value.String()
+}
+
+// RmDirStatement removes an empty directory
+type RmDirStatement struct {
+	Token token.Token
+	Path  Expression // directory to remove
+}
+
+func (rd *RmDirStatement) statementNode() {}
+
+// TokenLiteral should return RMDIR
+func (rd *RmDirStatement) TokenLiteral() string { return strings.ToUpper(rd.Token.Literal) }
+
+func (rd *RmDirStatement) String() string {
+	return rd.TokenLiteral() + " " + rd.Path.String()
+}
+
 // RunCommand clears all variables and starts execution
 // RUN linenum starts execution at linenum
 type RunCommand struct {
@@ -1491,6 +1954,29 @@ func (run *RunCommand) String() string {
 	return rc
 }
 
+// SaveCommand writes the current program to a file
+// Format is "A" for ASCII, "P" for protected or empty for tokenized
+type SaveCommand struct {
+	Token  token.Token
+	Path   Expression // file to save the program in
+	Format string
+}
+
+func (sv *SaveCommand) statementNode() {}
+
+// TokenLiteral should return SAVE
+func (sv *SaveCommand) TokenLiteral() string { return strings.ToUpper(sv.Token.Literal) }
+
+func (sv *SaveCommand) String() string {
+	sc := "SAVE " + sv.Path.String()
+
+	if len(sv.Format) > 0 {
+		sc = sc + "," + sv.Format
+	}
+
+	return sc
+}
+
 // parameter indexs for ScreenStatement
 const (
 	ScrnMode        = iota // 0
@@ -1547,6 +2033,19 @@ func (stop *StopStatement) statementNode()       {}
 func (stop *StopStatement) TokenLiteral() string { return strings.ToUpper(stop.Token.Literal) }
 func (stop *StopStatement) String() string       { return strings.ToUpper(stop.Token.Literal) + " " }
 
+// SwapStatement exchanges the values of two variables
+type SwapStatement struct {
+	Token token.Token
+	Left  *Identifier
+	Right *Identifier
+}
+
+func (swp *SwapStatement) statementNode()       {}
+func (swp *SwapStatement) TokenLiteral() string { return strings.ToUpper(swp.Token.Literal) }
+func (swp *SwapStatement) String() string {
+	return swp.TokenLiteral() + " " + swp.Left.String() + ", " + swp.Right.String()
+}
+
 type ToStatement struct {
 	Token token.Token
 }
@@ -1555,6 +2054,16 @@ func (to *ToStatement) statementNode()       {}
 func (to *ToStatement) TokenLiteral() string { return strings.ToUpper(to.Token.Literal) }
 func (to *ToStatement) String() string       { return " " + strings.ToUpper(to.Token.Literal) + " " }
 
+// TimerStatement turns timer trapping on, off or stops it
+type TimerStatement struct {
+	Token  token.Token // "TIMER"
+	Action string      // "ON", "OFF" or "STOP"
+}
+
+func (tm *TimerStatement) statementNode()       {}
+func (tm *TimerStatement) TokenLiteral() string { return strings.ToUpper(tm.Token.Literal) }
+func (tm *TimerStatement) String() string       { return tm.TokenLiteral() + " " + tm.Action }
+
 // TroffCommand turns off tracing
 type TroffCommand struct {
 	Token token.Token
@@ -1661,3 +2170,83 @@ func (vw *ViewPrintStatement) String() string {
 
 	return out.String()
 }
+
+// WendStatement marks the end of a WHILE loop
+type WendStatement struct {
+	Token token.Token
+}
+
+func (wend *WendStatement) statementNode()       {}
+func (wend *WendStatement) TokenLiteral() string { return strings.ToUpper(wend.Token.Literal) }
+func (wend *WendStatement) String() string       { return wend.TokenLiteral() }
+
+// WhileStatement loops until the condition evaluates false
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression // loop continues while this is non-zero
+}
+
+func (whl *WhileStatement) statementNode()       {}
+func (whl *WhileStatement) TokenLiteral() string { return strings.ToUpper(whl.Token.Literal) }
+func (whl *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(whl.TokenLiteral() + " ")
+	if whl.Condition != nil {
+		out.WriteString(whl.Condition.String())
+	}
+
+	return out.String()
+}
+
+// WidthStatement sets the line width of the screen, a file or a device
+// Params holds the size, or a device name and its size, or for the
+// screen the columns and rows
+type WidthStatement struct {
+	Token   token.Token
+	FileNum *FileNumber // file to change, nil for the screen or a device
+	Params  []Expression
+}
+
+func (wdth *WidthStatement) statementNode()       {}
+func (wdth *WidthStatement) TokenLiteral() string { return strings.ToUpper(wdth.Token.Literal) }
+func (wdth *WidthStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(wdth.TokenLiteral() + " ")
+	writeFilePrefix(&out, wdth.FileNum)
+
+	for i, prm := range wdth.Params {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(prm.String())
+	}
+
+	return out.String()
+}
+
+// WriteStatement outputs data items with delimiters
+type WriteStatement struct {
+	Token   token.Token
+	FileNum *FileNumber // file to write to, nil for the screen
+	Items   []Expression
+}
+
+func (wrt *WriteStatement) statementNode()       {}
+func (wrt *WriteStatement) TokenLiteral() string { return strings.ToUpper(wrt.Token.Literal) }
+func (wrt *WriteStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(wrt.TokenLiteral() + " ")
+	writeFilePrefix(&out, wrt.FileNum)
+
+	for i, item := range wrt.Items {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(item.String())
+	}
+
+	return out.String()
+}
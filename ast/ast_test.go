@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/navionguy/basicwasm/token"
 	"github.com/stretchr/testify/assert"
@@ -324,6 +325,40 @@ func TestCodeMultiStmts(t *testing.T) {
 	}
 }
 
+func Test_CodeJumpSameLine(t *testing.T) {
+	code := Code{currIndex: 1, currLine: 20, lines: []codeLine{{lineNum: 10}, {lineNum: 20, curStmt: 2, stmts: []Statement{&LineNumStmt{}, &EndStatement{}, &EndStatement{}}}}}
+
+	err := code.Jump(20)
+
+	assert.Equal(t, 0, err, "Jump(20) failed")
+	assert.Equal(t, 1, code.currIndex, "Jump(20) went to the wrong line")
+	assert.Equal(t, 0, code.lines[1].curStmt, "Jump(20) should start at the top of the line")
+	assert.True(t, code.Next(), "Next() after Jump(20) should find the next statement")
+}
+
+// 10 X = 1 : GOTO 10 has to run X = 1 again after the jump
+// before the fix Jump left the line at the GOTO it was on
+func Test_CodeJumpBackIntoLine(t *testing.T) {
+	var program Program
+	program.New()
+
+	let := &LetStatement{Token: token.Token{Type: token.LET, Literal: "LET"}, Name: &Identifier{Value: "X"}, Value: &IntegerLiteral{Value: 1}}
+	gt := &GotoStatement{Token: token.Token{Type: token.GOTO, Literal: "GOTO"}, JmpTo: []token.Token{{Type: token.INT, Literal: "10"}}}
+	program.AddStatement(&LineNumStmt{Token: token.Token{Type: token.LINENUM, Literal: "10"}, Value: 10})
+	program.AddStatement(let)
+	program.AddStatement(gt)
+
+	code := program.StatementIter()
+	for i := 0; i < 2; i++ {
+		assert.True(t, code.Next(), "couldn't step to the GOTO")
+	}
+	assert.Equal(t, gt, code.Value(), "not on the GOTO")
+
+	assert.Equal(t, 0, code.Jump(10), "Jump(10) failed")
+	assert.True(t, code.Next(), "nothing after the jump")
+	assert.Equal(t, let, code.Value(), "the jump didn't start at the top of the line")
+}
+
 func Test_CodeRetPoint(t *testing.T) {
 	code := Code{currIndex: 1, currLine: 100, lines: []codeLine{{}, {lineNum: 10, curStmt: 5}}}
 
@@ -604,6 +639,15 @@ func TestData(t *testing.T) {
 	}
 }
 
+func Test_DefTypeStatement(t *testing.T) {
+	dt := DefTypeStatement{Token: token.Token{Type: token.DEFINT, Literal: "DEFINT"}, TypeID: "%", Ranges: [][2]byte{{'I', 'N'}, {'S', 'S'}}}
+
+	dt.statementNode()
+
+	assert.Equal(t, "DEFINT", dt.TokenLiteral())
+	assert.Equal(t, "DEFINT I-N, S", dt.String())
+}
+
 func Test_DimStatement(t *testing.T) {
 	id1 := Identifier{Token: token.Token{Type: token.IDENT, Literal: "T[]"}, Value: "[]", Type: "", Index: []*IndexExpression{
 		{Left: &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "10"}, Value: 5},
@@ -741,6 +785,14 @@ func Test_ExpressionStatement(t *testing.T) {
 	assert.Equal(t, "X = X * Y", exp.String())
 }
 
+func Test_EraseStatement(t *testing.T) {
+	ers := EraseStatement{Token: token.Token{Type: token.ERASE, Literal: "erase"}, Vars: []*Identifier{{Value: "A"}, {Value: "B$"}}}
+
+	ers.statementNode()
+	assert.Equal(t, "ERASE", ers.TokenLiteral())
+	assert.Equal(t, "ERASE A, B$", ers.String())
+}
+
 func Test_ErrorStatment(t *testing.T) {
 	err := ErrorStatement{Token: token.Token{Type: token.ERROR, Literal: "ERROR"}, ErrNum: &IntegerLiteral{Value: 31}}
 
@@ -975,6 +1027,27 @@ func Test_KeySettings(t *testing.T) {
 	assert.Equal(t, "F1 \r\nF2 \r\nF3 \r\nF4 \r\nF5 \r\nF6 \r\nF7 \r\nF8 \r\nF9 \r\nF10 \r\n", keys.String(), "didn't get key mappings from keys.String()")
 }
 
+func Test_KeyTraps(t *testing.T) {
+	traps := &KeyTraps{}
+	traps.Keys[1] = EventTrap{Line: 100, State: TrapOn}
+	traps.Keys[15] = EventTrap{Line: 200, State: TrapStop}
+	traps.Keys[2] = EventTrap{State: TrapOn}
+
+	traps.statementNode()
+
+	assert.Equal(t, "KEY", traps.TokenLiteral())
+	assert.Equal(t, "ON KEY(1) GOSUB 100\r\nON KEY(15) GOSUB 200\r\n", traps.String())
+}
+
+func Test_KeyTrapStatement(t *testing.T) {
+	key := &KeyTrapStatement{Token: token.Token{Type: token.KEY, Literal: "key"}, Key: &IntegerLiteral{Value: 11}, Action: "STOP"}
+
+	key.statementNode()
+
+	assert.Equal(t, "KEY", key.TokenLiteral())
+	assert.Equal(t, "KEY(11) STOP", key.String())
+}
+
 func Test_KeyStatement(t *testing.T) {
 	key := &KeyStatement{Token: token.Token{Type: token.KEY, Literal: "KEY"}, Param: &IntegerLiteral{Value: 1},
 		Data: []Expression{&StringLiteral{Value: "FILES"}, &StringLiteral{Value: "Syntax Error"}}}
@@ -1107,6 +1180,14 @@ func Test_OnExpression(t *testing.T) {
 	assert.EqualValues(t, "ON", on.String(), "ON string is incorrect")
 }
 
+func Test_OptionBaseStatement(t *testing.T) {
+	ob := OptionBaseStatement{Token: token.Token{Type: token.OPTION, Literal: "OPTION"}, Base: 1}
+
+	ob.statementNode()
+	assert.Equal(t, "OPTION", ob.TokenLiteral())
+	assert.Equal(t, "OPTION BASE 1", ob.String())
+}
+
 func Test_OpenStatement(t *testing.T) {
 
 	tests := []struct {
@@ -1119,7 +1200,7 @@ func Test_OpenStatement(t *testing.T) {
 			Mode:       `R`,
 			FileNumSep: `#`,
 			FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 37}},
-			FileName:   `briefname`,
+			FileName:   &StringLiteral{Value: `briefname`},
 			RecLen:     `256`},
 			exp: `OPEN R, #37, "briefname",256`,
 		},
@@ -1128,7 +1209,7 @@ func Test_OpenStatement(t *testing.T) {
 			Mode:       `R`,
 			FileNumSep: `#`,
 			FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 37}},
-			FileName:   `trashyname`,
+			FileName:   &StringLiteral{Value: `trashyname`},
 			RecLen:     `256`,
 			Trash:      []TrashStatement{{Token: token.Token{Literal: "bad stuff"}}}},
 			exp: `OPEN R, #37, "trashyname",256 bad stuff`,
@@ -1137,21 +1218,43 @@ func Test_OpenStatement(t *testing.T) {
 			Verbose:    false,
 			Mode:       `R`,
 			FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 38}},
-			FileName:   `trashyname`,
+			FileName:   &StringLiteral{Value: `trashyname`},
 			RecLen:     `256`,
 			Trash:      []TrashStatement{{Token: token.Token{Literal: "bad stuff"}}}},
 			exp: `OPEN R, 38, "trashyname",256 bad stuff`,
 		},
+		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
+			Verbose:    false,
+			Mode:       `O`,
+			QuotedMode: true,
+			FileNumSep: `#`,
+			FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 1}},
+			FileName:   &StringLiteral{Value: `D.TXT`}},
+			exp: `OPEN "O", #1, "D.TXT"`,
+		},
+		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
+			Verbose:    false,
+			Mode:       `O`,
+			QuotedMode: true,
+			FileNumSep: `#`,
+			FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 1}},
+			FileName:   &Identifier{Value: `N$`}},
+			exp: `OPEN "O", #1, N$`,
+		},
 
 		// test verbose
 		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
 			Trash: []TrashStatement{{Token: token.Token{Literal: "filename"}}}, Verbose: true},
 			exp: `OPEN "" filename`},
 		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
-			FileName: "real.dat", Verbose: true},
+			FileName: &StringLiteral{Value: "real.dat"}, Verbose: true},
 			exp: `OPEN "real.dat"`},
 		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
-			FileName:   "bubba.txt",
+			FileName: &Identifier{Value: "F$"}, Mode: `APPEND`, Verbose: true,
+			FileNumSep: `#`, FileNumber: FileNumber{Numbr: &IntegerLiteral{Value: 1}}},
+			exp: `OPEN F$ FOR APPEND AS #1`},
+		{open: OpenStatement{Token: token.Token{Literal: "OPEN"},
+			FileName:   &StringLiteral{Value: "bubba.txt"},
 			Mode:       `OUTPUT`,
 			Verbose:    true,
 			Access:     `READ WRITE`,
@@ -1202,6 +1305,24 @@ func Test_OnErrorGoto(t *testing.T) {
 	}
 }
 
+func Test_OnKeyStatement(t *testing.T) {
+	ok := &OnKeyStatement{Token: token.Token{Type: token.ON, Literal: "on"}, Key: &IntegerLiteral{Value: 1}, Jump: 1000}
+
+	ok.statementNode()
+
+	assert.Equal(t, "ON", ok.TokenLiteral())
+	assert.Equal(t, "ON KEY(1) GOSUB 1000", ok.String())
+}
+
+func Test_OnTimerStatement(t *testing.T) {
+	ot := &OnTimerStatement{Token: token.Token{Type: token.ON, Literal: "ON"}, Interval: &IntegerLiteral{Value: 60}, Jump: 500}
+
+	ot.statementNode()
+
+	assert.Equal(t, "ON", ot.TokenLiteral())
+	assert.Equal(t, "ON TIMER(60) GOSUB 500", ot.String())
+}
+
 func Test_OnGoStatement(t *testing.T) {
 	tests := []struct {
 		og  OnGoStatement
@@ -1249,6 +1370,7 @@ func Test_PrefixExpression(t *testing.T) {
 		val int16
 	}{
 		{exp: "-37", typ: token.MINUS, lit: "-", val: 37},
+		{exp: "NOT 5", typ: token.NOT, lit: "NOT", val: 5},
 	}
 
 	for _, tt := range tests {
@@ -1292,6 +1414,25 @@ func Test_RemStatement(t *testing.T) {
 	assert.Equal(t, stmt.String(), "REM A Comment", "Rem statement didn't build string correctly")
 }
 
+func Test_RandomizeStatement(t *testing.T) {
+	tests := []struct {
+		seed Expression
+		exp  string
+	}{
+		{exp: "RANDOMIZE"},
+		{seed: &IntegerLiteral{Value: 42}, exp: "RANDOMIZE 42"},
+	}
+
+	for _, tt := range tests {
+		rnd := &RandomizeStatement{Token: token.Token{Type: token.RANDOMIZE, Literal: "RANDOMIZE"}, Seed: tt.seed}
+
+		rnd.statementNode()
+
+		assert.Equal(t, "RANDOMIZE", rnd.TokenLiteral())
+		assert.Equal(t, tt.exp, rnd.String())
+	}
+}
+
 func Test_RestoreStatement(t *testing.T) {
 	rstr := &RestoreStatement{Token: token.Token{Type: token.RESTORE, Literal: "RESTORE"}, Line: 200}
 
@@ -1338,6 +1479,46 @@ func Test_RunCommand(t *testing.T) {
 	}
 }
 
+func Test_SaveCommand(t *testing.T) {
+	tests := []struct {
+		cmd SaveCommand
+		exp string
+	}{
+		{cmd: SaveCommand{Token: token.Token{Type: token.SAVE, Literal: "save"}, Path: &StringLiteral{Value: `START.BAS`}}, exp: `SAVE "START.BAS"`},
+		{cmd: SaveCommand{Token: token.Token{Type: token.SAVE, Literal: "SAVE"}, Path: &StringLiteral{Value: `START.BAS`}, Format: "A"}, exp: `SAVE "START.BAS",A`},
+		{cmd: SaveCommand{Token: token.Token{Type: token.SAVE, Literal: "SAVE"}, Path: &StringLiteral{Value: `START.BAS`}, Format: "P"}, exp: `SAVE "START.BAS",P`},
+	}
+
+	for _, tt := range tests {
+		cmd := &tt.cmd
+		cmd.statementNode()
+
+		assert.Equal(t, "SAVE", cmd.TokenLiteral(), "Save command has incorrect TokenLiteral")
+		assert.Equal(t, tt.exp, cmd.String(), "Save command didn't build string correctly")
+	}
+}
+
+func Test_DiskStatements(t *testing.T) {
+	path := &StringLiteral{Value: `OLD.BAS`}
+	tests := []struct {
+		stmt Statement
+		lit  string
+		exp  string
+	}{
+		{stmt: &KillStatement{Token: token.Token{Type: token.KILL, Literal: "kill"}, Path: &StringLiteral{Value: `*.BAK`}}, lit: "KILL", exp: `KILL "*.BAK"`},
+		{stmt: &MkDirStatement{Token: token.Token{Type: token.MKDIR, Literal: "MKDIR"}, Path: &StringLiteral{Value: `GAMES`}}, lit: "MKDIR", exp: `MKDIR "GAMES"`},
+		{stmt: &NameStatement{Token: token.Token{Type: token.NAME, Literal: "name"}, OldName: path, NewName: &StringLiteral{Value: `NEW.BAS`}}, lit: "NAME", exp: `NAME "OLD.BAS" AS "NEW.BAS"`},
+		{stmt: &RmDirStatement{Token: token.Token{Type: token.RMDIR, Literal: "rmdir"}, Path: &StringLiteral{Value: `GAMES`}}, lit: "RMDIR", exp: `RMDIR "GAMES"`},
+	}
+
+	for _, tt := range tests {
+		tt.stmt.statementNode()
+
+		assert.Equal(t, tt.lit, tt.stmt.TokenLiteral(), "%T has incorrect TokenLiteral", tt.stmt)
+		assert.Equal(t, tt.exp, tt.stmt.String(), "%T didn't build string correctly", tt.stmt)
+	}
+}
+
 func Test_ScreenStatement(t *testing.T) {
 	tests := []struct {
 		prms []Expression // array of parameter expressions
@@ -1396,6 +1577,24 @@ func Test_ToStatement(t *testing.T) {
 	assert.Equal(t, " TO ", to.String())
 }
 
+func Test_TimerStatement(t *testing.T) {
+	tm := &TimerStatement{Token: token.Token{Type: token.TIMER, Literal: "timer"}, Action: "OFF"}
+
+	tm.statementNode()
+
+	assert.Equal(t, "TIMER", tm.TokenLiteral())
+	assert.Equal(t, "TIMER OFF", tm.String())
+}
+
+func Test_TimerTrap(t *testing.T) {
+	tt := &TimerTrap{Trap: EventTrap{Line: 300, State: TrapOn}, Interval: 90 * time.Second}
+
+	tt.statementNode()
+
+	assert.Equal(t, "TIMER", tt.TokenLiteral())
+	assert.Equal(t, "ON TIMER(90) GOSUB 300", tt.String())
+}
+
 func Test_TronTroffCommands(t *testing.T) {
 
 	cmd := &TroffCommand{
@@ -1444,3 +1643,108 @@ func Test_ViewPrintStatement(t *testing.T) {
 	assert.Equal(t, "VIEW PRINT", vwp.TokenLiteral())
 	assert.Equal(t, "VIEW PRINT 3 TO 24", vwp.String())
 }
+
+func Test_WhileWendStatements(t *testing.T) {
+	whl := &WhileStatement{Token: token.Token{Type: token.WHILE, Literal: "while"},
+		Condition: &InfixExpression{Left: &Identifier{Value: "X"}, Operator: "<", Right: &IntegerLiteral{Value: 5}}}
+
+	whl.statementNode()
+	assert.Equal(t, "WHILE", whl.TokenLiteral())
+	assert.Equal(t, "WHILE X < 5", whl.String())
+
+	wend := &WendStatement{Token: token.Token{Type: token.WEND, Literal: "wend"}}
+
+	wend.statementNode()
+	assert.Equal(t, "WEND", wend.TokenLiteral())
+	assert.Equal(t, "WEND", wend.String())
+}
+
+func Test_InputStatements(t *testing.T) {
+	inp := &InputStatement{Token: token.Token{Type: token.INPUT, Literal: "input"}, SameLine: true, Prompt: "Values",
+		Vars: []*Identifier{{Value: "A"}, {Value: "B$"}}}
+
+	inp.statementNode()
+	assert.Equal(t, "INPUT", inp.TokenLiteral())
+	assert.Equal(t, `INPUT; "Values", A, B$`, inp.String())
+
+	inp.QMark = true
+	assert.Equal(t, `INPUT; "Values"; A, B$`, inp.String())
+
+	li := &LineInputStatement{Token: token.Token{Type: token.LINE, Literal: "LINE INPUT"}, Var: &Identifier{Value: "A$"}}
+
+	li.statementNode()
+	assert.Equal(t, "LINE INPUT", li.TokenLiteral())
+	assert.Equal(t, `LINE INPUT A$`, li.String())
+}
+
+func Test_WidthStatement(t *testing.T) {
+	wdth := &WidthStatement{Token: token.Token{Type: token.WIDTH, Literal: "width"}, Params: []Expression{&IntegerLiteral{Value: 40, Token: token.Token{Literal: "40"}}}}
+
+	wdth.statementNode()
+	assert.Equal(t, "WIDTH", wdth.TokenLiteral())
+	assert.Equal(t, "WIDTH 40", wdth.String())
+
+	wdth.Params = []Expression{&StringLiteral{Value: "LPT1:"}, &IntegerLiteral{Value: 132, Token: token.Token{Literal: "132"}}}
+	assert.Equal(t, `WIDTH "LPT1:", 132`, wdth.String())
+
+	wdth.FileNum = &FileNumber{Token: token.Token{Literal: "#"}, Numbr: &IntegerLiteral{Value: 1, Token: token.Token{Literal: "1"}}}
+	wdth.Params = wdth.Params[1:]
+	assert.Equal(t, `WIDTH #1, 132`, wdth.String())
+}
+
+func Test_WriteStatement(t *testing.T) {
+	wrt := &WriteStatement{Token: token.Token{Type: token.WRITE, Literal: "write"},
+		Items: []Expression{&Identifier{Value: "A"}, &StringLiteral{Value: "B"}}}
+
+	wrt.statementNode()
+	assert.Equal(t, "WRITE", wrt.TokenLiteral())
+	assert.Equal(t, `WRITE A, "B"`, wrt.String())
+
+	wrt.FileNum = &FileNumber{Token: token.Token{Literal: "#"}, Numbr: &IntegerLiteral{Value: 1, Token: token.Token{Literal: "1"}}}
+	assert.Equal(t, `WRITE #1, A, "B"`, wrt.String())
+}
+
+func Test_RecordStatements(t *testing.T) {
+	fn := &FileNumber{Token: token.Token{Literal: "#"}, Numbr: &IntegerLiteral{Value: 1, Token: token.Token{Literal: "1"}}}
+
+	fld := &FieldStatement{Token: token.Token{Type: token.FIELD, Literal: "field"}, FileNum: fn,
+		Fields: []*FieldItem{{Width: &IntegerLiteral{Value: 20, Token: token.Token{Literal: "20"}}, Var: &Identifier{Value: "N$"}}}}
+	fld.statementNode()
+	assert.Equal(t, "FIELD", fld.TokenLiteral())
+	assert.Equal(t, "FIELD #1, 20 AS N$", fld.String())
+
+	get := &GetStatement{Token: token.Token{Type: token.GET, Literal: "get"}, FileNum: fn}
+	get.statementNode()
+	assert.Equal(t, "GET", get.TokenLiteral())
+	assert.Equal(t, "GET #1", get.String())
+
+	put := &PutStatement{Token: token.Token{Type: token.PUT, Literal: "put"}, FileNum: fn, Record: &IntegerLiteral{Value: 3, Token: token.Token{Literal: "3"}}}
+	put.statementNode()
+	assert.Equal(t, "PUT", put.TokenLiteral())
+	assert.Equal(t, "PUT #1, 3", put.String())
+
+	ls := &LsetStatement{Token: token.Token{Type: token.LSET, Literal: "lset"}, Name: &Identifier{Value: "N$"}, Value: &StringLiteral{Value: "Fred"}}
+	ls.statementNode()
+	assert.Equal(t, "LSET", ls.TokenLiteral())
+	assert.Equal(t, `LSET N$ = "Fred"`, ls.String())
+
+	rs := &RsetStatement{Token: token.Token{Type: token.RSET, Literal: "rset"}, Name: &Identifier{Value: "N$"}, Value: &StringLiteral{Value: "Fred"}}
+	rs.statementNode()
+	assert.Equal(t, "RSET", rs.TokenLiteral())
+	assert.Equal(t, `RSET N$ = "Fred"`, rs.String())
+}
+
+func Test_StringStatements(t *testing.T) {
+	swp := &SwapStatement{Token: token.Token{Type: token.SWAP, Literal: "swap"}, Left: &Identifier{Value: "A"}, Right: &Identifier{Value: "B"}}
+	swp.statementNode()
+	assert.Equal(t, "SWAP", swp.TokenLiteral())
+	assert.Equal(t, "SWAP A, B", swp.String())
+
+	mid := &MidStatement{Token: token.Token{Type: token.IDENT, Literal: "MID$"}, Name: &Identifier{Value: "A$"}, Start: &IntegerLiteral{Value: 3}, Value: &StringLiteral{Value: "XY"}}
+	mid.statementNode()
+	assert.Equal(t, "MID$", mid.TokenLiteral())
+	assert.Equal(t, `MID$(A$, 3) = "XY"`, mid.String())
+
+	mid.Length = &IntegerLiteral{Value: 2}
+	assert.Equal(t, `MID$(A$, 3, 2) = "XY"`, mid.String())
+}
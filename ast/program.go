@@ -35,8 +35,9 @@ type Code struct {
 
 // RetPoint holds the line and statement we want to return to
 type RetPoint struct {
-	currIndex int // index into lines
-	currStmt  int // current statment executing
+	currIndex int        // index into lines
+	currStmt  int        // current statment executing
+	Trap      *EventTrap // set when an event trap did the GOSUB
 }
 
 // ConstData provides access to DATA elements
@@ -83,6 +84,10 @@ func (p *Program) Parsed() {
 
 // CmdParsed gets the command line ready to execute
 func (p *Program) CmdParsed() {
+	// a syntax error can leave nothing to execute
+	if len(p.cmdLine.lines) == 0 {
+		return
+	}
 	p.cmdLine.lines[0].curStmt = 0
 }
 
@@ -293,6 +298,8 @@ func (cd *Code) Jump(target int) int {
 
 	if ok {
 		cd.currIndex = i
+		// start from the top, even if it is the line we are on
+		cd.lines[i].curStmt = 0
 		return 0
 	}
 	// stop execution
@@ -54,18 +54,17 @@ const (
 	InternalErr
 	BadFileNum
 	FileNotFound
+	BadFileMode
+	FileAlreadyOpen
 	_
 	_
-	_
-	_
-	_
-	_
+	FileAlreadyExists
 	_
 	_ // 60
 	_
-	_
-	_
-	_
+	InputPastEnd
+	BadRecordNum
+	BadFileName
 	_
 	_
 	_
@@ -75,8 +74,8 @@ const (
 	_
 	_
 	_
-	_
-	_
+	RenameAcrossDisks
+	PathFileAccess
 	PathNotFound
 	ServerError
 )
@@ -84,24 +83,50 @@ const (
 // TextForError returns the error text based on error number
 func TextForError(err int) string {
 	switch err {
+	case BadFileMode:
+		return "Bad file mode"
+	case BadFileName:
+		return "Bad file name"
+	case BadFileNum:
+		return "Bad file number"
+	case BadRecordNum:
+		return "Bad record number"
 	case CantContinue:
 		return "Can't continue"
 	case DivByZero:
 		return "Division by zero"
+	case DuplicateDefinition:
+		return "Duplicate Definition"
+	case FieldOverflow:
+		return "FIELD overflow"
+	case FileAlreadyExists:
+		return "File already exists"
+	case FileAlreadyOpen:
+		return "File already open"
 	case FileNotFound:
 		return "File not found"
 	case IllegalDirect:
 		return "Illegal direct"
 	case IllegalFuncCallErr:
 		return "Illegal function call"
+	case InputPastEnd:
+		return "Input past end"
 	case NextWithoutFor:
 		return "NEXT without FOR"
 	case OutOfData:
 		return "Out of DATA"
+	case OutOfMemory:
+		return "Out of memory"
 	case Overflow:
 		return "Overflow"
+	case PermissionDenied:
+		return "Permission Denied"
+	case RenameAcrossDisks:
+		return "Rename across disks"
 	case ReturnWoGosub:
 		return "RETURN without GOSUB"
+	case SubscriptRange:
+		return "Subscript out of range"
 	case Syntax:
 		return "Syntax error"
 	case TypeMismatch:
@@ -110,6 +135,12 @@ func TextForError(err int) string {
 		return "Undefined user function"
 	case UnDefinedLineNumber:
 		return "Undefined line number"
+	case WendWoWhile:
+		return "WEND without WHILE"
+	case WhileWoWend:
+		return "WHILE without WEND"
+	case PathFileAccess:
+		return "Path/File access error"
 	case PathNotFound:
 		return "Path not found"
 	case ServerError:
@@ -11,19 +11,35 @@ func TestTextForError(t *testing.T) {
 		inp int
 		exp string
 	}{
+		{inp: BadFileMode, exp: "Bad file mode"},
+		{inp: BadFileName, exp: "Bad file name"},
+		{inp: BadFileNum, exp: "Bad file number"},
+		{inp: BadRecordNum, exp: "Bad record number"},
+		{inp: FieldOverflow, exp: "FIELD overflow"},
 		{inp: CantContinue, exp: "Can't continue"},
 		{inp: DivByZero, exp: "Division by zero"},
+		{inp: DuplicateDefinition, exp: "Duplicate Definition"},
+		{inp: FileAlreadyExists, exp: "File already exists"},
+		{inp: FileAlreadyOpen, exp: "File already open"},
 		{inp: FileNotFound, exp: "File not found"},
 		{inp: IllegalDirect, exp: "Illegal direct"},
 		{inp: IllegalFuncCallErr, exp: "Illegal function call"},
+		{inp: InputPastEnd, exp: "Input past end"},
 		{inp: NextWithoutFor, exp: "NEXT without FOR"},
 		{inp: OutOfData, exp: "Out of DATA"},
+		{inp: OutOfMemory, exp: "Out of memory"},
 		{inp: Overflow, exp: "Overflow"},
+		{inp: PermissionDenied, exp: "Permission Denied"},
+		{inp: RenameAcrossDisks, exp: "Rename across disks"},
 		{inp: ReturnWoGosub, exp: "RETURN without GOSUB"},
+		{inp: SubscriptRange, exp: "Subscript out of range"},
 		{inp: Syntax, exp: "Syntax error"},
 		{inp: TypeMismatch, exp: "Type mismatch"},
 		{inp: UndefinedFunction, exp: "Undefined user function"},
 		{inp: UnDefinedLineNumber, exp: "Undefined line number"},
+		{inp: WendWoWhile, exp: "WEND without WHILE"},
+		{inp: WhileWoWend, exp: "WHILE without WEND"},
+		{inp: PathFileAccess, exp: "Path/File access error"},
 		{inp: PathNotFound, exp: "Path not found"},
 		{inp: 100, exp: "Unprintable error"},
 		{inp: ServerError, exp: "Server error"},
@@ -6,11 +6,19 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/mbf"
 	"github.com/navionguy/basicwasm/object"
 )
 
+// NoArgs are the builtins that are used like variables, they take no parameters
+var NoArgs = map[string]bool{"DATE$": true, "TIME$": true, "TIMER": true}
+
+// OptionalArgs are the builtins that can be used with or without parameters
+var OptionalArgs = map[string]bool{"RND": true}
+
 var Builtins = map[string]*object.Builtin{
 	"ABS": { // absolute value
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
@@ -244,7 +252,7 @@ var Builtins = map[string]*object.Builtin{
 			}
 		},
 	},
-	"CVD": { // convert string to double precision float
+	"CVD": { // convert an 8 byte MBF string to double precision float
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.StdError(env, berrors.Syntax)
@@ -256,7 +264,12 @@ var Builtins = map[string]*object.Builtin{
 				return object.StdError(env, berrors.TypeMismatch)
 			}
 
-			return FixType(env, int(binary.LittleEndian.Uint64(str[:8])))
+			val, ok := mbf.DecodeDouble(str)
+			if !ok {
+				return object.StdError(env, berrors.IllegalFuncCallErr)
+			}
+
+			return &object.FloatDbl{Value: val}
 		},
 	},
 	"CVI": { // convert string to integer
@@ -274,7 +287,7 @@ var Builtins = map[string]*object.Builtin{
 			return FixType(env, int16(binary.LittleEndian.Uint16(num[:2])))
 		},
 	},
-	"CVS": { // convert string to single precision float
+	"CVS": { // convert a 4 byte MBF string to single precision float
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.StdError(env, berrors.Syntax)
@@ -286,7 +299,34 @@ var Builtins = map[string]*object.Builtin{
 				return object.StdError(env, berrors.TypeMismatch)
 			}
 
-			return FixType(env, int32(binary.LittleEndian.Uint32(str[:4])))
+			val, ok := mbf.DecodeSingle(str)
+			if !ok {
+				return object.StdError(env, berrors.IllegalFuncCallErr)
+			}
+
+			return &object.FloatSgl{Value: val}
+		},
+	},
+	"DATE$": { // current date as mm-dd-yyyy
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.StdError(env, berrors.Syntax)
+			}
+
+			return &object.String{Value: env.Now().Format("01-02-2006")}
+		},
+	},
+	"EOF": { // returns -1 if the file has no more data to read
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			fh, err := extractFile(env, args)
+			if err != nil {
+				return err
+			}
+
+			if fh.EOF() {
+				return &object.Integer{Value: -1}
+			}
+			return &object.Integer{Value: 0}
 		},
 	},
 	"EXP": { // e^^x
@@ -505,6 +545,26 @@ var Builtins = map[string]*object.Builtin{
 			return &object.Integer{Value: int16(len(bstr))}
 		},
 	},
+	"LOC": { // number of 128 byte blocks read or written
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			fh, err := extractFile(env, args)
+			if err != nil {
+				return err
+			}
+
+			return FixType(env, fh.LOC())
+		},
+	},
+	"LOF": { // length of the file in bytes
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			fh, err := extractFile(env, args)
+			if err != nil {
+				return err
+			}
+
+			return FixType(env, fh.LOF())
+		},
+	},
 	"LOG": { // return the natural log of a number
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -540,30 +600,16 @@ var Builtins = map[string]*object.Builtin{
 			}
 
 			src, ok, isString := extractString(args[0])
-			floc, ok2 := extractNumeric(args[1])
-			fct := float64(0)
-			ok3 := true
-
-			if len(args) == 3 {
-				fct, ok3 = extractNumeric(args[2])
-			}
-
-			if !ok || !ok2 || !ok3 {
+			if !ok {
 				return object.StdError(env, berrors.Syntax)
 			}
 
-			ct := int(fct) // length of string to return
-			loc := int(floc)
-
-			if (loc < 1) || (loc > 255) || (ct < 0) || (ct > 255) {
-				return object.StdError(env, berrors.IllegalFuncCallErr)
+			start, end, err := MidRange(env, len(src), args[1:]...)
+			if err != nil {
+				return err
 			}
 
-			bt := src[loc-1:]
-
-			if ct != 0 {
-				bt = bt[:ct]
-			}
+			bt := src[start:end]
 
 			if isString {
 				return &object.String{Value: string(bt)}
@@ -572,13 +618,13 @@ var Builtins = map[string]*object.Builtin{
 			return &object.BStr{Value: bt}
 		},
 	},
-	"MKD$": { // convert a numeric to a 8 byte BStr
+	"MKD$": { // convert a numeric to an 8 byte MBF BStr
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.StdError(env, berrors.Syntax)
 			}
 
-			return bstrEncode(8, env, args[0])
+			return mbfEncode(8, env, args[0])
 		},
 	},
 	"MKI$": { // convert a numeric to a 2 byte BStr
@@ -590,13 +636,13 @@ var Builtins = map[string]*object.Builtin{
 			return bstrEncode(2, env, args[0])
 		},
 	},
-	"MKS$": { // convert a numeric to a 4 byte string
+	"MKS$": { // convert a numeric to a 4 byte MBF BStr
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.StdError(env, berrors.Syntax)
 			}
 
-			return bstrEncode(4, env, args[0])
+			return mbfEncode(4, env, args[0])
 		},
 	},
 	"OCT$": { // convert a numberic to octal representation
@@ -620,6 +666,22 @@ var Builtins = map[string]*object.Builtin{
 			return object.StdError(env, berrors.Overflow)
 		},
 	},
+	"POS": { // return the column the cursor is in, counting from 1
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			// the argument is a dummy, but it has to be there
+			if len(args) != 1 {
+				return object.StdError(env, berrors.Syntax)
+			}
+
+			if _, ok := extractNumeric(args[0]); !ok {
+				return object.StdError(env, berrors.TypeMismatch)
+			}
+
+			_, col := env.Terminal().GetCursor()
+
+			return &object.Integer{Value: int16(col + 1)}
+		},
+	},
 	"RIGHT$": { // return the rightmost n characters of the string
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 2 {
@@ -662,13 +724,18 @@ var Builtins = map[string]*object.Builtin{
 				return object.StdError(env, berrors.Syntax)
 			}
 
+			// RND by itself is the same as RND(1)
+			if len(args) == 0 {
+				return env.Random(1)
+			}
+
 			x, ok := extractNumeric(args[0])
 
 			if !ok {
 				return object.StdError(env, berrors.TypeMismatch)
 			}
 
-			return env.Random(int(x))
+			return env.Random(x)
 		},
 	},
 	"SCREEN": { // read the ascii value at a position on the screen
@@ -757,6 +824,31 @@ var Builtins = map[string]*object.Builtin{
 			return &object.String{Value: sp}
 		},
 	},
+	"SPC": { // skip n spaces, PRINT handles it directly
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.StdError(env, berrors.Syntax)
+			}
+
+			arg, ok := extractNumeric(args[0])
+
+			if !ok {
+				return object.StdError(env, berrors.TypeMismatch)
+			}
+
+			val := int(math.Round(arg))
+
+			if (val < 0) || (val > 255) {
+				return object.StdError(env, berrors.IllegalFuncCallErr)
+			}
+
+			var out bytes.Buffer
+			for i := 0; i < val; i++ {
+				out.WriteString(" ")
+			}
+			return &object.String{Value: out.String()}
+		},
+	},
 	"SQR": { // calculate square root of argument
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -780,14 +872,12 @@ var Builtins = map[string]*object.Builtin{
 				return object.StdError(env, berrors.Syntax)
 			}
 
-			arg, ok := extractNumeric(args[0])
+			st, ok := object.FormatNumber(args[0])
 
 			if !ok {
 				return object.StdError(env, berrors.TypeMismatch)
 			}
 
-			st := fmt.Sprint(arg)
-
 			return &object.String{Value: st}
 		},
 	},
@@ -887,6 +977,28 @@ var Builtins = map[string]*object.Builtin{
 			return &object.FloatSgl{Value: float32(math.Tan(arg))}
 		},
 	},
+	"TIME$": { // current time as hh:mm:ss
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.StdError(env, berrors.Syntax)
+			}
+
+			return &object.String{Value: env.Now().Format("15:04:05")}
+		},
+	},
+	"TIMER": { // seconds since midnight
+		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.StdError(env, berrors.Syntax)
+			}
+
+			now := env.Now()
+			midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			secs := math.Floor(now.Sub(midnight).Seconds()*100) / 100
+
+			return &object.FloatSgl{Value: float32(secs)}
+		},
+	},
 	"VAL": {
 		Fn: func(env *object.Environment, fn *object.Builtin, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -911,7 +1023,7 @@ var Builtins = map[string]*object.Builtin{
 
 // Some common functionality
 
-// MKD$, MKI$, and MKS$ all return values as a Bstr
+// MKI$ returns its value as a Bstr
 func bstrEncode(size int, env *object.Environment, arg object.Object) object.Object {
 	var rc int64
 	switch ar := arg.(type) {
@@ -946,6 +1058,28 @@ func bstrEncode(size int, env *object.Environment, arg object.Object) object.Obj
 	return buildBstr(size, rc)
 }
 
+// MKS$ and MKD$ store their value in Microsoft Binary Format
+// the way GW-BASIC random files hold them
+func mbfEncode(size int, env *object.Environment, arg object.Object) object.Object {
+	val, ok := extractNumeric(arg)
+	if !ok {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	var bt []byte
+	if size == 4 {
+		bt, ok = mbf.EncodeSingle(float32(val))
+	} else {
+		bt, ok = mbf.EncodeDouble(val)
+	}
+
+	if !ok {
+		return object.StdError(env, berrors.Overflow)
+	}
+
+	return &object.BStr{Value: bt}
+}
+
 // now that I have created the integer part
 // use the binary package to serialize rc
 // as a byte series, little Endian
@@ -964,6 +1098,27 @@ func buildBstr(size int, rc int64) object.Object {
 
 }
 
+// given a file number argument, return the open file
+func extractFile(env *object.Environment, args []object.Object) (*object.FileHandle, object.Object) {
+	if len(args) != 1 {
+		return nil, object.StdError(env, berrors.Syntax)
+	}
+
+	num, ok := extractNumeric(args[0])
+
+	if !ok {
+		return nil, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	fh := env.GetFile(int16(math.Round(num)))
+
+	if fh == nil {
+		return nil, object.StdError(env, berrors.BadFileNum)
+	}
+
+	return fh, nil
+}
+
 // given any of the numeric values, return a float64 representation
 // bool = false means non-numeric
 func extractNumeric(obj object.Object) (float64, bool) {
@@ -1020,7 +1175,53 @@ func extractString(obj object.Object) ([]byte, bool, bool) {
 	}
 }
 
+// MidRange checks the position and length arguments of MID$ and
+// returns where they start and end in a string of size bytes
+func MidRange(env *object.Environment, size int, args ...object.Object) (int, int, object.Object) {
+	if (len(args) < 1) || (len(args) > 2) {
+		return 0, 0, object.StdError(env, berrors.Syntax)
+	}
+
+	floc, ok := extractNumeric(args[0])
+	fct := float64(255) // without a length, take the rest of the string
+	ok2 := true
+
+	if len(args) == 2 {
+		fct, ok2 = extractNumeric(args[1])
+	}
+
+	if !ok || !ok2 {
+		return 0, 0, object.StdError(env, berrors.Syntax)
+	}
+
+	ct := int(fct) // length of string to return
+	loc := int(floc)
+
+	if (loc < 1) || (loc > 255) || (ct < 0) || (ct > 255) {
+		return 0, 0, object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	start := loc - 1
+	if start > size {
+		start = size
+	}
+
+	end := start + ct
+	if end > size {
+		end = size
+	}
+
+	return start, end, nil
+}
+
+// FixType wraps a Go number in the smallest object that holds it
+// whole numbers that fit become integers, floats too large for their
+// precision give an Overflow error
 func FixType(env *object.Environment, val interface{}) object.Object {
+	if isInfinite(val) {
+		return object.StdError(env, berrors.Overflow)
+	}
+
 	// check the integer types
 	res := tryInteger(val)
 
@@ -1116,19 +1317,36 @@ func shrinkF64(f64 float64) object.Object {
 		return shrinkF32(float32(f64))
 	}
 
-	i64 := int(f64)
-	if float64(i64) == f64 {
-		return shrinkI64(i64)
+	if isInt16(f64) {
+		return &object.Integer{Value: int16(f64)}
 	}
 
 	return &object.FloatDbl{Value: f64}
 }
 
+// a float only shrinks to an integer if it fits in 16 bits
+// anything larger keeps the precision it was calculated in
 func shrinkF32(f32 float32) object.Object {
-	i32 := int32(f32)
-	if float32(i32) == f32 {
-		return shrinkI32(i32)
+	if isInt16(float64(f32)) {
+		return &object.Integer{Value: int16(f32)}
 	}
 
 	return &object.FloatSgl{Value: f32}
 }
+
+// isInt16 returns true for whole numbers from -32768 to 32767
+func isInt16(f float64) bool {
+	return (f == math.Trunc(f)) && (f >= math.MinInt16) && (f <= math.MaxInt16)
+}
+
+// isInfinite returns true if a float overflowed its precision
+func isInfinite(val interface{}) bool {
+	switch f := val.(type) {
+	case float32:
+		return math.IsInf(float64(f), 0)
+	case float64:
+		return math.IsInf(f, 0)
+	}
+
+	return false
+}
@@ -1,8 +1,10 @@
 package builtins
 
 import (
+	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/navionguy/basicwasm/decimal"
 	"github.com/navionguy/basicwasm/mocks"
@@ -293,8 +295,9 @@ func TestCvd(t *testing.T) {
 	tests := []test{
 		{cmd: `10 CVD("ABCD", "EFGH")`, lnum: 10, inp: []object.Object{&object.String{Value: "ABCD"}, &object.String{Value: "EFGH"}}, exp: &object.Error{Message: "Syntax error in 10"}},
 		{cmd: `30 CVD(123)`, lnum: 30, inp: []object.Object{&object.Integer{Value: 123}}, exp: &object.Error{Message: "Type mismatch in 30"}},
-		{cmd: `40 CVD("........")`, inp: []object.Object{&object.String{Value: "........"}}, exp: &object.FloatDbl{Value: 3327647950551526912}},
-		{cmd: `50 A$ = MKD$(-12) : CVD(A$)`, inp: []object.Object{res}, exp: &object.Integer{Value: -12}},
+		{cmd: `40 CVD("........")`, inp: []object.Object{&object.String{Value: "........"}}, exp: &object.FloatDbl{Value: 1.407018002725003e-25}},
+		{cmd: `50 A$ = MKD$(-12) : CVD(A$)`, inp: []object.Object{res}, exp: &object.FloatDbl{Value: -12}},
+		{cmd: `60 CVD("ABCD")`, lnum: 60, inp: []object.Object{&object.String{Value: "ABCD"}}, exp: &object.Error{Message: "Illegal function call in 60"}},
 	}
 
 	runTests(t, "CVD", tests)
@@ -314,13 +317,81 @@ func TestCvs(t *testing.T) {
 	tests := []test{
 		{cmd: `10 CVS("ABCD", "EFGH")`, lnum: 10, inp: []object.Object{&object.String{Value: "ABCD"}, &object.String{Value: "EFGH"}}, exp: &object.Error{Message: "Syntax error in 10"}},
 		{cmd: `30 CVS(123)`, lnum: 30, inp: []object.Object{&object.Integer{Value: 123}}, exp: &object.Error{Message: "Type mismatch in 30"}},
-		//{cmd: `40 Y$ = MKS$(35) : CVS(Y$)`, exp: &object.IntDbl{Value: 35}},
-		{cmd: `50 CVS("..")`, inp: []object.Object{&object.String{Value: ".."}}, exp: &object.Integer{Value: 11822}},
+		{cmd: `40 CVS(MKS$(35))`, inp: []object.Object{&object.BStr{Value: []byte{0x00, 0x00, 0x0c, 0x86}}}, exp: &object.FloatSgl{Value: 35}},
+		{cmd: `50 CVS("..")`, lnum: 50, inp: []object.Object{&object.String{Value: ".."}}, exp: &object.Error{Message: "Illegal function call in 50"}},
+		{cmd: `60 CVS("ABCD")`, inp: []object.Object{&object.String{Value: "ABCD"}}, exp: &object.FloatSgl{Value: 6.6156256e-19}},
 	}
 
 	runTests(t, "CVS", tests)
 }
 
+// runClockTests runs tests against a clock stopped at 1:02:03.456pm on July 4th, 2025
+func runClockTests(t *testing.T, bltin string, tests []test) {
+	for _, tt := range tests {
+		fn := Builtins[bltin]
+
+		var mt mocks.MockTerm
+		mocks.InitMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		env.SetClock(func() time.Time { return time.Date(2025, 7, 4, 13, 2, 3, 456000000, time.Local) })
+
+		if tt.lnum != 0 {
+			env.Set(token.LINENUM, &object.IntDbl{Value: int32(tt.lnum)})
+			env.SetRun(true)
+		}
+		res := fn.Fn(env, fn, tt.inp...)
+
+		compareObjects(tt.cmd, res, tt.exp, t)
+	}
+}
+
+func TestDate(t *testing.T) {
+	tests := []test{
+		{cmd: `10 DATE$`, exp: &object.String{Value: "07-04-2025"}},
+		{cmd: `20 DATE$(1)`, lnum: 20, inp: []object.Object{&object.Integer{Value: 1}}, exp: &object.Error{Message: "Syntax error in 20"}},
+	}
+
+	runClockTests(t, "DATE$", tests)
+}
+
+func TestEOF(t *testing.T) {
+	tests := []test{
+		{cmd: `10 EOF(1, 2)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 10"}},
+		{cmd: `20 EOF("A")`, lnum: 20, inp: []object.Object{&object.String{Value: "A"}}, exp: &object.Error{Message: "Type mismatch in 20"}},
+		{cmd: `30 EOF(3)`, lnum: 30, inp: []object.Object{&object.Integer{Value: 3}}, exp: &object.Error{Message: "Bad file number in 30"}},
+	}
+
+	runTests(t, "EOF", tests)
+}
+
+func TestFileFunctions(t *testing.T) {
+	var mt mocks.MockTerm
+	mocks.InitMockTerm(&mt)
+	env := object.NewTermEnvironment(mt)
+
+	object.CreateFileStore().StoreFile(`C:\FUNCS.DAT`, []byte("12345\x1a"))
+	env.OpenFile(1, `C:\FUNCS.DAT`, object.InputFile, 128)
+
+	tests := []struct {
+		fn  string
+		exp object.Object
+	}{
+		{fn: "EOF", exp: &object.Integer{Value: 0}},
+		{fn: "LOC", exp: &object.Integer{Value: 0}},
+		{fn: "LOF", exp: &object.Integer{Value: 6}},
+	}
+
+	for _, tt := range tests {
+		fn := Builtins[tt.fn]
+		res := fn.Fn(env, fn, &object.Integer{Value: 1})
+		assert.Equalf(t, tt.exp, res, "%s(1) returned %s", tt.fn, res.Inspect())
+	}
+
+	fn := Builtins["LOC"]
+	res := fn.Fn(env, fn, &object.Integer{Value: 2})
+	assert.Equal(t, "Bad file number", res.Inspect())
+}
+
 func TestExp(t *testing.T) {
 	tests := []test{
 		{cmd: `10 EXP(2, 3)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 2}, &object.Integer{Value: 3}}, exp: &object.Error{Message: "Syntax error in 10"}},
@@ -446,7 +517,7 @@ func TestLeft(t *testing.T) {
 		{cmd: `30 LEFT$("George", 3)`, lnum: 30, inp: []object.Object{&object.String{Value: "George"}, &object.Integer{Value: 3}}, exp: &object.String{Value: "Geo"}},
 		//{`40 LEFT$("George", 0)`, &object.String{Value: ""}},
 		{cmd: `50 LEFT$("George", 300)`, lnum: 50, inp: []object.Object{&object.String{Value: "George"}, &object.Integer{Value: 300}}, exp: &object.Error{Message: "Illegal function call in 50"}},
-		{cmd: `60 X$ = MKS$(65999) : LEFT$( X$, 2)`, inp: []object.Object{res, &object.Integer{Value: 2}}, exp: &object.BStr{Value: []byte{0xcf, 0x01}}},
+		{cmd: `60 X$ = MKS$(65999) : LEFT$( X$, 2)`, inp: []object.Object{res, &object.Integer{Value: 2}}, exp: &object.BStr{Value: []byte{0x80, 0xe7}}},
 	}
 	runTests(t, "LEFT$", tests)
 }
@@ -504,9 +575,18 @@ func TestMID(t *testing.T) {
 		{cmd: `50 A$ = "Georgia" : MID$(A$,"4",3)`, lnum: 50, inp: []object.Object{
 			&object.TypedVar{TypeID: "$", Value: &object.String{Value: "Georgia"}}, &object.String{Value: "4"}, &object.Integer{Value: 3},
 		}, exp: &object.Error{Message: "Syntax error in 50"}},
-		{cmd: `60 A$ = MKD$(35456778) : MID$(A$,2,2)`, inp: []object.Object{
-			res, &object.Integer{Value: 2}, &object.Integer{Value: 2},
-		}, exp: &object.BStr{Value: []byte{0x07, 0x1d}}},
+		{cmd: `60 A$ = MKD$(35456778) : MID$(A$,5,2)`, inp: []object.Object{
+			res, &object.Integer{Value: 5}, &object.Integer{Value: 2},
+		}, exp: &object.BStr{Value: []byte{0xc2, 0x41}}},
+		{cmd: `70 A$ = "Georgia" : MID$(A$,6,5)`, inp: []object.Object{
+			&object.String{Value: "Georgia"}, &object.Integer{Value: 6}, &object.Integer{Value: 5},
+		}, exp: &object.String{Value: "ia"}},
+		{cmd: `80 A$ = "Georgia" : MID$(A$,10)`, inp: []object.Object{
+			&object.String{Value: "Georgia"}, &object.Integer{Value: 10},
+		}, exp: &object.String{Value: ""}},
+		{cmd: `90 A$ = "Georgia" : MID$(A$,4,0)`, inp: []object.Object{
+			&object.String{Value: "Georgia"}, &object.Integer{Value: 4}, &object.Integer{Value: 0},
+		}, exp: &object.String{Value: ""}},
 	}
 
 	runTests(t, "MID$", tests)
@@ -516,7 +596,8 @@ func TestMKD(t *testing.T) {
 	tests := []test{
 		{cmd: `10 MKD$("..")`, lnum: 10, inp: []object.Object{&object.String{Value: ".."}}, exp: &object.Error{Message: "Type mismatch in 10"}},
 		{cmd: `20 MKD$(1, 2)`, lnum: 20, inp: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 20"}},
-		{cmd: `30 MKD$(35)`, inp: []object.Object{&object.Integer{Value: 35}}, exp: &object.BStr{Value: []byte{0x23, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
+		{cmd: `30 MKD$(35)`, inp: []object.Object{&object.Integer{Value: 35}}, exp: &object.BStr{Value: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x86}}},
+		{cmd: `40 MKD$(-.1#)`, inp: []object.Object{&object.FloatDbl{Value: -0.1}}, exp: &object.BStr{Value: []byte{0xd0, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x7d}}},
 	}
 
 	runTests(t, "MKD$", tests)
@@ -537,7 +618,9 @@ func TestMKS(t *testing.T) {
 	tests := []test{
 		{cmd: `10 MKS$("..")`, lnum: 10, inp: []object.Object{&object.String{Value: ".."}}, exp: &object.Error{Message: "Type mismatch in 10"}},
 		{cmd: `20 MKS$(1, 2)`, lnum: 20, inp: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 20"}},
-		{cmd: `30 MKS$(35)`, inp: []object.Object{&object.Integer{Value: 35}}, exp: &object.BStr{Value: []byte{0x23, 0x00, 0x00, 0x00}}},
+		{cmd: `30 MKS$(35)`, inp: []object.Object{&object.Integer{Value: 35}}, exp: &object.BStr{Value: []byte{0x00, 0x00, 0x0c, 0x86}}},
+		{cmd: `40 MKS$(.1)`, inp: []object.Object{&object.FloatSgl{Value: 0.1}}, exp: &object.BStr{Value: []byte{0xcd, 0xcc, 0x4c, 0x7d}}},
+		{cmd: `50 MKS$(1D+39)`, lnum: 50, inp: []object.Object{&object.FloatDbl{Value: 1e39}}, exp: &object.Error{Message: "Overflow in 50"}},
 	}
 
 	runTests(t, "MKS$", tests)
@@ -554,6 +637,33 @@ func TestOct(t *testing.T) {
 	runTests(t, "OCT$", tests)
 }
 
+func TestPos(t *testing.T) {
+	tests := []struct {
+		inp []object.Object
+		exp object.Object
+		col int
+	}{
+		{inp: []object.Object{&object.Integer{Value: 0}}, exp: &object.Integer{Value: 1}},
+		{inp: []object.Object{&object.Integer{Value: 0}}, exp: &object.Integer{Value: 21}, col: 20},
+		{inp: []object.Object{&object.Integer{Value: 0}, &object.Integer{Value: 0}}, exp: &object.Error{Code: 2, Message: "Syntax error"}},
+		{inp: []object.Object{&object.String{Value: "fred"}}, exp: &object.Error{Code: 13, Message: "Type mismatch"}},
+	}
+
+	for _, tt := range tests {
+		fn, ok := Builtins["POS"]
+
+		assert.True(t, ok, "Failed to find POS() function")
+
+		var mt mocks.MockTerm
+		mocks.InitMockTerm(&mt)
+		*mt.Col = tt.col
+		env := object.NewTermEnvironment(mt)
+		res := fn.Fn(env, fn, tt.inp...)
+
+		assert.EqualValuesf(t, tt.exp, res, "call to POS(%s) returned %T", tt.inp[0].Inspect(), res)
+	}
+}
+
 func TestRight(t *testing.T) {
 	tests := []test{
 		{cmd: `10 RIGHT$("Fred")`, lnum: 10, inp: []object.Object{&object.String{Value: "Fred"}}, exp: &object.Error{Message: "Syntax error in 10"}},
@@ -571,7 +681,9 @@ func TestRnd(t *testing.T) {
 	tests := []test{
 		{cmd: `10 RND(5, 5)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 5}, &object.Integer{Value: 5}}, exp: &object.Error{Message: "Syntax error in 10"}},
 		{cmd: `20 RND("fred")`, lnum: 20, inp: []object.Object{&object.String{Value: "Fred"}}, exp: &object.Error{Message: "Type mismatch in 20"}},
-		{cmd: `30 RND(0)`, inp: []object.Object{&object.Integer{Value: 0}}, exp: &object.FloatSgl{Value: 0.615608156}},
+		{cmd: `30 RND(0)`, inp: []object.Object{&object.Integer{Value: 0}}, exp: &object.FloatSgl{Value: 0.31163514}},
+		{cmd: `40 RND`, exp: &object.FloatSgl{Value: 0.12135011}},
+		{cmd: `50 RND(1)`, inp: []object.Object{&object.Integer{Value: 1}}, exp: &object.FloatSgl{Value: 0.12135011}},
 	}
 	runTests(t, "RND", tests)
 }
@@ -623,6 +735,17 @@ func TestSpaces(t *testing.T) {
 	runTests(t, "SPACE$", tests)
 }
 
+func TestSpc(t *testing.T) {
+	tests := []test{
+		{cmd: `10 SPC(5, 2)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 5}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 10"}},
+		{cmd: `20 SPC("fred")`, lnum: 20, inp: []object.Object{&object.String{Value: "fred"}}, exp: &object.Error{Message: "Type mismatch in 20"}},
+		{cmd: `30 SPC(256)`, lnum: 30, inp: []object.Object{&object.Integer{Value: 256}}, exp: &object.Error{Message: "Illegal function call in 30"}},
+		{cmd: `40 SPC(3)`, inp: []object.Object{&object.Integer{Value: 3}}, exp: &object.String{Value: "   "}},
+	}
+
+	runTests(t, "SPC", tests)
+}
+
 func TestSqr(t *testing.T) {
 	tests := []test{
 		{cmd: `10 SQR(5, 2)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 5}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 10"}},
@@ -639,9 +762,13 @@ func TestStrs(t *testing.T) {
 		{cmd: `10 STR$(5, 2)`, lnum: 10, inp: []object.Object{&object.Integer{Value: 5}, &object.Integer{Value: 2}}, exp: &object.Error{Message: "Syntax error in 10"}},
 		{cmd: `20 STR$("fred")`, lnum: 20, inp: []object.Object{&object.String{Value: "fred"}}, exp: &object.Error{Message: "Type mismatch in 20"}},
 		{cmd: `30 STR$(-1)`, inp: []object.Object{&object.Integer{Value: -1}}, exp: &object.String{Value: "-1"}},
-		{cmd: `40 STR$(256)`, inp: []object.Object{&object.Integer{Value: 256}}, exp: &object.String{Value: "256"}},
-		{cmd: `50 STR$(2)`, inp: []object.Object{&object.Integer{Value: 2}}, exp: &object.String{Value: "2"}},
-		{cmd: `60 STR$(1.5)`, inp: []object.Object{&object.FloatSgl{Value: 1.5}}, exp: &object.String{Value: "1.5"}},
+		{cmd: `40 STR$(256)`, inp: []object.Object{&object.Integer{Value: 256}}, exp: &object.String{Value: " 256"}},
+		{cmd: `50 STR$(2)`, inp: []object.Object{&object.Integer{Value: 2}}, exp: &object.String{Value: " 2"}},
+		{cmd: `60 STR$(1.5)`, inp: []object.Object{&object.FloatSgl{Value: 1.5}}, exp: &object.String{Value: " 1.5"}},
+		{cmd: `70 STR$(.25)`, inp: []object.Object{&object.FloatSgl{Value: 0.25}}, exp: &object.String{Value: " .25"}},
+		{cmd: `80 STR$(1E+20)`, inp: []object.Object{&object.FloatSgl{Value: 1e20}}, exp: &object.String{Value: " 1E+20"}},
+		{cmd: `90 STR$(1/3#)`, inp: []object.Object{&object.FloatDbl{Value: 1.0 / 3}}, exp: &object.String{Value: " .3333333333333333"}},
+		{cmd: `100 STR$(X)`, inp: []object.Object{&object.TypedVar{Value: &object.Integer{Value: 7}, TypeID: "%"}}, exp: &object.String{Value: " 7"}},
 	}
 
 	runTests(t, "STR$", tests)
@@ -701,6 +828,24 @@ func TestTan(t *testing.T) {
 	runTests(t, "TAN", tests)
 }
 
+func TestTime(t *testing.T) {
+	tests := []test{
+		{cmd: `10 TIME$`, exp: &object.String{Value: "13:02:03"}},
+		{cmd: `20 TIME$(1)`, lnum: 20, inp: []object.Object{&object.Integer{Value: 1}}, exp: &object.Error{Message: "Syntax error in 20"}},
+	}
+
+	runClockTests(t, "TIME$", tests)
+}
+
+func TestTimer(t *testing.T) {
+	tests := []test{
+		{cmd: `10 TIMER`, exp: &object.FloatSgl{Value: 46923.45}},
+		{cmd: `20 TIMER(1)`, lnum: 20, inp: []object.Object{&object.Integer{Value: 1}}, exp: &object.Error{Message: "Syntax error in 20"}},
+	}
+
+	runClockTests(t, "TIMER", tests)
+}
+
 func TestVal(t *testing.T) {
 	tests := []test{
 		{cmd: `10 VAL("5", "2")`, lnum: 10, inp: []object.Object{&object.String{Value: "5"}, &object.String{Value: "2"}}, exp: &object.Error{Message: "Syntax error in 10"}},
@@ -757,6 +902,10 @@ func Test_FixType(t *testing.T) {
 		{cmd: "FixType(float32)", inp: float32(3000), exp: &object.Integer{Value: 3000}},
 		{cmd: "FixType(float64(3000))", inp: float64(3000), exp: &object.Integer{Value: 3000}},
 		{cmd: "FixType(float64(3276912345677))", inp: float64(3276912345677), exp: &object.FloatDbl{Value: 3276912345677}},
+		{cmd: "FixType(float32(40000))", inp: float32(40000), exp: &object.FloatSgl{Value: 40000}},
+		{cmd: "FixType(float64(1e9))", inp: float64(1e9), exp: &object.FloatSgl{Value: 1e9}},
+		{cmd: "FixType(float32(+Inf))", inp: float32(math.Inf(1)), lnum: 10, exp: &object.Error{Message: "Overflow in 10"}},
+		{cmd: "FixType(float64(-Inf))", inp: math.Inf(-1), lnum: 10, exp: &object.Error{Message: "Overflow in 10"}},
 		{cmd: `FixType("Fred")`, inp: "Fred", lnum: 10, exp: &object.Error{Message: "Type mismatch in 10"}},
 	}
 
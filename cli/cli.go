@@ -52,6 +52,8 @@ func evalKeyCodes(keys []byte, env *object.Environment) {
 		env.Terminal().Locate(row+1, col)
 		env.Terminal().Print("\x1b[P")
 	//env.Terminal().Print("\b\a")
+	case 0x00: // extended key, the scan code isn't used yet
+		env.Terminal().ReadKeys(1)
 	case 0x03: // ctrl-c
 		env.Terminal().Println("")
 		if env.GetSetting(settings.Auto) != nil {
@@ -178,6 +180,26 @@ func prompt(env *object.Environment) {
 	env.SaveSetting(settings.Auto, auto)
 }
 
+// RunProgram runs the program already loaded into env with no one
+// at the keyboard, there is no OK prompt when it finishes
+// returns false if the program stopped on an error
+func RunProgram(env *object.Environment) bool {
+	rc := evaluator.Eval(&ast.RunCommand{}, env.StatementIter(), env)
+	env.CmdComplete()
+
+	switch msg := rc.(type) {
+	case *object.Error:
+		env.Terminal().Println(msg.Message)
+		return false
+	case *object.HaltSignal:
+		if len(msg.Msg) > 0 {
+			env.Terminal().Println(msg.Msg)
+		}
+	}
+
+	return true
+}
+
 // just display the error and then the prompt
 func giveError(err string, env *object.Environment) {
 	env.Terminal().Println(err)
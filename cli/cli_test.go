@@ -5,9 +5,12 @@ import (
 	"testing"
 
 	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/lexer"
 	"github.com/navionguy/basicwasm/mocks"
 	"github.com/navionguy/basicwasm/object"
+	"github.com/navionguy/basicwasm/parser"
 	"github.com/navionguy/basicwasm/settings"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_EvalKeyCodes(t *testing.T) {
@@ -21,6 +24,7 @@ func Test_EvalKeyCodes(t *testing.T) {
 		{inp: "Down arrow", key: []byte{0x7f}, exp: []string{"\x1b[P"}},              // move cursor down
 		{inp: "F", key: []byte("F"), exp: []string{"F"}},                             // just echo the key
 		{inp: "ctrl-c", key: []byte{0x03}, exp: []string{""}},                        // nothing visibile, need to check state ToDo
+		{inp: "H", key: []byte{0x00}},                                                // extended key, scan code gets dropped
 		{inp: "ctrl-c auto", key: []byte{0x03}, exp: []string{"", "OK"}, auto: true}, // should turn off auto ToDo check that
 	}
 
@@ -111,3 +115,32 @@ func Test_GiveError(t *testing.T) {
 	}
 
 }
+
+func Test_RunProgram(t *testing.T) {
+	tests := []struct {
+		src []string
+		exp []string
+		ok  bool
+	}{
+		{src: []string{`10 PRINT "HELLO"`}, exp: []string{"HELLO", ""}, ok: true},
+		{src: []string{`10 PRINT "HI"`, `20 END`, `30 PRINT "NO"`}, exp: []string{"HI", ""}, ok: true},
+		{src: []string{`10 X = 1 / 0`}, exp: []string{"Division by zero in 10"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		trm := mocks.MockTerm{}
+		mocks.InitMockTerm(&trm)
+		trm.ExpMsg = &mocks.Expector{Exp: tt.exp}
+		env := object.NewTermEnvironment(trm)
+		for _, line := range tt.src {
+			p := parser.New(lexer.New(line))
+			p.ParseProgram(env)
+		}
+
+		ok := RunProgram(env)
+
+		assert.Equal(t, tt.ok, ok, "RunProgram(%v) returned wrong result", tt.src)
+		assert.False(t, trm.ExpMsg.Failed, "RunProgram(%v) unexpected output", tt.src)
+		assert.Empty(t, trm.ExpMsg.Exp, "RunProgram(%v) missing output", tt.src)
+	}
+}
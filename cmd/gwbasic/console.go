@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stdConsole implements object.Console over stdin and stdout
+// there is no screen to look at, so he only keeps track of where
+// the cursor would be and what has been printed on the current row
+type stdConsole struct {
+	keys chan byte // keys read from stdin, closed at the end of input
+	out  *bufio.Writer
+	log  io.Writer
+	row  int    // cursor row, top of the screen is zero
+	col  int    // cursor column, left edge is zero
+	line []byte // text printed on the cursor row
+	esc  []byte // escape sequence collected so far
+}
+
+// screen rows, CSRLIN never goes past the bottom one
+const stdRows = 25
+
+// newStdConsole builds a console that reads keys from in, prints to out
+// and sends log messages to log
+func newStdConsole(in io.Reader, out io.Writer, log io.Writer) *stdConsole {
+	sc := &stdConsole{keys: make(chan byte, 256), out: bufio.NewWriter(out), log: log}
+	go sc.readStdin(bufio.NewReader(in))
+
+	return sc
+}
+
+// readStdin passes keys along as they arrive so INKEY$ doesn't have to wait
+func (sc *stdConsole) readStdin(in *bufio.Reader) {
+	for {
+		bt, err := in.ReadByte()
+		if err != nil {
+			close(sc.keys)
+			return
+		}
+		sc.keys <- bt
+	}
+}
+
+// Cls clears the screen, all that means here is the cursor goes home
+func (sc *stdConsole) Cls() {
+	sc.row, sc.col = 0, 0
+	sc.line = nil
+}
+
+// Print sends msg to stdout, escape sequences meant for a
+// terminal are dropped as are carriage returns
+func (sc *stdConsole) Print(msg string) {
+	for i := 0; i < len(msg); i++ {
+		sc.printByte(msg[i])
+	}
+}
+
+// printByte writes one byte and moves the cursor to match
+func (sc *stdConsole) printByte(bt byte) {
+	if len(sc.esc) > 0 {
+		sc.esc = append(sc.esc, bt)
+		// a CSI sequence runs until its final byte, '@' through '~'
+		if (len(sc.esc) > 2) || (bt != '[') {
+			if (bt >= '@') && (bt <= '~') {
+				sc.esc = nil
+			}
+		}
+		return
+	}
+
+	switch bt {
+	case 0x1b:
+		sc.esc = append(sc.esc, bt)
+		return
+	case '\r':
+		sc.col = 0
+		return
+	case '\n':
+		sc.newLine()
+	case '\b':
+		if sc.col > 0 {
+			sc.col--
+		}
+	case '\a':
+	default:
+		sc.putChar(bt)
+	}
+
+	sc.out.WriteByte(bt)
+}
+
+// newLine moves the cursor down a row, the screen scrolls at the bottom
+func (sc *stdConsole) newLine() {
+	sc.col = 0
+	sc.line = nil
+	if sc.row < stdRows-1 {
+		sc.row++
+	}
+}
+
+// putChar remembers bt at the cursor position and moves the cursor on
+func (sc *stdConsole) putChar(bt byte) {
+	for len(sc.line) <= sc.col {
+		sc.line = append(sc.line, ' ')
+	}
+	sc.line[sc.col] = bt
+	sc.col++
+}
+
+// Println prints msg followed by a new line
+func (sc *stdConsole) Println(msg string) {
+	sc.Print(msg + "\r\n")
+}
+
+// Locate moves the cursor to row, col
+// NOTE: the upper left screen position is 1,1
+func (sc *stdConsole) Locate(row, col int) {
+	if row-1 != sc.row {
+		sc.line = nil
+	}
+	sc.row, sc.col = row-1, col-1
+}
+
+// SetWidth clears the screen, stdout has no width to change
+func (sc *stdConsole) SetWidth(cols int) {
+	sc.Cls()
+}
+
+// Log sends msg to the log writer, usually stderr
+func (sc *stdConsole) Log(msg string) {
+	fmt.Fprintln(sc.log, msg)
+}
+
+// GetCursor returns the cursor position
+// NOTE: the upper left screen position is 0,0
+func (sc *stdConsole) GetCursor() (int, int) {
+	return sc.row, sc.col
+}
+
+// Read returns what was printed on the cursor row, any other
+// row has nothing that can be read back
+func (sc *stdConsole) Read(col, row, length int) string {
+	if (row != sc.row) || (col < 0) || (col >= len(sc.line)) {
+		return ""
+	}
+
+	end := col + length
+	if end > len(sc.line) {
+		end = len(sc.line)
+	}
+
+	return strings.TrimRight(string(sc.line[col:end]), " ")
+}
+
+// ReadKeys reads count keys from stdin, once stdin has
+// been closed he returns whatever he got
+func (sc *stdConsole) ReadKeys(count int) []byte {
+	var keys []byte
+
+	// anything printed so far should be seen before waiting on input
+	sc.Flush()
+	for len(keys) < count {
+		bt, ok := <-sc.keys
+		if !ok {
+			break
+		}
+		keys = append(keys, bt)
+	}
+
+	return keys
+}
+
+// InKey returns the next key from stdin if one has arrived
+func (sc *stdConsole) InKey() string {
+	sc.Flush()
+	select {
+	case bt, ok := <-sc.keys:
+		if ok {
+			return string([]byte{bt})
+		}
+	default:
+	}
+
+	return ""
+}
+
+// SoundBell sends a bell character
+func (sc *stdConsole) SoundBell() {
+	sc.Print("\a")
+}
+
+// BreakCheck always returns false, CTRL-C simply ends the process
+func (sc *stdConsole) BreakCheck() bool {
+	return false
+}
+
+// Flush makes sure everything printed has been written out
+func (sc *stdConsole) Flush() {
+	sc.out.Flush()
+}
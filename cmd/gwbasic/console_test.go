@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StdConsolePrint(t *testing.T) {
+	tests := []struct {
+		inp []string
+		exp string
+		row int
+		col int
+	}{
+		{inp: []string{"HELLO"}, exp: "HELLO", col: 5},
+		{inp: []string{"HI", "\r\n", "THERE"}, exp: "HI\nTHERE", row: 1, col: 5},
+		{inp: []string{"\x1b[1;24rX"}, exp: "X", col: 1},
+		{inp: []string{"\x1b", "[2;10", "rX"}, exp: "X", col: 1},
+		{inp: []string{"AB\b \b"}, exp: "AB\b \b", col: 1},
+		{inp: []string{"\a"}, exp: "\a"},
+		{inp: []string{strings.Repeat("\n", 30)}, exp: strings.Repeat("\n", 30), row: 24},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		sc := newStdConsole(strings.NewReader(""), &out, &out)
+		for _, msg := range tt.inp {
+			sc.Print(msg)
+		}
+		sc.Flush()
+
+		row, col := sc.GetCursor()
+		assert.Equal(t, tt.exp, out.String(), "Print(%q) wrong output", tt.inp)
+		assert.Equal(t, tt.row, row, "Print(%q) wrong row", tt.inp)
+		assert.Equal(t, tt.col, col, "Print(%q) wrong col", tt.inp)
+	}
+}
+
+func Test_StdConsoleRead(t *testing.T) {
+	tests := []struct {
+		prt string
+		row int
+		col int
+		len int
+		exp string
+	}{
+		{prt: "HELLO WORLD", col: 6, len: 5, exp: "WORLD"},
+		{prt: "HELLO WORLD", col: 0, len: 80, exp: "HELLO WORLD"},
+		{prt: "HELLO   ", col: 0, len: 80, exp: "HELLO"},
+		{prt: "HELLO", col: 20, len: 5},
+		{prt: "HELLO", row: 3, len: 5},
+		{prt: "OLD\r\nNEW", row: 1, len: 3, exp: "NEW"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		sc := newStdConsole(strings.NewReader(""), &out, &out)
+		sc.Print(tt.prt)
+
+		assert.Equal(t, tt.exp, sc.Read(tt.col, tt.row, tt.len), "Read(%d, %d, %d) after %q", tt.col, tt.row, tt.len, tt.prt)
+	}
+}
+
+func Test_StdConsoleLocate(t *testing.T) {
+	var out bytes.Buffer
+	sc := newStdConsole(strings.NewReader(""), &out, &out)
+
+	sc.Print("HELLO")
+	sc.Locate(1, 2)
+	row, col := sc.GetCursor()
+	assert.Equal(t, 0, row, "Locate same row")
+	assert.Equal(t, 1, col, "Locate same row")
+	assert.Equal(t, "ELLO", sc.Read(1, 0, 80), "Locate same row keeps the text")
+
+	sc.Locate(5, 10)
+	row, col = sc.GetCursor()
+	assert.Equal(t, 4, row, "Locate new row")
+	assert.Equal(t, 9, col, "Locate new row")
+	assert.Equal(t, "", sc.Read(0, 4, 80), "Locate new row starts empty")
+
+	sc.Cls()
+	row, col = sc.GetCursor()
+	assert.Equal(t, 0, row, "Cls")
+	assert.Equal(t, 0, col, "Cls")
+}
+
+func Test_StdConsoleReadKeys(t *testing.T) {
+	var out bytes.Buffer
+	sc := newStdConsole(strings.NewReader("ABC"), &out, &out)
+
+	sc.Print("PROMPT")
+	assert.Equal(t, []byte("AB"), sc.ReadKeys(2), "ReadKeys(2)")
+	assert.Equal(t, "PROMPT", out.String(), "ReadKeys didn't flush the output")
+	assert.Equal(t, []byte("C"), sc.ReadKeys(2), "ReadKeys past the end of input")
+	assert.Empty(t, sc.ReadKeys(1), "ReadKeys after input closed")
+	assert.Empty(t, sc.InKey(), "InKey after input closed")
+	assert.False(t, sc.BreakCheck(), "BreakCheck")
+}
+
+func Test_StdConsoleInKey(t *testing.T) {
+	var out bytes.Buffer
+	sc := newStdConsole(strings.NewReader("AB"), &out, &out)
+
+	// wait for the first key to show up
+	assert.Equal(t, []byte("A"), sc.ReadKeys(1), "ReadKeys(1)")
+	assert.Eventually(t, func() bool { return len(sc.keys) > 0 }, time.Second, time.Millisecond, "key never arrived")
+	assert.Equal(t, "B", sc.InKey(), "InKey with a key waiting")
+	assert.Equal(t, "", sc.InKey(), "InKey with nothing waiting")
+}
@@ -0,0 +1,72 @@
+// gwbasic runs BASIC programs from a shell, no browser needed
+//
+//	gwbasic run [-drive dir] [-readonly] prog.bas
+//
+// Program output goes to stdout and keyboard input is read from stdin.
+// Drive C: is mapped onto the directory holding the program unless
+// -drive says otherwise.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/navionguy/basicwasm/cli"
+	"github.com/navionguy/basicwasm/driveclient"
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/object"
+)
+
+const usage = "usage: gwbasic run [-drive dir] [-readonly] prog.bas"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run does the work of main, returning the exit code
+// 0 if the program ran to completion, 1 if it failed and 2 for bad arguments
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if (len(args) == 0) || (args[0] != "run") {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	fset := flag.NewFlagSet("run", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	drive := fset.String("drive", "", "directory to use as drive C:, defaults to the program's directory")
+	readOnly := fset.Bool("readonly", false, "refuse changes to drive C:")
+
+	if (fset.Parse(args[1:]) != nil) || (fset.NArg() != 1) {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	prog := fset.Arg(0)
+	f, err := os.Open(prog)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer f.Close()
+
+	if len(*drive) == 0 {
+		*drive = filepath.Dir(prog)
+	}
+
+	con := newStdConsole(stdin, stdout, stderr)
+	defer con.Flush()
+
+	env := object.NewTermEnvironment(con)
+	env.SetClient(driveclient.New(*drive, *readOnly))
+
+	fileserv.ParseFile(bufio.NewReader(f), env)
+	if !cli.RunProgram(env) {
+		return 1
+	}
+
+	return 0
+}
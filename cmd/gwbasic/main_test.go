@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Run(t *testing.T) {
+	tests := []struct {
+		args  []string
+		src   string
+		keys  string
+		rc    int
+		out   string
+		err   string
+		gone  string // file that should have been removed
+		there string // file that should still be there
+	}{
+		{args: []string{}, rc: 2, err: usage},
+		{args: []string{"list", "prog.bas"}, rc: 2, err: usage},
+		{args: []string{"run"}, rc: 2, err: usage},
+		{args: []string{"run", "-bogus", "prog.bas"}, rc: 2, err: usage},
+		{args: []string{"run", "none.bas"}, rc: 1, err: "no such file"},
+		{args: []string{"run", "prog.bas"}, src: "10 PRINT \"HELLO\"\n", out: "HELLO\n"},
+		{args: []string{"run", "prog.bas"}, src: "10 X = 2\r\n20 PRINT X * 3\r\n", out: " 6 \n"},
+		{args: []string{"run", "prog.bas"}, src: "10 PRINT 1 / 0\n", rc: 1, out: "Division by zero in 10\n"},
+		{args: []string{"run", "prog.bas"}, src: "10 INPUT A$\n20 PRINT \"HI \"; A$\n", keys: "BOB\n", out: "? BOB\nHI BOB\n"},
+		{args: []string{"run", "prog.bas"}, src: "10 KILL \"junk.txt\"\n", gone: "junk.txt"},
+		{args: []string{"run", "-readonly", "prog.bas"}, src: "10 KILL \"junk.txt\"\n", rc: 1, out: "Permission Denied in 10\n", there: "junk.txt"},
+		{args: []string{"run", "-readonly", "prog.bas"}, src: "10 OPEN \"out.txt\" FOR OUTPUT AS #1\n20 PRINT #1, \"DATA\"\n30 CLOSE #1\n40 PRINT \"DONE\"\n", out: "DONE\n"},
+	}
+
+	for _, tt := range tests {
+		dir, err := ioutil.TempDir("", "gwbasic")
+		assert.Nil(t, err, "couldn't create temp dir")
+		ioutil.WriteFile(filepath.Join(dir, "junk.txt"), []byte("junk"), 0644)
+
+		// program names are relative to the temp dir
+		args := append([]string{}, tt.args...)
+		if len(args) > 1 {
+			args[len(args)-1] = filepath.Join(dir, args[len(args)-1])
+		}
+		if len(tt.src) > 0 {
+			ioutil.WriteFile(args[len(args)-1], []byte(tt.src), 0644)
+		}
+
+		var stdout, stderr bytes.Buffer
+		rc := run(args, strings.NewReader(tt.keys), &stdout, &stderr)
+
+		assert.Equal(t, tt.rc, rc, "run(%v) wrong exit code", tt.args)
+		assert.Equal(t, tt.out, stdout.String(), "run(%v) wrong output", tt.args)
+		assert.Contains(t, stderr.String(), tt.err, "run(%v) wrong error", tt.args)
+
+		if len(tt.gone) > 0 {
+			_, err := os.Stat(filepath.Join(dir, tt.gone))
+			assert.True(t, os.IsNotExist(err), "run(%v) didn't remove %s", tt.args, tt.gone)
+		}
+		if len(tt.there) > 0 {
+			_, err := os.Stat(filepath.Join(dir, tt.there))
+			assert.Nil(t, err, "run(%v) removed %s", tt.args, tt.there)
+		}
+
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,170 @@
+// Package conformance runs BASIC programs through the interpreter and
+// compares what they print against golden files.
+//
+// Each program in the programs directory, NAME.BAS, can have keyboard
+// input in NAME.KEYS and has its expected output in NAME.GOLDEN. The
+// golden file holds the full transcript of everything printed followed
+// by the final contents of the screen.
+//
+//	go test ./conformance                        check the programs in testdata
+//	go test ./conformance -programs ~/hamcalc    check another set of programs
+//	go test ./conformance -update                rewrite the golden files
+package conformance
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/navionguy/basicwasm/cli"
+	"github.com/navionguy/basicwasm/driveclient"
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/object"
+	"github.com/navionguy/basicwasm/screen"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	programs = flag.String("programs", "testdata", "directory of programs to run")
+	update   = flag.Bool("update", false, "rewrite the golden files")
+	maxSteps = flag.Int("steps", 100000, "statements a program can run before it is stopped")
+)
+
+// recorder keeps a transcript of everything printed to the screen
+// and breaks into programs that run too long
+type recorder struct {
+	*screen.Screen
+	out   strings.Builder
+	steps int
+}
+
+// Print records msg and then prints it to the screen
+func (rec *recorder) Print(msg string) {
+	rec.out.WriteString(msg)
+	rec.Screen.Print(msg)
+}
+
+// Println records msg and then prints it with a new line
+func (rec *recorder) Println(msg string) {
+	rec.Print(msg + "\r\n")
+}
+
+// BreakCheck is called after every statement, once the program has
+// used up its statements it acts like CTRL-C was typed
+func (rec *recorder) BreakCheck() bool {
+	rec.steps++
+
+	return (rec.steps == *maxSteps) || rec.Screen.BreakCheck()
+}
+
+func Test_Conformance(t *testing.T) {
+	progs := findPrograms(t, *programs)
+
+	for _, prog := range progs {
+		prog := prog
+		t.Run(filepath.Base(prog), func(t *testing.T) {
+			res := runProgram(t, prog)
+			golden := strings.TrimSuffix(prog, filepath.Ext(prog)) + ".golden"
+
+			if *update {
+				assert.Nil(t, ioutil.WriteFile(golden, []byte(res), 0644), "couldn't write %s", golden)
+				return
+			}
+
+			exp, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s, run with -update to create it", err)
+			}
+
+			assert.Equal(t, string(exp), res, "%s doesn't match %s", prog, golden)
+		})
+	}
+}
+
+// findPrograms lists the .BAS files in dir, in either case
+func findPrograms(t *testing.T, dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var progs []string
+	for _, fl := range files {
+		if !fl.IsDir() && strings.EqualFold(filepath.Ext(fl.Name()), ".bas") {
+			progs = append(progs, filepath.Join(dir, fl.Name()))
+		}
+	}
+	sort.Strings(progs)
+
+	if len(progs) == 0 {
+		t.Fatalf("no programs found in %s", dir)
+	}
+
+	return progs
+}
+
+// runProgram runs prog with its keyboard input and returns the
+// transcript and final screen, the contents of a golden file
+func runProgram(t *testing.T, prog string) string {
+	f, err := os.Open(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rec := &recorder{Screen: screen.New(80)}
+	keys, err := ioutil.ReadFile(strings.TrimSuffix(prog, filepath.Ext(prog)) + ".keys")
+	if err == nil {
+		rec.TypeKeys(string(keys))
+	}
+
+	env := object.NewTermEnvironment(rec)
+	env.SetClient(driveclient.New(filepath.Dir(prog), true))
+
+	fileserv.ParseFile(bufio.NewReader(f), env)
+	cli.RunProgram(env)
+
+	return fmt.Sprintf("--- transcript\n%s\n--- screen\n%s\n", readable(rec.out.String()), strings.TrimRight(rec.String(), "\n"))
+}
+
+// readable turns the transcript into lines of text, control
+// characters other than the line endings are shown as ^X
+func readable(out string) string {
+	var res strings.Builder
+
+	for _, r := range strings.Replace(out, "\r\n", "\n", -1) {
+		switch {
+		case r == '\n':
+			res.WriteRune(r)
+		case (r < ' ') || (r == 0x7f):
+			res.WriteString("^" + string(r^0x40))
+		default:
+			res.WriteRune(r)
+		}
+	}
+
+	return res.String()
+}
+
+func Test_Readable(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+	}{
+		{inp: "HELLO\r\n", exp: "HELLO\n"},
+		{inp: "\x1b[5;7r", exp: "^[[5;7r"},
+		{inp: "AB\b \b\r", exp: "AB^H ^H^M"},
+		{inp: "\a\x7f", exp: "^G^?"},
+		{inp: "░", exp: "░"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, readable(tt.inp), "readable(%q)", tt.inp)
+	}
+}
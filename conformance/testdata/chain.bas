@@ -0,0 +1,3 @@
+10 REM load another program from the drive
+20 PRINT "CHAINING"
+30 CHAIN "hello.bas"
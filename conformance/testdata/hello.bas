@@ -0,0 +1,7 @@
+10 REM simple output and arithmetic
+20 PRINT "HELLO, WORLD"
+30 X = 6 : Y = 7
+40 PRINT "6 * 7 ="; X * Y
+50 FOR I = 1 TO 3 : PRINT I; : NEXT I
+60 PRINT
+70 PRINT "DONE"
@@ -0,0 +1,4 @@
+10 REM keyboard input
+20 INPUT "WHAT IS YOUR NAME"; N$
+30 INPUT "HOW OLD ARE YOU"; A
+40 PRINT "HELLO "; N$; ", NEXT YEAR YOU WILL BE"; A + 1
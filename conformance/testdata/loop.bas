@@ -0,0 +1,2 @@
+10 REM runaway loop stopped by the statement limit
+20 GOTO 20
@@ -0,0 +1,7 @@
+10 REM cursor placement and the VIEW PRINT window
+20 CLS
+30 LOCATE 1, 30 : PRINT "TITLE"
+40 LOCATE 25, 1 : PRINT "STATUS";
+50 VIEW PRINT 3 TO 6
+60 FOR I = 1 TO 6 : PRINT "LINE"; I : NEXT I
+70 VIEW PRINT
@@ -0,0 +1,44 @@
+// Package driveclient lets the interpreter reach a drive without a server.
+// It is kept out of fileserv so that httptest never ends up in the
+// web assembly build.
+package driveclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/navionguy/basicwasm/fileserv"
+)
+
+// DriveClient answers the requests the interpreter would send to the
+// server by handing them straight to the drive routes, no network needed
+type DriveClient struct {
+	rtr *mux.Router
+}
+
+// New maps drive C: onto the directory dir
+func New(dir string, readOnly bool) *DriveClient {
+	rtr := mux.NewRouter()
+	fileserv.WrapDrive(rtr, "drivec", dir, readOnly)
+
+	return &DriveClient{rtr: rtr}
+}
+
+// Do runs the request through the drive routes
+func (dc *DriveClient) Do(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	dc.rtr.ServeHTTP(rec, req)
+
+	return rec.Result(), nil
+}
+
+// Get builds a GET request for url and runs it
+func (dc *DriveClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return dc.Do(req)
+}
@@ -0,0 +1,51 @@
+package driveclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/mocks"
+	"github.com/navionguy/basicwasm/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DriveClient(t *testing.T) {
+	root, err := ioutil.TempDir("", "drivec")
+	assert.Nil(t, err, "couldn't create temp dir")
+	defer os.RemoveAll(root)
+	ioutil.WriteFile(filepath.Join(root, "prog.bas"), []byte("10 PRINT"), 0644)
+
+	var trm mocks.MockTerm
+	mocks.InitMockTerm(&trm)
+	env := object.NewTermEnvironment(trm)
+	env.SetClient(New(root, false))
+
+	rdr, rc := fileserv.GetFile("prog.bas", env)
+	if assert.Nil(t, rc, "GetFile through the drive client failed") {
+		line, _ := rdr.ReadString('\n')
+		assert.Equal(t, "10 PRINT", line, "GetFile got the wrong contents")
+	}
+
+	_, rc = fileserv.GetFile("none.bas", env)
+	assert.NotNil(t, rc, "GetFile found a file that isn't there")
+
+	assert.Nil(t, fileserv.PutFile("new.bas", []byte("20 END"), env), "PutFile through the drive client failed")
+	data, _ := ioutil.ReadFile(filepath.Join(root, "new.bas"))
+	assert.Equal(t, "20 END", string(data), "PutFile wrote the wrong contents")
+
+	// characters that mean something in a URL are still part of the name
+	assert.Nil(t, fileserv.PutFile("A#1.DAT", []byte("HASH"), env), "PutFile of a name with a # failed")
+	data, _ = ioutil.ReadFile(filepath.Join(root, "a#1.dat"))
+	assert.Equal(t, "HASH", string(data), "PutFile of a name with a # wrote the wrong file")
+	rdr, rc = fileserv.GetFile("A#1.DAT", env)
+	if assert.Nil(t, rc, "GetFile of a name with a # failed") {
+		line, _ := rdr.ReadString('\n')
+		assert.Equal(t, "HASH", line, "GetFile of a name with a # got the wrong contents")
+	}
+
+	env.SetClient(New(root, true))
+	assert.NotNil(t, fileserv.PutFile("ro.bas", []byte("20 END"), env), "PutFile wrote to a read only drive")
+}
@@ -0,0 +1,200 @@
+package evaluator
+
+import (
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/decimal"
+	"github.com/navionguy/basicwasm/object"
+)
+
+const (
+	maxArrayDims     = 255   // most subscripts an array can have
+	maxArrayElements = 65535 // past this, the original would run out of memory
+)
+
+// DIM allocates each array, they can only be dimensioned once
+func evalDimStatement(dim *ast.DimStatement, code *ast.Code, env *object.Environment) object.Object {
+	for _, id := range dim.Vars {
+		if !id.Array || (len(id.Index) == 0) {
+			return object.StdError(env, berrors.Syntax)
+		}
+
+		if env.Exists(id.Value) {
+			return object.StdError(env, berrors.DuplicateDefinition)
+		}
+
+		bounds, err := evalArrayBounds(id.Index, code, env)
+		if err != nil {
+			return err
+		}
+
+		typeid, _ := parseVarName(id.Value, env)
+		obj := newArray(typeid, bounds, env)
+		if isError(obj) {
+			return obj
+		}
+		env.Set(id.Value, obj)
+	}
+
+	return nil
+}
+
+// evaluate the upper bound of each dimension
+func evalArrayBounds(dims []*ast.IndexExpression, code *ast.Code, env *object.Environment) ([]int16, object.Object) {
+	bounds := []int16{}
+	for _, d := range dims {
+		val := Eval(d.Index, code, env)
+		if isError(val) {
+			return nil, val
+		}
+
+		ub, err := coerceIndex(val, env)
+		if err != nil {
+			return nil, err
+		}
+
+		bounds = append(bounds, ub)
+	}
+
+	return bounds, nil
+}
+
+// newArray checks the bounds will fit before allocating
+func newArray(typeid string, bounds []int16, env *object.Environment) object.Object {
+	if len(bounds) > maxArrayDims {
+		return object.StdError(env, berrors.SubscriptRange)
+	}
+
+	size := 1
+	for _, ub := range bounds {
+		if ub < env.OptionBase() {
+			return object.StdError(env, berrors.SubscriptRange)
+		}
+
+		size *= int(ub-env.OptionBase()) + 1
+		if size > maxArrayElements {
+			return object.StdError(env, berrors.OutOfMemory)
+		}
+	}
+
+	return allocArray(typeid, bounds, env)
+}
+
+// allocArray builds one dimension, recursing down for the rest
+func allocArray(typeid string, bounds []int16, env *object.Environment) object.Object {
+	obj := object.Array{TypeID: typeid, Elements: make([]object.Object, int(bounds[0]-env.OptionBase())+1)}
+
+	for i := range obj.Elements {
+		// if more dimensions exist, recurse down them
+		if len(bounds) > 1 {
+			obj.Elements[i] = allocArray(typeid, bounds[1:], env)
+			continue
+		}
+
+		// I'm at the last dimension value
+		obj.Elements[i] = allocArrayValue(typeid)
+	}
+
+	return &obj
+}
+
+func allocArrayValue(typeid string) object.Object {
+	var obj object.Object
+
+	switch typeid {
+	case "", "%":
+		obj = &object.Integer{Value: 0}
+	case "$":
+		obj = &object.String{Value: ""}
+	case "#":
+		obj = &object.FloatDbl{Value: 0}
+	case "!":
+		obj = &object.FloatSgl{Value: 0}
+	case "FIXED":
+		obj = &object.Fixed{Value: decimal.Zero}
+	}
+
+	return obj
+}
+
+// evalArray finds the array, using it without a DIM
+// gives it an upper bound of 10 in each dimension
+func evalArray(id *ast.Identifier, env *object.Environment) object.Object {
+	if env.Exists(id.Value) {
+		return env.Get(id.Value)
+	}
+
+	bounds := make([]int16, len(id.Index))
+	for i := range bounds {
+		bounds[i] = object.DefaultDimSize
+	}
+
+	typeid, _ := parseVarName(id.Value, env)
+	obj := newArray(typeid, bounds, env)
+	if !isError(obj) {
+		env.Set(id.Value, obj)
+	}
+
+	return obj
+}
+
+// evaluate the expression to index into array and save newVal
+// if the len(index) recurse down into array until you get to the last index value
+// once you find it, if newVal is nil return the current value
+// if newVal is not nil, push newVal into correct element and get out
+func evalIndexArray(index []*ast.IndexExpression, array, newVal object.Object, code *ast.Code, env *object.Environment) object.Object {
+	vals, ok := array.(*object.Array)
+
+	// more subscripts than the array has dimensions
+	if !ok {
+		return object.StdError(env, berrors.SubscriptRange)
+	}
+
+	// get the first index value
+	indObj := Eval(index[0].Index, code, env)
+	if isError(indObj) {
+		return indObj
+	}
+
+	// coerce the index into an int 16
+	ind, err := coerceIndex(indObj, env)
+	if err != nil {
+		return err
+	}
+
+	i := int(ind) - int(env.OptionBase())
+	if (i < 0) || (i >= len(vals.Elements)) {
+		return object.StdError(env, berrors.SubscriptRange)
+	}
+
+	// check if their are more dimensions to the array
+	if len(index) > 1 {
+		return evalIndexArray(index[1:], vals.Elements[i], newVal, code, env)
+	}
+
+	// fewer subscripts than the array has dimensions
+	if _, ok := vals.Elements[i].(*object.Array); ok {
+		return object.StdError(env, berrors.SubscriptRange)
+	}
+
+	if newVal != nil {
+		vals.Elements[i] = newVal
+	}
+	return vals.Elements[i]
+}
+
+// ERASE removes arrays so they can be DIMmed again
+func evalEraseStatement(ers *ast.EraseStatement, env *object.Environment) object.Object {
+	for _, id := range ers.Vars {
+		name := id.Value
+		if !id.Array {
+			name += "[]"
+		}
+
+		if !env.Erase(name) {
+			return object.StdError(env, berrors.IllegalFuncCallErr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// DATE$ = and TIME$ = move the clock the program sees
+func evalClockStatement(name string, val object.Object, env *object.Environment) object.Object {
+	if tv, ok := val.(*object.TypedVar); ok {
+		val = tv.Value
+	}
+
+	str, ok := val.(*object.String)
+	if !ok {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	now := env.Now()
+	if name == "DATE$" {
+		now, ok = parseClockDate(str.Value, now)
+	} else {
+		now, ok = parseClockTime(str.Value, now)
+	}
+
+	if !ok {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	env.SetNow(now)
+	return nil
+}
+
+// parseClockDate reads mm-dd-yy or mm-dd-yyyy, a / works as well as a -
+// the time of day is kept from now
+func parseClockDate(date string, now time.Time) (time.Time, bool) {
+	flds := strings.FieldsFunc(date, func(r rune) bool { return (r == '-') || (r == '/') })
+	nums, ok := parseClockFields(flds)
+	if !ok || (len(nums) != 3) {
+		return now, false
+	}
+
+	month, day, year := nums[0], nums[1], nums[2]
+
+	// two digit years run from 1980 to 2079
+	switch {
+	case len(flds[2]) <= 2 && year >= 80:
+		year += 1900
+	case len(flds[2]) <= 2:
+		year += 2000
+	}
+
+	if (year < 1980) || (year > 2099) || (month < 1) || (month > 12) || (day < 1) {
+		return now, false
+	}
+
+	set := time.Date(year, time.Month(month), day, now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), now.Location())
+
+	// a day past the end of the month rolls over into the next one
+	if set.Day() != day {
+		return now, false
+	}
+
+	return set, true
+}
+
+// parseClockTime reads hh, hh:mm or hh:mm:ss, the date is kept from now
+func parseClockTime(tod string, now time.Time) (time.Time, bool) {
+	nums, ok := parseClockFields(strings.Split(tod, ":"))
+	if !ok || (len(nums) > 3) {
+		return now, false
+	}
+
+	for len(nums) < 3 {
+		nums = append(nums, 0)
+	}
+
+	if (nums[0] > 23) || (nums[1] > 59) || (nums[2] > 59) {
+		return now, false
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day(), nums[0], nums[1], nums[2], 0, now.Location()), true
+}
+
+// parseClockFields converts each field into a number
+func parseClockFields(flds []string) ([]int, bool) {
+	var nums []int
+
+	for _, fld := range flds {
+		n, err := strconv.Atoi(strings.TrimSpace(fld))
+		if (err != nil) || (n < 0) {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+
+	return nums, len(nums) > 0
+}
@@ -0,0 +1,249 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/filelist"
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// evalPathParameter evaluates a file or directory name into its full path
+func evalPathParameter(exp ast.Expression, code *ast.Code, env *object.Environment) (string, object.Object) {
+	val := evalExpressionNode(exp, code, env)
+	if isError(val) {
+		return "", val
+	}
+
+	str, ok := val.(*object.String)
+	if !ok {
+		return "", object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if len(str.Value) == 0 {
+		return "", object.StdError(env, berrors.BadFileName)
+	}
+
+	return evalFileKey(str.Value, env), nil
+}
+
+// KILL deletes all the files that match the file spec
+func evalKillStatement(kill *ast.KillStatement, code *ast.Code, env *object.Environment) object.Object {
+	path, err := evalPathParameter(kill.Path, code, env)
+	if err != nil {
+		return err
+	}
+
+	dir := path[:strings.LastIndex(path, `\`)+1]
+	spec := path[len(dir):]
+
+	if !strings.ContainsAny(spec, "*?") {
+		return evalKillFile(path, true, env)
+	}
+
+	local, remote, err := evalKillMatches(dir, spec, env)
+	if err != nil {
+		return err
+	}
+
+	if len(local)+len(remote) == 0 {
+		return object.StdError(env, berrors.FileNotFound)
+	}
+
+	names := evalKillNames(local, remote)
+	for _, name := range names {
+		if env.FileIsOpen(dir + name) {
+			return object.StdError(env, berrors.FileAlreadyOpen)
+		}
+	}
+
+	for _, name := range names {
+		if err := evalKillFile(dir+name, remote[name], env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evalKillNames sorts the local and server matches into one list
+// so files are always deleted in the same order
+func evalKillNames(local map[string]bool, remote map[string]bool) []string {
+	var names []string
+	for name := range local {
+		names = append(names, name)
+	}
+	for name := range remote {
+		if !local[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// evalKillMatches finds the local and server files that match spec
+func evalKillMatches(dir string, spec string, env *object.Environment) (map[string]bool, map[string]bool, object.Object) {
+	local := make(map[string]bool)
+	remote := make(map[string]bool)
+
+	for _, name := range object.CreateFileStore().FilesIn(dir) {
+		if matchFileSpec(spec, name) {
+			local[name] = true
+		}
+	}
+
+	rdr, err := fileserv.GetFile(dir, env)
+	if err != nil {
+		// only a problem if nothing is stored locally either
+		if len(object.CreateFileStore().FilesIn(dir)) == 0 {
+			return nil, nil, object.StdError(env, berrors.PathNotFound)
+		}
+		return local, remote, nil
+	}
+
+	list := filelist.NewFileList()
+	list.Build(rdr, env)
+	for _, fl := range list.Files {
+		if !fl.Subdir && matchFileSpec(spec, strings.ToLower(fl.Name)) {
+			remote[strings.ToLower(fl.Name)] = true
+		}
+	}
+
+	return local, remote, nil
+}
+
+// evalKillFile deletes a single file from local storage and the server
+// if onServer is false, the server isn't asked
+func evalKillFile(path string, onServer bool, env *object.Environment) object.Object {
+	if env.FileIsOpen(path) {
+		return object.StdError(env, berrors.FileAlreadyOpen)
+	}
+
+	local := object.CreateFileStore().RemoveFile(path)
+	if !onServer {
+		return nil
+	}
+
+	err := fileserv.DeleteFile(path, env)
+
+	// a file that only existed locally is fine
+	if local && isFileNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// NAME gives a file a new name
+func evalNameStatement(name *ast.NameStatement, code *ast.Code, env *object.Environment) object.Object {
+	from, err := evalPathParameter(name.OldName, code, env)
+	if err != nil {
+		return err
+	}
+
+	to, err := evalPathParameter(name.NewName, code, env)
+	if err != nil {
+		return err
+	}
+
+	if env.FileIsOpen(from) {
+		return object.StdError(env, berrors.FileAlreadyOpen)
+	}
+
+	lf := object.CreateFileStore()
+	if lf.Exists(to) {
+		return object.StdError(env, berrors.FileAlreadyExists)
+	}
+
+	local := lf.Exists(from)
+
+	err = fileserv.RenameFile(from, to, env)
+	if (err != nil) && !(local && isFileNotFound(err)) {
+		return err
+	}
+
+	lf.RenameFile(from, to)
+
+	return nil
+}
+
+// MKDIR creates a directory on the server
+func evalMkDirStatement(mkdir *ast.MkDirStatement, code *ast.Code, env *object.Environment) object.Object {
+	path, err := evalPathParameter(mkdir.Path, code, env)
+	if err != nil {
+		return err
+	}
+
+	return fileserv.MakeDir(path, env)
+}
+
+// RMDIR removes an empty directory from the server
+func evalRmDirStatement(rmdir *ast.RmDirStatement, code *ast.Code, env *object.Environment) object.Object {
+	path, err := evalPathParameter(rmdir.Path, code, env)
+	if err != nil {
+		return err
+	}
+
+	// can't remove the directory you are in
+	if strings.HasPrefix(fileserv.GetCWD(env), path+`\`) {
+		return object.StdError(env, berrors.PathFileAccess)
+	}
+
+	// files stored locally keep it from being empty
+	if len(object.CreateFileStore().FilesIn(path+`\`)) > 0 {
+		return object.StdError(env, berrors.PathFileAccess)
+	}
+
+	return fileserv.RemoveDir(path, env)
+}
+
+// isFileNotFound returns true if err is a File not found error
+func isFileNotFound(err object.Object) bool {
+	e, ok := err.(*object.Error)
+
+	return ok && (e.Code == berrors.FileNotFound)
+}
+
+// matchFileSpec compares a file name to a DOS style spec like "*.BAS"
+// the name and extension are matched separately so "*.*" matches everything
+func matchFileSpec(spec string, name string) bool {
+	sBase, sExt := splitFileSpec(spec)
+	nBase, nExt := splitFileSpec(name)
+
+	return matchWildcards(sBase, nBase) && matchWildcards(sExt, nExt)
+}
+
+// splitFileSpec breaks a name into its base and extension
+func splitFileSpec(name string) (string, string) {
+	i := strings.LastIndex(name, ".")
+	if i == -1 {
+		return name, ""
+	}
+
+	return name[:i], name[i+1:]
+}
+
+// matchWildcards does the matching for one part of the name
+// ? matches any single character, * matches the rest of the part
+func matchWildcards(spec string, name string) bool {
+	for i := 0; i < len(spec); i++ {
+		switch {
+		case spec[i] == '*':
+			return true
+		case i >= len(name):
+			// DOS lets ? match past the end of the name
+			if spec[i] != '?' {
+				return false
+			}
+		case (spec[i] != '?') && !strings.EqualFold(spec[i:i+1], name[i:i+1]):
+			return false
+		}
+	}
+
+	return len(name) <= len(spec)
+}
@@ -16,9 +16,8 @@ import (
 	"github.com/navionguy/basicwasm/decimal"
 	"github.com/navionguy/basicwasm/filelist"
 	"github.com/navionguy/basicwasm/fileserv"
-	"github.com/navionguy/basicwasm/lexer"
+	"github.com/navionguy/basicwasm/keybuffer"
 	"github.com/navionguy/basicwasm/object"
-	"github.com/navionguy/basicwasm/parser"
 	"github.com/navionguy/basicwasm/settings"
 	"github.com/navionguy/basicwasm/token"
 )
@@ -54,7 +53,7 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 		return evalChDirStatement(node, code, env)
 
 	case *ast.ClearCommand:
-		evalClearCommand(node, code, env)
+		return evalClearCommand(node, code, env)
 
 	case *ast.CloseStatement:
 		return evalCloseStatement(node, code, env)
@@ -74,8 +73,11 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.DataStatement:
 		return nil
 
+	case *ast.DefTypeStatement:
+		evalDefTypeStatement(node, env)
+
 	case *ast.DimStatement:
-		evalDimStatement(node, code, env)
+		return evalDimStatement(node, code, env)
 
 	case *ast.BlockExpression:
 		return evalBlockExpression(node, code, env)
@@ -89,6 +91,9 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.EndStatement:
 		return evalEndStatement(node, code, env)
 
+	case *ast.EraseStatement:
+		return evalEraseStatement(node, env)
+
 	case *ast.ErrorStatement:
 		return evalErrorStatement(node, code, env)
 
@@ -98,6 +103,9 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 		}
 		return Eval(node.Expression, code, env)
 
+	case *ast.FieldStatement:
+		return evalFieldStatement(node, code, env)
+
 	case *ast.FilesCommand:
 		return evalFilesCommand(node, code, env)
 
@@ -107,6 +115,9 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.GosubStatement:
 		return evalGosubStatement(node, code, env)
 
+	case *ast.GetStatement:
+		return evalGetStatement(node, code, env)
+
 	case *ast.GotoStatement:
 		return evalGotoStatement(node, code, env)
 
@@ -116,18 +127,29 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.HexConstant:
 		return evalHexConstant(node, code, env)
 
+	case *ast.InputStatement:
+		if node.FileNum != nil {
+			return evalInputFileStatement(node, code, env)
+		}
+		return evalInputStatement(node, code, env)
+
 	case *ast.KeyStatement:
 		return evalKeyStatement(node, code, env)
 
+	case *ast.KeyTrapStatement:
+		return evalKeyTrapStatement(node, code, env)
+
+	case *ast.KillStatement:
+		return evalKillStatement(node, code, env)
+
 	case *ast.LetStatement:
 		val := Eval(node.Value, code, env)
 		if isError(val) {
 			return val
 		}
-		// life gets more complicated, not less
-		if !strings.ContainsAny(node.Name.Token.Literal, "[($%!#") {
-			env.Set(node.Name.Token.Literal, val)
-			return nil
+		// DATE$ and TIME$ set the clock rather than a variable
+		if (node.Name.Value == "DATE$") || (node.Name.Value == "TIME$") {
+			return evalClockStatement(node.Name.Value, val, env)
 		}
 		return saveVariable(code, env, node.Name, val)
 
@@ -138,6 +160,12 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 			env.Terminal().Print(fmt.Sprintf("[%d]", node.Value))
 		}
 
+	case *ast.LineInputStatement:
+		if node.FileNum != nil {
+			return evalLineInputFileStatement(node, code, env)
+		}
+		return evalLineInputStatement(node, code, env)
+
 	case *ast.ListStatement:
 		evalListStatement(node, code, env)
 
@@ -147,9 +175,21 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.LocateStatement:
 		return evalLocateStatement(node, code, env)
 
+	case *ast.LsetStatement:
+		return evalSetStatement(node.Name, node.Value, false, code, env)
+
+	case *ast.MidStatement:
+		return evalMidStatement(node, code, env)
+
 	case *ast.NextStatement:
 		return evalNextStatement(node, code, env)
 
+	case *ast.MkDirStatement:
+		return evalMkDirStatement(node, code, env)
+
+	case *ast.NameStatement:
+		return evalNameStatement(node, code, env)
+
 	case *ast.NewCommand:
 		return evalNewCommand(node, code, env)
 
@@ -159,15 +199,30 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.OnErrorGoto:
 		return evalOnErrorStatement(node, code, env)
 
+	case *ast.OnKeyStatement:
+		return evalOnKeyStatement(node, code, env)
+
+	case *ast.OnTimerStatement:
+		return evalOnTimerStatement(node, code, env)
+
 	case *ast.OnGoStatement:
 		return evalOnGoStatement(node, code, env)
 
 	case *ast.OpenStatement:
 		return evalOpenStatement(*node, code, env)
 
+	case *ast.OptionBaseStatement:
+		return env.SetOptionBase(node.Base)
+
 	case *ast.PrintStatement:
 		return evalPrintStatement(node, code, env)
 
+	case *ast.PutStatement:
+		return evalPutStatement(node, code, env)
+
+	case *ast.RsetStatement:
+		return evalSetStatement(node.Name, node.Value, true, code, env)
+
 		// Expressions
 	case *ast.IntegerLiteral:
 		i, err := strconv.Atoi(node.TokenLiteral())
@@ -180,6 +235,14 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 		return &object.IntDbl{Value: node.Value}
 
 	case *ast.FixedLiteral:
+		if constDigits(node.Value.Literal) > sglConstDigits {
+			f, err := strconv.ParseFloat(node.Value.Literal, 64)
+			if err != nil {
+				return object.StdError(env, berrors.Syntax)
+			}
+			return &object.FloatDbl{Value: f}
+		}
+
 		val, err := decimal.NewFromString(node.Value.Literal)
 
 		if err != nil {
@@ -221,6 +284,9 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.ReadStatement:
 		return evalReadStatement(node, code, env)
 
+	case *ast.RandomizeStatement:
+		return evalRandomizeStatement(node, code, env)
+
 	case *ast.RestoreStatement:
 		return evalRestoreStatement(node, code, env)
 
@@ -233,15 +299,24 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 	case *ast.ReturnStatement:
 		return evalReturnStatement(node, code, env)
 
+	case *ast.RmDirStatement:
+		return evalRmDirStatement(node, code, env)
+
 	case *ast.RunCommand:
 		return evalRunCommand(node, code, env)
 
+	case *ast.SaveCommand:
+		return evalSaveCommand(node, code, env)
+
 	case *ast.ScreenStatement:
 		return evalScreenStatement(node, code, env)
 
 	case *ast.StopStatement:
 		return evalStopStatement(node, code, env)
 
+	case *ast.SwapStatement:
+		return evalSwapStatement(node, code, env)
+
 	case *ast.CallExpression:
 		function := Eval(node.Function, code, env)
 		if isError(function) {
@@ -256,6 +331,9 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 
 		return applyFunction(function, args, code, env)
 
+	case *ast.TimerStatement:
+		return evalTimerStatement(node, code, env)
+
 	case *ast.TroffCommand:
 		evalTroffCommand(env)
 
@@ -267,6 +345,19 @@ func Eval(node ast.Node, code *ast.Code, env *object.Environment) object.Object
 
 	case *ast.ViewStatement:
 		return evalViewStatement(node, code, env)
+
+	case *ast.WendStatement:
+		return evalWendStatement(node, code, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, code, env)
+
+	case *ast.WidthStatement:
+		return evalWidthStatement(node, code, env)
+
+	case *ast.WriteStatement:
+		return evalWriteStatement(node, code, env)
+
 	default:
 		msg := fmt.Sprintf("unsupported codepoint at line %d, %T", code.CurLine(), node)
 		env.Terminal().Println(msg)
@@ -444,7 +535,7 @@ func evalChainStatement(chain *ast.ChainStatement, code *ast.Code, env *object.E
 
 // attempt to pull down the  desired file
 func evalChainLoad(file string, code *ast.Code, chain *ast.ChainStatement, env *object.Environment) object.Object {
-	rdr, err := fileserv.GetFile(file, env)
+	rdr, err := evalGetProgram(file, env)
 
 	if err != nil {
 		return err
@@ -457,6 +548,10 @@ func evalChainLoad(file string, code *ast.Code, chain *ast.ChainStatement, env *
 func evalChainParse(rdr *bufio.Reader, code *ast.Code, chain *ast.ChainStatement, env *object.Environment) object.Object {
 	env.NewProgram()
 	env.ClearVars()
+	// DEFtype settings only survive along with COMMON variables
+	if !env.HasCommon() {
+		env.ClearDefTypes()
+	}
 	fileserv.ParseFile(rdr, env)
 	env.ConstData().Restore() // start at the first DATA statement
 
@@ -509,24 +604,31 @@ func evalChDirStatement(chdir *ast.ChDirStatement, code *ast.Code, env *object.E
 }
 
 // clear all variables and close all files
-func evalClearCommand(clear *ast.ClearCommand, code *ast.Code, env *object.Environment) {
+func evalClearCommand(clear *ast.ClearCommand, code *ast.Code, env *object.Environment) object.Object {
 	env.ClearVars() // environment handles all the details
-	env.CloseAllFiles()
+	rc := evalCloseAllFiles(env)
 	env.ClearCommon()
+	env.ClearDefTypes()
+	env.ClearRandom()
+
+	return rc
 }
 
 // close one or more files
 func evalCloseStatement(close *ast.CloseStatement, code *ast.Code, env *object.Environment) object.Object {
-	for _, fnum := range close.Files {
-		rc := evalExpressionNode(fnum.Numbr, code, env)
-		switch val := rc.(type) {
-		case *object.Integer:
-			if !env.CloseFile(val.Value) {
-				// file number was not open
-				return object.StdError(env, berrors.BadFileNum)
-			}
-		default:
-			return object.StdError(env, berrors.Syntax)
+	// no file numbers means close them all
+	if len(close.Files) == 0 {
+		return evalCloseAllFiles(env)
+	}
+
+	for i := range close.Files {
+		num, err := evalFileNumberValue(&close.Files[i], code, env)
+		if err != nil {
+			return err
+		}
+
+		if err := evalCloseFile(num, env); err != nil {
+			return err
 		}
 	}
 
@@ -717,17 +819,25 @@ func evalStatements(code *ast.Code, env *object.Environment) object.Object {
 
 			if !halt {
 				halt = !code.Next()
+				ok = !halt
 			}
 		} else {
 			if env.Terminal().BreakCheck() {
 				rc = evalStatementsBreakChk(code, env)
 				halt = true
 			} else {
+				evalEventTraps(code, env)
 				halt = !code.Next()
+				ok = !halt
 			}
 		}
 	}
 
+	// running off the end of the program closes the files, like END
+	if !ok && (rc == nil) && env.ProgramRunning() {
+		rc = evalCloseAllFiles(env)
+	}
+
 	return rc
 }
 
@@ -824,9 +934,6 @@ func evalReadStatement(rd *ast.ReadStatement, code *ast.Code, env *object.Enviro
 			value = &object.Integer{Value: val.Value}
 		case *ast.DblIntegerLiteral:
 			value = &object.IntDbl{Value: val.Value}
-		case *ast.FixedLiteral:
-			fval := Eval(val, code, env)
-			value, _ = fval.(*object.Fixed)
 		case *ast.FloatSingleLiteral:
 			value = &object.FloatSgl{Value: val.Value}
 		case *ast.FloatDoubleLiteral:
@@ -846,6 +953,51 @@ func evalReadStatement(rd *ast.ReadStatement, code *ast.Code, env *object.Enviro
 	return nil
 }
 
+// evalRandomizeStatement reseeds the random number generator
+// without a seed the user is asked for one
+func evalRandomizeStatement(rnd *ast.RandomizeStatement, code *ast.Code, env *object.Environment) object.Object {
+	if rnd.Seed == nil {
+		return evalRandomizePrompt(code, env)
+	}
+
+	val := Eval(rnd.Seed, code, env)
+	if isError(val) {
+		return val
+	}
+
+	if tv, ok := val.(*object.TypedVar); ok {
+		val = tv.Value
+	}
+
+	seed, err := object.RandomSeed(val)
+	if err != 0 {
+		return object.StdError(env, err)
+	}
+
+	env.Randomize(seed)
+	return nil
+}
+
+// evalRandomizePrompt keeps asking until the user enters a valid seed
+func evalRandomizePrompt(code *ast.Code, env *object.Environment) object.Object {
+	for {
+		evalInputPrompt("Random number seed (-32768 to 32767)", true, env)
+
+		line, brk := readInputLine(false, env)
+		if brk {
+			return evalStatementsBreakChk(code, env)
+		}
+
+		val, ok := inputNumeric(strings.TrimSpace(line), &ast.Identifier{Value: "SEED%"}, env)
+		if ok {
+			env.Randomize(val.(*object.Integer).Value)
+			return nil
+		}
+
+		env.Terminal().Println("?Redo from start")
+	}
+}
+
 // evalRestoreStatement makes sure you can re-read data statements
 func evalRestoreStatement(rst *ast.RestoreStatement, code *ast.Code, env *object.Environment) object.Object {
 	if rst.Line >= 0 {
@@ -920,7 +1072,7 @@ func evalResumeError(err int, env *object.Environment) object.Object {
 }
 
 // evalReturnStatement gets you back to where the sub-routine was called
-// alternatively, allows you to recover from an event trap <- ToDo
+// alternatively, allows you to recover from an event trap
 func evalReturnStatement(ret *ast.ReturnStatement, code *ast.Code, env *object.Environment) object.Object {
 	// get code iterator pointing to where I need to be
 	rt := env.Pop()
@@ -930,6 +1082,11 @@ func evalReturnStatement(ret *ast.ReturnStatement, code *ast.Code, env *object.E
 		return object.StdError(env, berrors.ReturnWoGosub)
 	}
 
+	// the trap can fire again now that its handler is done
+	if rt.Trap != nil {
+		rt.Trap.Active = false
+	}
+
 	code.JumpToRetPoint(*rt)
 	return nil
 }
@@ -959,7 +1116,7 @@ func evalRunLoad(run *ast.RunCommand, code *ast.Code, env *object.Environment) o
 }
 
 func evalRunFetch(file string, run *ast.RunCommand, env *object.Environment) object.Object {
-	rdr, err := fileserv.GetFile(file, env)
+	rdr, err := evalGetProgram(file, env)
 
 	if err != nil {
 		object.StdError(env, berrors.Syntax)
@@ -973,7 +1130,9 @@ func evalRunParse(rdr *bufio.Reader, run *ast.RunCommand, env *object.Environmen
 	// create a new program code space
 	env.NewProgram()
 	if !run.KeepOpen {
-		env.CloseAllFiles()
+		if err := evalCloseAllFiles(env); err != nil {
+			return err
+		}
 	}
 
 	// parse the loaded file into an AST for evaluation
@@ -986,6 +1145,9 @@ func evalRunCheckStartLineNum(run *ast.RunCommand, env *object.Environment) obje
 	//	env.Terminal().Println("evalRunCheckStartLineNum")
 	pcode := env.StatementIter()
 	env.ConstData().Restore()
+	env.ClearRandom()
+	env.ClearDefTypes()
+	evalClearTraps(env)
 
 	if run.StartLine > 0 {
 		err := pcode.Jump(run.StartLine)
@@ -1065,94 +1227,63 @@ func evalStopStatement(stop *ast.StopStatement, code *ast.Code, env *object.Envi
 	return &halt
 }
 
-// turn off tracing
-func evalTroffCommand(env *object.Environment) {
-	env.SetTrace(false)
-}
-
-// turn on tracing
-func evalTronCommand(env *object.Environment) {
-	env.SetTrace(true)
-}
-
-func evalDimStatement(dim *ast.DimStatement, code *ast.Code, env *object.Environment) {
-
-	for i, id := range dim.Vars {
-		typeid, _ := parseVarName(id.Token.Literal)
-
-		obj := allocArray(typeid, id.Index, code, env)
-		env.Set(dim.Vars[i].Token.Literal, obj)
+// SWAP exchanges the values of two variables of the same type
+func evalSwapStatement(swp *ast.SwapStatement, code *ast.Code, env *object.Environment) object.Object {
+	left := evalIdentifier(swp.Left, code, env)
+	if isError(left) {
+		return left
 	}
 
-}
-
-func allocArray(typeid string, dims []*ast.IndexExpression, code *ast.Code, env *object.Environment) object.Object {
-	d := Eval(dims[0].Index, code, env)
-	if isError(d) {
-		return d
+	right := evalIdentifier(swp.Right, code, env)
+	if isError(right) {
+		return right
 	}
-	i, ok := d.(*object.Integer)
-
-	if !ok {
-		id, err := coerceIndex(d, env)
 
-		if err != nil {
-			return nil
-		}
-
-		i = &object.Integer{Value: id}
+	lt, _ := parseVarName(swp.Left.Value, env)
+	rt, _ := parseVarName(swp.Right.Value, env)
+	if (lt != rt) || (isStringValue(left) != isStringValue(right)) {
+		return object.StdError(env, berrors.TypeMismatch)
 	}
 
-	elms := make([]object.Object, i.Value)
-	obj := object.Array{TypeID: typeid, Elements: elms}
-
-	// if more dimensions exist, recurse down them
-	if len(dims) > 1 {
-		for i := range obj.Elements {
-			obj.Elements[i] = allocArray(typeid, dims[1:], code, env)
-
-			// check for possible errors
-			err, ok := obj.Elements[i].(*object.Error)
-
-			if ok {
-				return err
-			}
-		}
-		return &obj
+	if rc := saveVariable(code, env, swp.Left, right); rc != nil {
+		return rc
 	}
 
-	// I'm at the last dimension value
-	// create initial values for everybody
+	return saveVariable(code, env, swp.Right, left)
+}
 
-	for j := range obj.Elements {
-		obj.Elements[j] = allocArrayValue(typeid)
+// true if obj holds characters rather than a number
+func isStringValue(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.String, *object.BStr:
+		return true
 	}
 
-	return &obj
+	return false
 }
 
-func allocArrayValue(typeid string) object.Object {
-	var obj object.Object
+// turn off tracing
+func evalTroffCommand(env *object.Environment) {
+	env.SetTrace(false)
+}
 
-	switch typeid {
-	case "", "%":
-		obj = &object.Integer{Value: 0}
-	case "$":
-		obj = &object.String{Value: ""}
-	case "#":
-		obj = &object.FloatDbl{Value: 0}
-	case "!":
-		obj = &object.FloatSgl{Value: 0}
-	case "FIXED":
-		obj = &object.Fixed{Value: decimal.Zero}
-	}
+// turn on tracing
+func evalTronCommand(env *object.Environment) {
+	env.SetTrace(true)
+}
 
-	return obj
+// DEFINT and friends set the default type for a range of letters
+func evalDefTypeStatement(dt *ast.DefTypeStatement, env *object.Environment) {
+	for _, r := range dt.Ranges {
+		env.SetDefType(r[0], r[1], dt.TypeID)
+	}
 }
 
 // stop execution and close any open files
 func evalEndStatement(end *ast.EndStatement, code *ast.Code, env *object.Environment) object.Object {
-	env.CloseAllFiles()
+	if err := evalCloseAllFiles(env); err != nil {
+		return err
+	}
 	return &object.HaltSignal{}
 }
 
@@ -1268,9 +1399,12 @@ func evalForStatement(four *ast.ForStatment, code *ast.Code, env *object.Environ
 		return object.StdError(env, berrors.Syntax)
 	}
 
-	// initialize my counter
+	// initialize my counter, LET gives it the type of its variable
 	rc := Eval(four.Init, code, env)
 
+	if isError(rc) {
+		return rc
+	}
 	if rc != nil {
 		return object.StdError(env, berrors.Syntax)
 	}
@@ -1445,6 +1579,11 @@ func evalImpliedLetStatement(node *ast.Identifier, code *ast.Code, env *object.E
 		return builtin
 	}
 
+	// array elements need their subscripts checked
+	if node.Array {
+		return evalIdentifier(node, code, env)
+	}
+
 	id := env.Get(node.Value)
 
 	return id
@@ -1533,18 +1672,33 @@ func evalKeyStatementMapFKey(key int16, keys *ast.KeySettings, val ast.Expressio
 	return nil
 }
 
+// define a key that can be trapped by ON KEY(15) to ON KEY(20)
+// the string holds the shift flags followed by the scan code
 func evalKeyStatmentCustomKey(key int16, keys *ast.KeySettings, val ast.Expression, code *ast.Code, env *object.Environment) object.Object {
 	b := evalExpressionNodeTyped(val, code, env, &object.String{})
 
 	if b == nil {
 		return object.StdError(env, berrors.Syntax)
 	}
+
+	def := b.(*object.String).Value
+	if len(def) != 2 {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	traps := evalGetKeyTraps(env)
+	traps.Defs[key] = keybuffer.ScanCodeKeys(def[0], def[1])
+	env.SaveSetting(settings.OnKeys, traps)
+
 	return nil
 }
 
 // list some or all of the current program
 func evalListStatement(stmt *ast.ListStatement, code *ast.Code, env *object.Environment) {
-	var out bytes.Buffer
+	// listing walks the program, a running one carries on from here
+	rp := code.GetReturnPoint()
+	defer code.JumpToRetPoint(rp)
+
 	// get a code iterator
 	cd := env.StatementIter()
 
@@ -1566,6 +1720,18 @@ func evalListStatement(stmt *ast.ListStatement, code *ast.Code, env *object.Envi
 		stop, _ = strconv.Atoi(stmt.Stop)
 	}
 
+	for _, line := range listProgram(start, stop, env) {
+		env.Terminal().Println(line)
+	}
+}
+
+// listProgram builds the text of the program lines from start to stop
+// the last entry is whatever was left in the buffer, it may be empty
+func listProgram(start int, stop int, env *object.Environment) []string {
+	var out bytes.Buffer
+	var lines []string
+	cd := env.StatementIter()
+
 	// couple of flags to control the listing loop
 	midLine := false // tells me I've printed a line # and the first statement (need to insert colons)
 	bList := false   // set true when I see a line # in the printing range
@@ -1585,14 +1751,15 @@ func evalListStatement(stmt *ast.ListStatement, code *ast.Code, env *object.Envi
 
 			// output anything in the buffer from a previous line, if I'm printing yet
 			if bList {
-				env.Terminal().Println(strings.TrimRight(out.String(), " "))
+				lines = append(lines, strings.TrimRight(out.String(), " "))
 				out.Truncate(0)
 			}
 			bList = (int(lnm.Value) >= start)
 			midLine = false // just wrote a line number, not in the middle of a line
 		}
 
-		if bList {
+		// the END the parser adds after the last line has no text
+		if bList && (len(stmt.String()) > 0) {
 			if midLine {
 				// seperate the statements
 				out.WriteString(": ")
@@ -1605,7 +1772,8 @@ func evalListStatement(stmt *ast.ListStatement, code *ast.Code, env *object.Envi
 
 		more = cd.Next()
 	}
-	env.Terminal().Println(out.String())
+
+	return append(lines, out.String())
 }
 
 // evalLoadCommand - load and parse the target program
@@ -1623,7 +1791,7 @@ func evalLoadCommand(stmt *ast.LoadCommand, code *ast.Code, env *object.Environm
 
 // calls the file server looking for a source file
 func evalLoadGetFile(file string, stmt *ast.LoadCommand, code *ast.Code, env *object.Environment) object.Object {
-	rdr, err := fileserv.GetFile(file, env)
+	rdr, err := evalGetProgram(file, env)
 
 	if err != nil {
 		// server sent an error, get out
@@ -1721,10 +1889,76 @@ func evalOctalConstant(stmt *ast.OctalConstant, env *object.Environment) object.
 	return &object.Integer{Value: int16(dst)}
 }
 
+// MID$ statement replaces characters in a string without changing its length
+func evalMidStatement(mid *ast.MidStatement, code *ast.Code, env *object.Environment) object.Object {
+	if !inputIsString(mid.Name, env) {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	var dst []byte
+	cur := evalExpressionNode(mid.Name, code, env)
+	switch str := cur.(type) {
+	case *object.String:
+		dst = []byte(str.Value)
+	case *object.BStr:
+		dst = append(dst, str.Value...)
+	default:
+		return cur
+	}
+
+	args := []object.Object{evalExpressionNode(mid.Start, code, env)}
+	if mid.Length != nil {
+		args = append(args, evalExpressionNode(mid.Length, code, env))
+	}
+	for _, arg := range args {
+		if isError(arg) {
+			return arg
+		}
+	}
+
+	start, end, err := builtins.MidRange(env, len(dst), args...)
+	if err != nil {
+		return err
+	}
+
+	// can't start past the end of the string
+	if start >= len(dst) {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	var src []byte
+	switch val := evalExpressionNode(mid.Value, code, env).(type) {
+	case *object.String:
+		src = []byte(val.Value)
+	case *object.BStr:
+		src = val.Value
+	case *object.Error:
+		return val
+	default:
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+	copy(dst[start:end], src)
+
+	// FIELD variables go into the record buffer
+	fh, fv := env.FindField(mid.Name.Value)
+	if fh != nil {
+		fh.SetField(fv, dst, false)
+		env.Set(mid.Name.Value, &object.String{Value: fh.FieldValue(fv)})
+		return nil
+	}
+
+	if _, ok := cur.(*object.BStr); ok {
+		return saveVariable(code, env, mid.Name, &object.BStr{Value: dst})
+	}
+
+	return saveVariable(code, env, mid.Name, &object.String{Value: string(dst)})
+}
+
 // evalNewCommand clears the code space and all the variables
 func evalNewCommand(cmd *ast.NewCommand, code *ast.Code, env *object.Environment) object.Object {
 	env.NewProgram()
 	env.ClearVars()
+	env.ClearDefTypes()
 
 	// send a halt signal if we are executing a program
 	var htl object.HaltSignal
@@ -1778,8 +2012,12 @@ func evalNextStep(four object.ForBlock, code *ast.Code, env *object.Environment)
 		return nil
 	}
 
-	// add step to the cntr
-	cntr = evalInfixExpression("+", cntr, step[0], env)
+	// add step to the cntr, it keeps the type of its variable
+	typeid, _ := parseVarName(four.Four.Init.Name.Token.Literal, env)
+	cntr = coerceVariable(typeid, evalInfixExpression("+", cntr, step[0], env), env)
+	if isError(cntr) {
+		return cntr
+	}
 	// save off the counter variable
 	env.Set(four.Four.Init.Name.Token.Literal, cntr)
 
@@ -1914,14 +2152,91 @@ func evalOnGoJump(ind int32, node *ast.OnGoStatement, code *ast.Code, env *objec
 // opens a data file
 // todo: support open device
 func evalOpenStatement(node ast.OpenStatement, code *ast.Code, env *object.Environment) object.Object {
-	// get the target file name ()
-	node.FileName = fileserv.BuildFullPath(node.FileName, env)
+	if node.FileName == nil {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	// get the target file name
+	name, err := evalPathParameter(node.FileName, code, env)
+	if err != nil {
+		return err
+	}
 
+	var rc object.Object
 	if node.Verbose {
-		return evalVerboseOpen(&node, code, env)
+		rc = evalVerboseOpen(&node, code, env)
+	} else {
+		rc = evalConciseOpen(&node, code, env)
+	}
+
+	if rc != nil {
+		return rc
 	}
 
-	return evalConciseOpen(&node, code, env)
+	return evalOpenFile(&node, name, code, env)
+}
+
+// with all the parameters filled in, actually open the file
+func evalOpenFile(node *ast.OpenStatement, name string, code *ast.Code, env *object.Environment) object.Object {
+	mode := evalOpenMode(node.Mode)
+	if mode == 0 {
+		return object.StdError(env, berrors.BadFileMode)
+	}
+
+	fnum, err := evalFileNumberValue(&node.FileNumber, code, env)
+	if err != nil {
+		return err
+	}
+
+	recLen, cerr := strconv.Atoi(node.RecLen)
+	if cerr != nil {
+		return object.StdError(env, berrors.Syntax)
+	}
+	if (recLen < 1) || (recLen > object.MaxRecLen) {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	// if I don't have it locally, go ask the server for it
+	if (mode != object.OutputFile) && !object.CreateFileStore().Exists(name) {
+		err = evalOpenFetch(name, env)
+
+		// only a problem if the file has to already exist
+		if (err != nil) && (mode == object.InputFile) {
+			return err
+		}
+	}
+
+	return env.OpenFile(fnum, name, mode, recLen)
+}
+
+// convert the mode, in either syntax, into a file mode
+func evalOpenMode(mode string) int {
+	switch strings.ToUpper(mode) {
+	case "I", token.INPUT:
+		return object.InputFile
+	case "O", token.OUTPUT:
+		return object.OutputFile
+	case "R", token.RANDOM:
+		return object.RandomFile
+	case "A", token.APPEND:
+		return object.AppendFile
+	}
+
+	return 0
+}
+
+// pull a copy of the file down from the server into local storage
+func evalOpenFetch(name string, env *object.Environment) object.Object {
+	rdr, err := fileserv.GetFile(name, env)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(rdr)
+	object.CreateFileStore().StoreFile(name, buf.Bytes())
+
+	return nil
 }
 
 // it's gwbasic, so they have two statement formats to open a file
@@ -1989,12 +2304,30 @@ func evalPaletteDefault(scrmode int) *ast.PaletteStatement {
 	return &plt
 }
 
+// printer is where PRINT sends its output, the screen or a file
+type printer interface {
+	Print(msg string)
+	Println(msg string)
+}
+
 // Process parameters of a Print statement
 func evalPrintStatement(node *ast.PrintStatement, code *ast.Code, env *object.Environment) object.Object {
+	var out printer = env.Terminal()
+
+	if node.FileNum != nil {
+		fh, err := evalFileForOutput(node.FileNum, code, env)
+		if err != nil {
+			return err
+		}
+		out = fh
+	}
+
+	pc := newPrintCursor(out, env)
+
 	var rc object.Object
 	// go print items, if there are any
 	if len(node.Items) > 0 {
-		rc = evalPrintItems(node, code, env)
+		rc = evalPrintItems(node, pc, code, env)
 	}
 
 	// if I got anything, it is an error
@@ -2002,21 +2335,24 @@ func evalPrintStatement(node *ast.PrintStatement, code *ast.Code, env *object.En
 		return rc
 	}
 
-	// if last seperator is ; no CR/LF
-	if (len(node.Seperators) > 0) && (node.Seperators[len(node.Seperators)-1] == ";") {
-		return nil
+	// if last seperator is ; or , no CR/LF
+	if len(node.Seperators) > 0 {
+		sep := node.Seperators[len(node.Seperators)-1]
+		if (sep == ";") || (sep == ",") {
+			return nil
+		}
 	}
 
 	// end with a newline
-	env.Terminal().Println("")
+	pc.newline()
 
 	return nil
 }
 
 // Print the individual items
-func evalPrintItems(node *ast.PrintStatement, code *ast.Code, env *object.Environment) object.Object {
+func evalPrintItems(node *ast.PrintStatement, pc *printCursor, code *ast.Code, env *object.Environment) object.Object {
 	var obj object.Object
-	fmt := ""
+	var using *usingFormat
 
 	for i, item := range node.Items {
 		switch node := item.(type) {
@@ -2025,6 +2361,12 @@ func evalPrintItems(node *ast.PrintStatement, code *ast.Code, env *object.Enviro
 		return rc.Inspect()*/
 
 		case *ast.CallExpression:
+			if done, err := evalPrintPosition(node, pc, code, env); done {
+				if err != nil {
+					return err
+				}
+				continue
+			}
 			obj = Eval(node, code, env)
 
 		case *ast.Identifier:
@@ -2039,16 +2381,15 @@ func evalPrintItems(node *ast.PrintStatement, code *ast.Code, env *object.Enviro
 			obj = &object.Integer{Value: node.Value}
 		case *ast.DblIntegerLiteral:
 			obj = &object.IntDbl{Value: node.Value}
-		case *ast.FixedLiteral:
-			fval := Eval(node, code, env)
-			obj = fval.(*object.Fixed)
 		case *ast.UsingExpression:
 			obj = evalUsingExpression(node, code, env)
 			form, ok := obj.(*object.String)
 			if ok {
-				fmt = form.Value
+				using = newUsingFormat(form.Value)
 				continue // skip any printing
 			}
+		default:
+			obj = evalExpressionNode(node, code, env)
 		}
 		_, ok := obj.(*object.Error)
 
@@ -2056,64 +2397,92 @@ func evalPrintItems(node *ast.PrintStatement, code *ast.Code, env *object.Enviro
 			return obj
 		}
 
-		if len(fmt) == 0 {
-			evalPrintItemValue(obj, env)
+		if using == nil {
+			evalPrintItemValue(obj, pc)
 		} else {
-			err := evalPrintItemUsing(fmt, obj, env)
+			err := evalPrintItemUsing(using, obj, pc, env)
 			if err != nil {
 				return err
 			}
+			// commas don't tab once USING has taken over
+			continue
 		}
 
-		// if seperated by a comma, that means tab
+		// if seperated by a comma, move to the next print zone
 		if node.Seperators[i] == "," {
-			env.Terminal().Print("\t")
+			pc.zone()
+		}
+	}
+
+	// finish off any text after the last field
+	if using != nil {
+		if lit := using.finish(); len(lit) > 0 {
+			pc.write(lit)
 		}
 	}
 
 	return nil
 }
 
-// evalPrintItemUsing uses the suppllied format string to Sprintf the object into a string
+// evalPrintPosition handles TAB() and SPC() which move the cursor
+// rather than print a value, returns false for any other call
+func evalPrintPosition(call *ast.CallExpression, pc *printCursor, code *ast.Code, env *object.Environment) (bool, object.Object) {
+	fn, ok := call.Function.(*ast.Identifier)
+	if !ok || ((fn.Value != "TAB") && (fn.Value != "SPC")) {
+		return false, nil
+	}
+
+	if len(call.Arguments) != 1 {
+		return true, object.StdError(env, berrors.Syntax)
+	}
+
+	arg := evalExpressionNode(call.Arguments[0], code, env)
+	if isError(arg) {
+		return true, arg
+	}
+	if isStringValue(arg) {
+		return true, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	n, err := coerceIndex(arg, env)
+	if err != nil {
+		return true, err
+	}
+
+	if fn.Value == "TAB" {
+		pc.tab(int(n))
+	} else {
+		pc.spc(int(n))
+	}
+
+	return true, nil
+}
+
+// evalPrintItemUsing fits the object into the next field of the format
 // and then prints it.
-// TODO support more than just numerics
-func evalPrintItemUsing(form string, item object.Object, env *object.Environment) object.Object {
-	out := fmt.Sprintf("fmt snap %T", item)
-	switch val := item.(type) {
-	case *object.Integer:
-		out = fmt.Sprintf(form, float32(val.Value))
-	case *object.IntDbl:
-		out = fmt.Sprintf(form, float32(val.Value))
-	case *object.Fixed:
-		v, _ := val.Value.Float64()
-		out = fmt.Sprintf(form, v)
-	case *object.FloatSgl:
-		out = fmt.Sprintf(form, val.Value)
-	case *object.FloatDbl:
-		out = fmt.Sprintf(form, val.Value)
+func evalPrintItemUsing(using *usingFormat, item object.Object, pc *printCursor, env *object.Environment) object.Object {
+	out, err := using.format(item, env)
+	if err != nil {
+		return err
 	}
-	env.Terminal().Print(out)
+
+	pc.write(out)
 	return nil
 }
 
 // figure out what a print item is, and turn it into a string
-func evalPrintItemValue(item object.Object, env *object.Environment) {
+// numbers are followed by a space and never split across lines
+func evalPrintItemValue(item object.Object, pc *printCursor) {
+	if num, ok := object.FormatNumber(item); ok {
+		pc.writeNumber(num + " ")
+		return
+	}
+
 	out := fmt.Sprintf("oh snap %T", item)
-	switch val := item.(type) {
-	case *object.String:
-		out = val.Inspect()
-	case *object.FloatDbl:
-		out = val.Inspect()
-	case *object.FloatSgl:
-		out = val.Inspect()
-	case *object.Fixed:
-		out = val.Value.String()
-	case *object.Integer:
-		out = val.Inspect()
-	case *object.IntDbl:
-		out = val.Inspect()
+	if str, ok := item.(*object.String); ok {
+		out = str.Inspect()
 	}
-	env.Terminal().Print(out)
+	pc.write(out)
 }
 
 // get the value of the identifier
@@ -2126,6 +2495,8 @@ func evalPrefixExpression(operator string, right object.Object, code *ast.Code,
 	switch operator {
 	case "-":
 		return evalMinusPrefixOperatorExpression(right, env)
+	case "NOT":
+		return evalNotPrefixExpression(right, env)
 	default:
 		return newError(env, "unknown operator: %s%s", operator, right.Type())
 	}
@@ -2134,6 +2505,10 @@ func evalPrefixExpression(operator string, right object.Object, code *ast.Code,
 func evalMinusPrefixOperatorExpression(right object.Object, env *object.Environment) object.Object {
 	switch obj := right.(type) {
 	case *object.Integer:
+		// -32768 is the one integer that can't be negated
+		if obj.Value == math.MinInt16 {
+			return &object.FloatSgl{Value: -math.MinInt16}
+		}
 		obj.Value = -obj.Value
 	case *object.IntDbl:
 		obj.Value = -obj.Value
@@ -2170,6 +2545,24 @@ func evalInfixBooleanExpression(operator string, left, right object.Object, env
 
 // evaluate an infix expression, returned value type should match either left or right type
 func evalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
+	if isError(left) {
+		return left
+	}
+	if isError(right) {
+		return right
+	}
+
+	switch operator {
+	case "AND", "OR", "XOR", "EQV", "IMP":
+		return evalLogicalInfixExpression(operator, left, right, env)
+	case "^":
+		return evalPowerExpression(left, right, env)
+	case "\\", "MOD":
+		return evalIntDivExpression(operator, left, right, env)
+	}
+
+	left = numericOperand(left)
+	right = numericOperand(right)
 	fn, ok := typeConverters[string(left.Type())+string(right.Type())]
 
 	if !ok {
@@ -2194,24 +2587,20 @@ func evalStringInfixExpression(operator string, left, right object.Object, env *
 	}
 }
 
+// integer results that don't fit in 16 bits become single precision
 func evalIntegerInfixExpression(operator string, leftVal, rightVal int, env *object.Environment) object.Object {
 	switch operator {
 	case "+":
-		return builtins.FixType(env, leftVal+rightVal)
+		return builtins.FixType(env, float32(leftVal+rightVal))
 	case "-":
-		return builtins.FixType(env, leftVal-rightVal)
+		return builtins.FixType(env, float32(leftVal-rightVal))
 	case "*":
-		return builtins.FixType(env, leftVal*rightVal)
+		return builtins.FixType(env, float32(leftVal*rightVal))
 	case "/":
 		if rightVal == 0 {
 			return object.StdError(env, berrors.DivByZero)
 		}
-		return builtins.FixType(env, float64(leftVal)/float64(rightVal))
-	case "\\":
-		// I'm learning stuff I never knew about GWBasic
-		return &object.Integer{Value: int16(leftVal) / int16(rightVal)}
-	case "MOD":
-		return builtins.FixType(env, leftVal%rightVal)
+		return builtins.FixType(env, float32(leftVal)/float32(rightVal))
 	case "<":
 		return &object.Integer{Value: bool2int16(leftVal < rightVal)}
 	case "<=":
@@ -2330,7 +2719,12 @@ func evalIfStatement(ie *ast.IfStatement, code *ast.Code, env *object.Environmen
 		return condition
 	}
 
-	if condition.(*object.Integer).Value == 0 { // that's a false
+	truth, err := evalTruth(condition, env)
+	if err != nil {
+		return err
+	}
+
+	if !truth { // that's a false
 		if ie.Alternative == nil {
 			return nil // continues at next statement
 		}
@@ -2411,11 +2805,9 @@ func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Enviro
 // check if the Identifier has a known value saved in the environment
 func evalIdentifier(node *ast.Identifier, code *ast.Code, env *object.Environment) object.Object {
 
-	val := env.Get(node.Value)
-
 	// if it isn't an array, it is the value
 	if node.Value[len(node.Value)-1] != ']' {
-		return val
+		return env.Get(node.Value)
 	}
 
 	// if there is no index into the array, that's an error
@@ -2423,86 +2815,44 @@ func evalIdentifier(node *ast.Identifier, code *ast.Code, env *object.Environmen
 		return object.StdError(env, berrors.Syntax)
 	}
 
-	// evaluate the index and return it
-	return evalIndexArray(node.Index, val, nil, code, env)
-}
-
-// evaluate the expression to index into array and save newVal
-// if the len(index) recurse down into array until you get to the last index value
-// once you find it, if newVal is nil return the current value
-// if newVal is not nil, push newVal into correct element and get out
-
-// original caller will save the whole mess back to the environment
-func evalIndexArray(index []*ast.IndexExpression, array, newVal object.Object, code *ast.Code, env *object.Environment) object.Object {
-
-	// get the first index value
-	indObj := Eval(index[0].Index, code, env)
-	if isError(indObj) {
-		return indObj
-	}
-
-	// coerce the index into an int 16
-	ind, err := coerceIndex(indObj, env)
-
-	if err != nil {
-		return object.StdError(env, berrors.Syntax)
-	}
-
-	// TODO array indices in BASIC can be based at zero as well!
-	ind -= 1
-
-	vals, ok := array.(*object.Array)
-
-	if !ok {
-		return object.StdError(env, berrors.Syntax)
-	}
-
-	// check if their are more dimensions to the array
-	if len(index) > 1 {
-		return evalIndexArray(index[1:], vals.Elements[ind], newVal, code, env)
-	}
-
-	if (ind < 0) || (int(ind) >= len(vals.Elements)) {
-		return object.StdError(env, berrors.Syntax)
+	val := evalArray(node, env)
+	if isError(val) {
+		return val
 	}
 
-	if newVal != nil {
-		vals.Elements[ind] = newVal
-	}
-	return vals.Elements[ind]
+	// evaluate the index and return it
+	return evalIndexArray(node.Index, val, nil, code, env)
 }
 
 // saveVariable into the environment
 func saveVariable(code *ast.Code, env *object.Environment, name *ast.Identifier, val object.Object) object.Object {
 	sname := name.Value
 
-	typeid, isarray := parseVarName(sname)
+	typeid, isarray := parseVarName(sname, env)
 
-	if !checkTypes(typeid, val) {
-		return object.StdError(env, berrors.Syntax)
+	val = coerceVariable(typeid, val, env)
+	if isError(val) {
+		return val
 	}
 
-	cv := env.Get(sname)
-
 	// if not dealing with an array, just save the new value
+	// a FIELD variable assigned directly no longer maps onto the record
 	if !isarray {
+		env.UnField(sname)
 		env.Set(sname, val)
 		return nil
 	}
 
-	cvarray, ok := cv.(*object.Array)
-
-	if ok && checkTypes(typeid, val) {
-		val := evalIndexArray(name.Index, cvarray, val, code, env)
-
-		_, ok := val.(*object.Error)
+	cv := evalArray(name, env)
+	if isError(cv) {
+		return cv
+	}
 
-		if ok {
-			return val
-		}
+	rc := evalIndexArray(name.Index, cv, val, code, env)
+	if isError(rc) {
+		return rc
 	}
 
-	env.Set(sname, cv)
 	return nil
 }
 
@@ -2546,46 +2896,9 @@ func coerceDblInteger(idx object.Object, env *object.Environment) (int32, object
 	return 0, object.StdError(env, berrors.Syntax)
 }
 
-func checkTypes(typeid string, val object.Object) bool {
-
-	tv, typed := val.(*object.TypedVar)
-
-	// if TypedVar, reach in and get his value object
-	if typed {
-		val = tv.Value
-	}
-
-	switch val.Type() {
-	case object.INTEGER_OBJ:
-		if strings.ContainsAny(typeid, "%#!") || (typeid == "") {
-			return true
-		}
-	case object.INTEGER_DBL:
-		if strings.Contains(typeid, "!") || (typeid == "") {
-			return true
-		}
-	case object.FIXED_OBJ:
-		if strings.ContainsAny(typeid, "#!") || (typeid == "") {
-			return true
-		}
-	case object.FLOATDBL_OBJ:
-		if (typeid == "#") || (typeid == "") {
-			return true
-		}
-	case object.FLOATSGL_OBJ:
-		if strings.ContainsAny(typeid, "#!") || (typeid == "") {
-			return true
-		}
-	case object.STRING_OBJ:
-		if typeid == "$" {
-			return true
-		}
-	}
-
-	return false
-}
-
-func parseVarName(name string) (string, bool) {
+// parseVarName returns the type character and if name is an array
+// without a type character, DEFINT and friends decide
+func parseVarName(name string, env *object.Environment) (string, bool) {
 	parts := strings.Split(name, "[")
 	altparts := strings.Split(name, "(")
 
@@ -2603,7 +2916,7 @@ func parseVarName(name string) (string, bool) {
 	typeid := base[len(base)-1:]
 
 	if !strings.ContainsAny(typeid, "$%#!") {
-		return "", isarray
+		return env.DefType(base), isarray
 	}
 
 	return typeid, isarray
@@ -2637,7 +2950,7 @@ func bool2int16(b bool) int16 {
 	// See issue 6011.
 	var i int16
 	if b {
-		i = 1
+		i = -1
 	} else {
 		i = 0
 	}
@@ -2646,19 +2959,26 @@ func bool2int16(b bool) int16 {
 
 // evalUsingExpression and return string object with format string
 func evalUsingExpression(stmt *ast.UsingExpression, code *ast.Code, env *object.Environment) object.Object {
+	if stmt.Format == nil {
+		return object.StdError(env, berrors.MissingOp)
+	}
+
 	frm := evalExpressionNode(stmt.Format, code, env)
-	inp := ""
-	switch obj := frm.(type) {
-	case *object.String:
-		inp = obj.Value
-	default:
-		return object.StdError(env, berrors.Syntax)
+	if isError(frm) {
+		return frm
+	}
+
+	form, ok := frm.(*object.String)
+	if !ok {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	// an empty format has no fields to print with
+	if len(form.Value) == 0 {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
 	}
-	l := lexer.New(inp)
-	p := parser.New(l)
-	fmt := p.ParseUsingRunTime()
 
-	return &object.String{Value: fmt}
+	return form
 }
 
 func evalViewPrintStatement(stmt *ast.ViewPrintStatement, code *ast.Code, env *object.Environment) object.Object {
@@ -2726,6 +3046,113 @@ func evalViewStatement(stmt *ast.ViewStatement, code *ast.Code, env *object.Envi
 	return nil
 }
 
+// evalWendStatement decides if the innermost WHILE loop goes around again
+func evalWendStatement(wend *ast.WendStatement, code *ast.Code, env *object.Environment) object.Object {
+	// make sure we are actually in a WHILE loop
+	if len(env.WhileLoops) == 0 {
+		return object.StdError(env, berrors.WendWoWhile)
+	}
+
+	blk := env.WhileLoops[len(env.WhileLoops)-1]
+
+	again, err := evalWhileCondition(blk.While, code, env)
+	if err != nil {
+		return err
+	}
+
+	if again {
+		// go back to where the while loop started
+		code.JumpToRetPoint(blk.Code)
+		return nil
+	}
+
+	// loop is done, drop it off the stack
+	env.WhileLoops = env.WhileLoops[:len(env.WhileLoops)-1]
+	return nil
+}
+
+// WHILE statement begins a while-loop
+func evalWhileStatement(whl *ast.WhileStatement, code *ast.Code, env *object.Environment) object.Object {
+	// if this loop was left by a GOTO, forget about it and anything inside it
+	evalWhileDropStale(whl, env)
+
+	run, err := evalWhileCondition(whl, code, env)
+	if err != nil {
+		return err
+	}
+
+	if !run {
+		return evalWhileSkipLoop(code, env)
+	}
+
+	// add WhileBlock to the list of running while loops
+	env.WhileLoops = append(env.WhileLoops, object.WhileBlock{Code: code.GetReturnPoint(), While: whl})
+
+	return nil
+}
+
+// evalWhileDropStale removes the loop, and any loops nested inside it
+// if we are starting it over again
+func evalWhileDropStale(whl *ast.WhileStatement, env *object.Environment) {
+	for i := range env.WhileLoops {
+		if env.WhileLoops[i].While == whl {
+			env.WhileLoops = env.WhileLoops[:i]
+			return
+		}
+	}
+}
+
+// evalWhileCondition returns true if the loop should execute
+func evalWhileCondition(whl *ast.WhileStatement, code *ast.Code, env *object.Environment) (bool, object.Object) {
+	if whl.Condition == nil {
+		return false, object.StdError(env, berrors.Syntax)
+	}
+
+	cond := Eval(whl.Condition, code, env)
+	if isError(cond) {
+		return false, cond
+	}
+
+	return evalTruth(cond, env)
+}
+
+// evalTruth decides if a condition is true, any non-zero number is
+func evalTruth(cond object.Object, env *object.Environment) (bool, object.Object) {
+	switch val := cond.(type) {
+	case *object.Integer:
+		return val.Value != 0, nil
+	case *object.IntDbl:
+		return val.Value != 0, nil
+	case *object.Fixed:
+		return !val.Value.IsZero(), nil
+	case *object.FloatSgl:
+		return val.Value != 0, nil
+	case *object.FloatDbl:
+		return val.Value != 0, nil
+	}
+
+	return false, object.StdError(env, berrors.TypeMismatch)
+}
+
+// evalWhileSkipLoop condition is false on entry
+// skip over statements until you find the matching WEND
+func evalWhileSkipLoop(code *ast.Code, env *object.Environment) object.Object {
+	depth := 0
+	for more := code.Next(); more; more = code.Next() {
+		switch code.Value().(type) {
+		case *ast.WhileStatement:
+			// found an inner WHILE loop, need to skip his WEND too
+			depth++
+		case *ast.WendStatement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+	return object.StdError(env, berrors.WhileWoWend)
+}
+
 // checkForTrash checks to see if the node has any trash
 func checkForTrash(node ast.Node, env *object.Environment) object.Object {
 
@@ -3,14 +3,21 @@ package evaluator
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/navionguy/basicwasm/ast"
 	"github.com/navionguy/basicwasm/berrors"
 	"github.com/navionguy/basicwasm/decimal"
+	"github.com/navionguy/basicwasm/driveclient"
 	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/keybuffer"
 	"github.com/navionguy/basicwasm/lexer"
 	"github.com/navionguy/basicwasm/mocks"
 	"github.com/navionguy/basicwasm/object"
@@ -668,9 +675,9 @@ func Test_CsrLinExpression(t *testing.T) {
 	assert.Nil(t, rc, "CSRLIN unexpectedly returned %s", fmt.Sprintf("%T", rc))
 	csrlin := env.Get("X")
 
-	newRow, ok := csrlin.(*object.Integer)
+	newRow, ok := csrlin.(*object.FloatSgl)
 
-	assert.True(t, ok, "CSRLIN did not return an integer!")
+	assert.True(t, ok, "CSRLIN did not set a single!")
 	assert.Equal(t, row+1, int(newRow.Value), "CSRLIN returned %d, expected %d", newRow.Value, row+1)
 }
 
@@ -943,6 +950,134 @@ func Test_ForSkip(t *testing.T) {
 	}
 }
 
+func Test_WhileWendStatements(t *testing.T) {
+	tests := []struct {
+		inp   string
+		exp   object.Object
+		loops int // loops left active at the end
+	}{
+		{inp: `10 X = 0 : WHILE X < 5 : X = X + 1 : WEND`, exp: &object.Integer{Value: 5}},
+		{inp: `10 X = 7 : WHILE X < 5 : X = X + 1 : WEND`, exp: &object.Integer{Value: 7}},
+		{inp: "10 X = 0 : I = 0\n20 WHILE I < 3\n30 J = 0\n40 WHILE J < 2 : X = X + 1 : J = J + 1 : WEND\n50 I = I + 1\n60 WEND", exp: &object.Integer{Value: 6}},
+		{inp: "10 X = 0 : WHILE 0\n20 WHILE 1 : X = 5 : WEND\n30 WEND", exp: &object.Integer{Value: 0}},
+		{inp: "10 X = 0\n20 WHILE 1\n30 X = X + 1 : IF X MOD 3 = 0 THEN 50\n40 WEND\n50 IF X < 6 THEN 20", exp: &object.Integer{Value: 6}, loops: 1},
+		{inp: `10 X = 2.5 : WHILE X : X = X - 0.5 : WEND`, exp: &object.Fixed{Value: decimal.NewFromInt32(0)}},
+		{inp: `10 WHILE 0 : X = 1`, exp: &object.Error{Code: berrors.WhileWoWend, Message: "WHILE without WEND in 10"}},
+		{inp: `10 X = 1 : WEND`, exp: &object.Error{Code: berrors.WendWoWhile, Message: "WEND without WHILE in 10"}},
+		{inp: `10 WHILE "A" : WEND`, exp: &object.Error{Code: berrors.TypeMismatch, Message: "Type mismatch in 10"}},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		rc := testEvalEnv(tt.inp, "X", env)
+
+		assert.Equalf(t, tt.exp.Inspect(), rc.Inspect(), "%s got unexpected result", tt.inp)
+
+		// re-entering a loop after a GOTO out of it shouldn't grow the stack
+		if _, ok := tt.exp.(*object.Error); !ok {
+			assert.Equalf(t, tt.loops, len(env.WhileLoops), "%s left loops on the stack", tt.inp)
+		}
+	}
+}
+
+func Test_InKey(t *testing.T) {
+	tests := []struct {
+		inp  string
+		keys string
+		chk  string
+		exp  string
+	}{
+		{inp: `10 A$ = INKEY$`, keys: "X", chk: "A$", exp: "X"},
+		{inp: `10 A$ = INKEY$`, chk: "A$", exp: ""},
+		{inp: `10 A$ = INKEY$ : B$ = INKEY$`, keys: "XY", chk: "B$", exp: "Y"},
+		{inp: `10 A$ = INKEY$ : B$ = INKEY$`, keys: "X", chk: "B$", exp: ""},
+		{inp: `10 A$ = INKEY$`, keys: "\x00H", chk: "A$", exp: "\x00H"},
+		{inp: `10 A$ = INKEY$ : B$ = INKEY$`, keys: "\x00Kz", chk: "B$", exp: "z"},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		*mt.StrVal = tt.keys
+		env := object.NewTermEnvironment(mt)
+
+		res := testEvalEnv(tt.inp, tt.chk, env)
+
+		if tv, ok := res.(*object.TypedVar); ok {
+			res = tv.Value
+		}
+		assert.Equalf(t, &object.String{Value: tt.exp}, res, "%s with keys %q", tt.inp, tt.keys)
+	}
+}
+
+func Test_InputStatement(t *testing.T) {
+	tests := []struct {
+		inp  string
+		keys string
+		vars map[string]object.Object
+		err  bool
+		brk  bool // input stopped by CTRL-C
+	}{
+		{inp: `10 INPUT A`, keys: "42\r", vars: map[string]object.Object{"A": &object.FloatSgl{Value: 42}}},
+		{inp: `10 INPUT "Age"; A%`, keys: "21.6\r", vars: map[string]object.Object{"A%": &object.Integer{Value: 22}}},
+		{inp: `10 INPUT "Values", A, B$, C#`, keys: "1.5, Hello ,2D3\r", vars: map[string]object.Object{"A": &object.FloatSgl{Value: 1.5}, "B$": &object.String{Value: "Hello"}, "C#": &object.FloatDbl{Value: 2000}}},
+		{inp: `10 INPUT; A$, B$`, keys: "\"Smith, John\", x\r", vars: map[string]object.Object{"A$": &object.String{Value: "Smith, John"}, "B$": &object.String{Value: "x"}}},
+		{inp: `10 INPUT A, B`, keys: "1\r1,2\r", vars: map[string]object.Object{"A": &object.FloatSgl{Value: 1}, "B": &object.FloatSgl{Value: 2}}},
+		{inp: `10 INPUT A`, keys: "fred\r\"5\"\r7\r", vars: map[string]object.Object{"A": &object.FloatSgl{Value: 7}}},
+		{inp: `10 INPUT A%`, keys: "40000\r-3\r", vars: map[string]object.Object{"A%": &object.Integer{Value: -3}}},
+		{inp: `10 INPUT A$`, keys: "abc\x08d\r", vars: map[string]object.Object{"A$": &object.String{Value: "abd"}}},
+		{inp: `10 INPUT A$`, keys: "ab\x00Kc\r", vars: map[string]object.Object{"A$": &object.String{Value: "abc"}}},
+		{inp: `10 LINE INPUT A$`, keys: "\"Hi\", there\r", vars: map[string]object.Object{"A$": &object.String{Value: `"Hi", there`}}},
+		{inp: `10 LINE INPUT; "Name? "; N$`, keys: "Bob\r", vars: map[string]object.Object{"N$": &object.String{Value: "Bob"}}},
+		{inp: `10 LINE INPUT A`, keys: "Bob\r", err: true},
+		{inp: `10 DEFSTR N : INPUT NM, A`, keys: "Bob, 5\r", vars: map[string]object.Object{"NM": &object.String{Value: "Bob"}, "A": &object.FloatSgl{Value: 5}}},
+		{inp: `10 DEFDBL D : INPUT D`, keys: "5\r", vars: map[string]object.Object{"D": &object.FloatDbl{Value: 5}}},
+		{inp: `10 INPUT A`, keys: "12", brk: true},
+		{inp: `10 INPUT A`, keys: "1\x03", brk: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := parser.New(l)
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		*mt.StrVal = tt.keys
+		env := object.NewTermEnvironment(mt)
+		p.ParseProgram(env)
+
+		assert.Zerof(t, len(p.Errors()), "%s failed to parse", tt.inp)
+
+		env.SetRun(true)
+		rc := Eval(&ast.Program{}, env.StatementIter(), env)
+
+		if tt.err {
+			assert.NotNilf(t, rc, "%s didn't fail", tt.inp)
+			continue
+		}
+
+		assert.Nilf(t, rc, "%s returned %T", tt.inp, rc)
+		assert.Equalf(t, tt.brk, env.GetSetting(settings.Restart) != nil, "%s break not handled", tt.inp)
+		for k, v := range tt.vars {
+			assert.Equalf(t, v, env.Get(k), "%s set %s incorrectly", tt.inp, k)
+		}
+	}
+}
+
+func ExampleInputStatement() {
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	*mt.StrVal = "one\r1\r"
+	env := object.NewTermEnvironment(mt)
+
+	testEvalEnv(`10 INPUT "Number"; A`, "A", env)
+	// Output:
+	// Number? one
+	// ?Redo from start
+	// Number? 1
+}
+
 func Test_GosubGotoStatements(t *testing.T) {
 	tests := []struct {
 		inp   string
@@ -988,6 +1123,31 @@ func Test_GosubGotoStatements(t *testing.T) {
 	}
 }
 
+// jumping back into a line that has already run starts it from the top
+func Test_GotoRepeatsLine(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp int16
+	}{
+		{inp: "10 X% = X% + 1 : IF X% < 3 THEN GOTO 10\n20 STOP", exp: 3},
+		{inp: "10 X% = X% + 1 : IF X% < 4 THEN GOTO 20\n20 IF X% < 4 THEN GOTO 10", exp: 4},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := parser.New(l)
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		p.ParseProgram(env)
+		env.SetRun(true)
+		Eval(&ast.Program{}, env.StatementIter(), env)
+
+		testIntegerObject(t, env.Get("X%"), tt.exp)
+	}
+}
+
 // GOTO and GOSUB can also be entered from the command line to start a program running
 func Test_GotoGosubDirect(t *testing.T) {
 	tests := []struct {
@@ -1030,13 +1190,13 @@ func Test_GotoGosubDirect(t *testing.T) {
 func Test_EvalIntegerExpression(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected int16
+		expected float32
 	}{
 		{"10 X = -5", -5},
 		{"50 X=5 + 5", 10},
-		{"70 X=5 < 10", 1},
+		{"70 X=5 < 10", -1},
 		{"80 x=5 > 10", 0},
-		{"110 x=10 > 1", 1},
+		{"110 x=10 > 1", -1},
 		{"120 x=10 < 1", 0},
 		{"130 x=10 / 2", 5},
 		{"160 X=10 \\ 2", 5},
@@ -1060,9 +1220,162 @@ func Test_EvalIntegerExpression(t *testing.T) {
 
 		val := env.Get("X")
 
-		x, ok := val.(*object.Integer)
+		x, ok := val.(*object.FloatSgl)
 		assert.True(t, ok, "eval %s didn't set X!", tt.input)
-		assert.Equal(t, tt.expected, int16(x.Value), "eval %s expected %d, got %d", tt.input, tt.expected, x.Value)
+		assert.Equal(t, tt.expected, x.Value, "eval %s expected %g, got %g", tt.input, tt.expected, x.Value)
+	}
+}
+
+func Test_LogicalOperators(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp object.Object
+		err int16
+	}{
+		{inp: "X = 12 AND 10", exp: &object.FloatSgl{Value: 8}},
+		{inp: "X = 12 or 10", exp: &object.FloatSgl{Value: 14}},
+		{inp: "X = 12 XOR 10", exp: &object.FloatSgl{Value: 6}},
+		{inp: "X = 12 EQV 10", exp: &object.FloatSgl{Value: -7}},
+		{inp: "X = 12 IMP 10", exp: &object.FloatSgl{Value: -5}},
+		{inp: "X = NOT 0", exp: &object.FloatSgl{Value: -1}},
+		{inp: "X = NOT 5", exp: &object.FloatSgl{Value: -6}},
+		{inp: "X = NOT 1 = 2", exp: &object.FloatSgl{Value: -1}},
+		{inp: "X = 3 > 2 AND 2 > 1", exp: &object.FloatSgl{Value: -1}},
+		{inp: "X = 1 OR 2 AND 0", exp: &object.FloatSgl{Value: 1}},
+		{inp: "X = 7.6 AND 255", exp: &object.FloatSgl{Value: 8}},
+		{inp: "X = 40000 AND 1", err: berrors.Overflow},
+		{inp: "X = NOT 32768#", err: berrors.Overflow},
+		{inp: `X = "A" OR 1`, err: berrors.TypeMismatch},
+		{inp: "X = 2 ^ 10", exp: &object.FloatSgl{Value: 1024}},
+		{inp: "X = -2 ^ 2", exp: &object.FloatSgl{Value: -4}},
+		{inp: "X = 2 ^ 3 ^ 2", exp: &object.FloatSgl{Value: 64}},
+		{inp: "X = 3 * 2 ^ 2", exp: &object.FloatSgl{Value: 12}},
+		{inp: "X = 1.5 ^ 2", exp: &object.FloatSgl{Value: 2.25}},
+		{inp: "D# = 1 / 3# : X = D# ^ 1", exp: &object.FloatSgl{Value: 1.0 / 3}},
+		{inp: "X = 0 ^ -1", err: berrors.DivByZero},
+		{inp: "X = 2 ^ 0.5", exp: &object.FloatSgl{Value: math.Sqrt2}},
+		{inp: "X = (-8) ^ 0.5", err: berrors.IllegalFuncCallErr},
+		{inp: "X = 10 ^ 39", err: berrors.Overflow},
+		{inp: `X = "A" ^ 2`, err: berrors.TypeMismatch},
+		{inp: "X = 7 mod 3 + 10 \\ 4", exp: &object.FloatSgl{Value: 3}},
+	}
+
+	for _, tt := range tests {
+		testRun(t, tt.inp, map[string]object.Object{"X": tt.exp}, tt.err)
+	}
+}
+
+func Test_NumericRules(t *testing.T) {
+	tests := []struct {
+		inp string
+		vr  string
+		exp object.Object
+		err int16
+	}{
+		{inp: "X = 32767 + 1", exp: &object.FloatSgl{Value: 32768}},
+		{inp: "X = -32768 - 1", exp: &object.FloatSgl{Value: -32769}},
+		{inp: "X = 30000 * 30000", exp: &object.FloatSgl{Value: 9e8}},
+		{inp: "Y% = -32768 : X = -Y%", exp: &object.FloatSgl{Value: 32768}},
+		{inp: "X = 40000 + 1", exp: &object.FloatSgl{Value: 40001}},
+		{inp: "X = 1 / 3", exp: &object.FloatSgl{Value: 1.0 / 3}},
+		{inp: "X = 1 / 3#", exp: &object.FloatSgl{Value: 1.0 / 3}},
+		{inp: "X# = 1 / 3#", vr: "X#", exp: &object.FloatDbl{Value: 1.0 / 3}},
+		{inp: `X = "X"`, err: berrors.TypeMismatch},
+		{inp: "X = 10 / 4", exp: &object.FloatSgl{Value: 2.5}},
+		{inp: "X = 1E+38 * 10", err: berrors.Overflow},
+		{inp: "X = 1D+300 * 1D+300", err: berrors.Overflow},
+		{inp: "X = 7 \\ 2", exp: &object.FloatSgl{Value: 3}},
+		{inp: "X = -7 \\ 2", exp: &object.FloatSgl{Value: -3}},
+		{inp: "X = 7.5 \\ 2", exp: &object.FloatSgl{Value: 4}},
+		{inp: "X = -7 MOD 3", exp: &object.FloatSgl{Value: -1}},
+		{inp: "X = 7.5 MOD 3", exp: &object.FloatSgl{Value: 2}},
+		{inp: "X = 7 \\ 0", err: berrors.DivByZero},
+		{inp: "X = 7 MOD 0.2", err: berrors.DivByZero},
+		{inp: "X = 40000 \\ 2", err: berrors.Overflow},
+		{inp: "X = -32768 \\ -1", err: berrors.Overflow},
+		{inp: `X = "A" MOD 2`, err: berrors.TypeMismatch},
+		{inp: "X% = 3.5", vr: "X%", exp: &object.Integer{Value: 4}},
+		{inp: "X% = -2.5", vr: "X%", exp: &object.Integer{Value: -3}},
+		{inp: "X% = 40000", err: berrors.Overflow},
+		{inp: "X% = 32767 : X% = X% + 1", err: berrors.Overflow},
+		{inp: "X! = 1 / 3#", vr: "X!", exp: &object.FloatSgl{Value: 1.0 / 3}},
+		{inp: "X! = 1D+39", err: berrors.Overflow},
+		{inp: "X# = 5", vr: "X#", exp: &object.FloatDbl{Value: 5}},
+		{inp: "X# = 40000", vr: "X#", exp: &object.FloatDbl{Value: 40000}},
+		{inp: "DIM X%(5) : X%(2) = 40000", err: berrors.Overflow},
+		{inp: `X$ = 5`, err: berrors.TypeMismatch},
+		{inp: `X% = "5"`, err: berrors.TypeMismatch},
+	}
+
+	for _, tt := range tests {
+		if len(tt.vr) == 0 {
+			tt.vr = "X"
+		}
+		testRun(t, tt.inp, map[string]object.Object{tt.vr: tt.exp}, tt.err)
+	}
+}
+
+func Test_DefTypeStatement(t *testing.T) {
+	tests := []struct {
+		inp string
+		vr  string
+		exp object.Object
+		err int16
+	}{
+		{inp: "DEFINT I-N : I = 3.7", vr: "I", exp: &object.Integer{Value: 4}},
+		{inp: "DEFINT I-N : NUM = -2.5", vr: "NUM", exp: &object.Integer{Value: -3}},
+		{inp: "DEFINT I-N : X = 15 / 4", exp: &object.FloatSgl{Value: 3.75}},
+		{inp: "DEFINT I-N : I! = 15 / 4", vr: "I!", exp: &object.FloatSgl{Value: 3.75}},
+		{inp: "DEFINT A : A = 40000", err: berrors.Overflow},
+		{inp: `DEFSTR S : S = "X"`, vr: "S", exp: &object.String{Value: "X"}},
+		{inp: "DEFSTR S : S = 5", err: berrors.TypeMismatch},
+		{inp: "DEFSTR S : X = LEN(S)", exp: &object.FloatSgl{Value: 0}},
+		{inp: "DEFDBL D : D = 1 / 3#", vr: "D", exp: &object.FloatDbl{Value: 1.0 / 3}},
+		{inp: "DEFDBL D : D = 5", vr: "D", exp: &object.FloatDbl{Value: 5}},
+		{inp: "DEFDBL X : DEFSNG X : X = 5", exp: &object.FloatSgl{Value: 5}},
+		{inp: "DEFSTR S : DIM S(5) : S(1) = 5", err: berrors.TypeMismatch},
+		{inp: "DEFINT A-Z : DIM X(5) : X(2) = 40000", err: berrors.Overflow},
+		{inp: "DEFINT I-N : FOR I = 1 TO 2.5 STEP .5 : X = X + I : NEXT", exp: &object.FloatSgl{Value: 3}},
+		{inp: "DEFINT I-N : FOR I = 1.6 TO 3 : NEXT", vr: "I", exp: &object.Integer{Value: 4}},
+		{inp: "FOR I% = 32766 TO 32767 : NEXT", err: berrors.Overflow},
+	}
+
+	for _, tt := range tests {
+		if len(tt.vr) == 0 {
+			tt.vr = "X"
+		}
+		testRun(t, tt.inp, map[string]object.Object{tt.vr: tt.exp}, tt.err)
+	}
+}
+
+func Test_DefTypeChain(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp object.Object
+	}{
+		{inp: `10 DEFINT I : COMMON A : CHAIN "next.bas"`, exp: &object.Integer{Value: 3}},
+		{inp: `10 DEFINT I : CHAIN "next.bas"`, exp: &object.FloatSgl{Value: 2.5}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.inp))
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(200)
+			res.Write([]byte(`10 I = 5 / 2`))
+		}))
+		defer ts.Close()
+		env.SaveSetting(settings.ServerURL, &ast.StringLiteral{Value: ts.URL})
+		p.ParseProgram(env)
+
+		assert.Zerof(t, len(p.Errors()), "%s failed to parse", tt.inp)
+
+		env.SetRun(true)
+		Eval(&ast.Program{}, env.StatementIter(), env)
+
+		assert.Equalf(t, tt.exp, env.Get("I"), "%s kept the wrong type", tt.inp)
 	}
 }
 
@@ -1093,7 +1406,7 @@ func TestDblInetegerExpression(t *testing.T) {
 
 		val := env.Get("X")
 
-		x, ok := val.(*object.IntDbl)
+		x, ok := val.(*object.FloatSgl)
 		assert.True(t, ok, "eval %s didn't set X!", tt.inp)
 		assert.Equal(t, tt.exp, int32(x.Value), "eval %s expected %d, got %d", tt.inp, tt.exp, x.Value)
 	}
@@ -1120,6 +1433,44 @@ func TestDim_Statements(t *testing.T) {
 	// 4
 }
 
+func Test_ArraySemantics(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp object.Object
+		err int16
+	}{
+		{inp: "DIM A(3) : A(0) = 5 : A(3) = 6 : X = A(0) + A(3)", exp: &object.FloatSgl{Value: 11}},
+		{inp: "DIM A(3) : A(4) = 1", err: berrors.SubscriptRange},
+		{inp: "DIM A(3) : X = A(-1)", err: berrors.SubscriptRange},
+		{inp: "OPTION BASE 1 : DIM A(3) : A(3) = 2 : X = A(3)", exp: &object.FloatSgl{Value: 2}},
+		{inp: "OPTION BASE 1 : DIM A(3) : X = A(0)", err: berrors.SubscriptRange},
+		{inp: "OPTION BASE 1 : DIM A(0)", err: berrors.SubscriptRange},
+		{inp: "DIM A(2) : OPTION BASE 1", err: berrors.DuplicateDefinition},
+		{inp: "DIM A(2) : ERASE A : OPTION BASE 1 : DIM A(2) : X = A(2)", exp: &object.FloatSgl{Value: 0}},
+		{inp: "DIM M(2, 3) : M(2, 3) = 7 : X = M(2, 3)", exp: &object.FloatSgl{Value: 7}},
+		{inp: "DIM M(2, 3) : X = M(3, 2)", err: berrors.SubscriptRange},
+		{inp: "DIM M(2, 3) : X = M(1)", err: berrors.SubscriptRange},
+		{inp: "DIM M(2, 3) : X = M(1, 1, 1)", err: berrors.SubscriptRange},
+		{inp: "M(2, 10, 1) = 4 : X = M(2, 10, 1)", exp: &object.FloatSgl{Value: 4}},
+		{inp: "X = M(11, 1)", err: berrors.SubscriptRange},
+		{inp: "A(1) = 1 : DIM A(5)", err: berrors.DuplicateDefinition},
+		{inp: "DIM A(5), A(6)", err: berrors.DuplicateDefinition},
+		{inp: "DIM A$(5) : ERASE A$ : DIM A$(6) : A$(6) = \"Z\" : X = LEN(A$(6))", exp: &object.FloatSgl{Value: 1}},
+		{inp: "ERASE A", err: berrors.IllegalFuncCallErr},
+		{inp: "DIM A(1000, 1000)", err: berrors.OutOfMemory},
+		{inp: "DIM A(1,1,1,1,1,1,1,1,1,1,1,1,1,1,1) : A(1,1,1,1,1,1,1,1,1,1,1,1,1,1,1) = 3 : X = A(1,1,1,1,1,1,1,1,1,1,1,1,1,1,1)", exp: &object.FloatSgl{Value: 3}},
+	}
+
+	for _, tt := range tests {
+		testRun(t, tt.inp, map[string]object.Object{"X": tt.exp}, tt.err)
+	}
+
+	// the most dimensions allowed
+	subs := strings.Repeat("0,", 255)
+	testRun(t, "DIM A("+subs[:len(subs)-1]+") : X = 1", map[string]object.Object{"X": &object.FloatSgl{Value: 1}}, 0)
+	testRun(t, "DIM B("+subs+"0)", nil, berrors.SubscriptRange)
+}
+
 func testEval(input string, vbl string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -1163,6 +1514,53 @@ func testEvalEnv(input string, vbl string, env *object.Environment) object.Objec
 	return env.Get(vbl)
 }
 
+// testRun runs inp as line 10 of a program, then checks that it failed
+// with err or, if err is zero, that it left each of vars as expected
+func testRun(t *testing.T, inp string, vars map[string]object.Object, err int16) {
+	t.Helper()
+
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	testRunEnv(t, inp, vars, err, object.NewTermEnvironment(mt))
+}
+
+// testRunEnv is testRun with an environment the caller has set up
+func testRunEnv(t *testing.T, inp string, vars map[string]object.Object, err int16, env *object.Environment) {
+	t.Helper()
+
+	p := parser.New(lexer.New("10 " + inp))
+	p.ParseProgram(env)
+	if !assert.Zerof(t, len(p.Errors()), "%s failed to parse", inp) {
+		return
+	}
+
+	env.SetRun(true)
+	rc := Eval(&ast.Program{}, env.StatementIter(), env)
+
+	if err != 0 {
+		e, ok := rc.(*object.Error)
+		if assert.Truef(t, ok, "%s didn't fail", inp) {
+			assert.Equalf(t, int(err), e.Code, "%s gave wrong error", inp)
+		}
+		return
+	}
+
+	assert.Nilf(t, rc, "%s returned %T", inp, rc)
+	for k, v := range vars {
+		assert.Equalf(t, v, env.Get(k), "%s set %s incorrectly", inp, k)
+	}
+}
+
+// testClientEnv builds an environment that talks to mc instead of a server
+func testClientEnv(mc *mocks.MockClient) *object.Environment {
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	env := object.NewTermEnvironment(mt)
+	env.SetClient(mc)
+
+	return env
+}
+
 func testEvalWithClient(input string, file string, err *error) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -1197,15 +1595,34 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int16) bool {
 	return true
 }
 
+func testSingleObject(t *testing.T, obj object.Object, expected float32) bool {
+	result, ok := obj.(*object.FloatSgl)
+	if !ok {
+		t.Errorf("object is not FloatSgl. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
 func Test_IfExpression(t *testing.T) {
 	tests := []struct {
 		inp string
-		exp int
+		exp float32
+		err int
 	}{
 		{inp: "10 IF 5 < 6 THEN 30\n20 x=5\n30 x=6", exp: 6},
 		//{inp: "10 IF 5 < 6 GOTO 30\n20 x=5\n30 x=7", exp: 7},
 		{inp: "10 IF 5 < 6 THEN END\n20 x=5", exp: 0},
 		//{"10 IF 5 > 6 THEN 20 ELSE END\n20 5", &object.HaltSignal{}},
+		{inp: "10 X=1: IF X THEN 30\n20 X=5\n30 END", exp: 1},
+		{inp: "10 IF X THEN 30\n20 X=5\n30 END", exp: 5},
+		{inp: "10 X#=0.5: IF X# THEN X=7", exp: 7},
+		{inp: "10 IF 1.5 THEN X=8", exp: 8},
+		{inp: `10 IF "A" THEN X=9`, err: berrors.TypeMismatch},
 	}
 
 	for _, tt := range tests {
@@ -1222,14 +1639,22 @@ func Test_IfExpression(t *testing.T) {
 		env.SetRun(true)
 		rc := Eval(&ast.Program{}, env.StatementIter(), env)
 
+		if tt.err != 0 {
+			e, ok := rc.(*object.Error)
+			if assert.True(t, ok, "eval %s didn't fail", tt.inp) {
+				assert.Equal(t, tt.err, e.Code, "eval %s gave wrong error", tt.inp)
+			}
+			continue
+		}
+
 		assert.Nil(t, rc, "eval %s returned a %T", tt.inp, rc)
 
 		x := env.Get("X")
 
-		val, ok := x.(*object.Integer)
+		val, ok := x.(*object.FloatSgl)
 
 		assert.True(t, ok, "eval of %s failed to set X", tt.inp)
-		assert.Equal(t, tt.exp, int(val.Value), "eval of %s, expected %d, got %d", tt.inp, tt.exp, val.Value)
+		assert.Equal(t, tt.exp, val.Value, "eval of %s, expected %g, got %g", tt.inp, tt.exp, val.Value)
 	}
 }
 
@@ -1244,9 +1669,9 @@ func Test_EndStatement(t *testing.T) {
 		rc := testEval(tt.input, "A")
 
 		assert.NotNil(t, rc, "End statement returned a nil!")
-		ec, ok := rc.(*object.Integer)
-		assert.True(t, ok, "End statement didn't return an integer")
-		assert.Equal(t, 0, int(ec.Value))
+		ec, ok := rc.(*object.FloatSgl)
+		assert.True(t, ok, "End statement didn't return a single")
+		assert.Equal(t, float32(0), ec.Value)
 	}
 }
 
@@ -1299,11 +1724,194 @@ func Test_KeyStatement(t *testing.T) {
 	}
 }
 
+func Test_KeyTraps(t *testing.T) {
+	tests := []struct {
+		inp   string
+		state int
+		press bool
+		chk   string
+		exp   float32
+		err   int
+	}{
+		{inp: "10 I = I + 1 : IF I < 3 THEN 10\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapOn, press: true, chk: "C", exp: 1},
+		{inp: "10 I = I + 1 : IF I < 3 THEN 10\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapOn, press: true, chk: "I", exp: 3},
+		{inp: "10 I = I + 1 : IF I < 3 THEN 10\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapOn, chk: "C", exp: 0},
+		{inp: "10 I = I + 1 : IF I < 3 THEN 10\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapOff, press: true, chk: "C", exp: 0},
+		{inp: "10 A = C : KEY(1) ON : B = C\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapStop, press: true, chk: "A", exp: 0},
+		{inp: "10 A = C : KEY(1) ON : B = C\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapStop, press: true, chk: "B", exp: 1},
+		{inp: "10 KEY(1) OFF : KEY(1) ON : B = C\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapStop, press: true, chk: "B", exp: 0},
+		{inp: "10 KEY(1) STOP : KEY(1) ON : B = C\n20 END\n100 C = C + 1 : RETURN", state: ast.TrapOn, press: true, chk: "B", exp: 1},
+		{inp: "10 KEY(21) ON", err: berrors.IllegalFuncCallErr},
+		{inp: "10 KEY(0) STOP", err: berrors.IllegalFuncCallErr},
+		{inp: `10 KEY("1") ON`, err: berrors.Syntax},
+		{inp: "10 ON KEY(1) GOSUB 500", err: berrors.UnDefinedLineNumber},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		// F1 is trapped by the handler at line 100
+		traps := &ast.KeyTraps{}
+		traps.Keys[1] = ast.EventTrap{Line: 100, State: tt.state}
+		env.SaveSetting(settings.OnKeys, traps)
+		if tt.press {
+			keybuffer.GetKeyBuffer().SaveKeyStroke([]byte{0x1b, 0x4f, 0x50})
+		}
+
+		res := testEvalEnv(tt.inp, tt.chk, env)
+		keybuffer.GetKeyBuffer().TrapSeen(1)
+		env.SaveSetting(settings.OnKeys, nil)
+
+		if tt.err != 0 {
+			assert.IsTypef(t, &object.Error{}, res, "%s didn't fail", tt.inp)
+			if err, ok := res.(*object.Error); ok {
+				assert.Equalf(t, tt.err, err.Code, "%s gave the wrong error", tt.inp)
+			}
+			continue
+		}
+
+		testSingleObject(t, res, tt.exp)
+	}
+}
+
+func Test_OnKeyStatement(t *testing.T) {
+	tests := []struct {
+		inp   string
+		key   int
+		line  int
+		state int
+		def   string
+	}{
+		{inp: "10 ON KEY(2) GOSUB 100 : KEY(2) ON\n20 END\n100 RETURN", key: 2, line: 100, state: ast.TrapOn},
+		{inp: "10 ON KEY(14) GOSUB 100 : KEY(14) STOP\n20 END\n100 RETURN", key: 14, line: 100, state: ast.TrapStop},
+		{inp: "10 ON KEY(3) GOSUB 100 : ON KEY(3) GOSUB 0\n20 END\n100 RETURN", key: 3},
+		{inp: "10 KEY 15, CHR$(03)+CHR$(25) : ON KEY(15) GOSUB 100\n20 END\n100 RETURN", key: 15, line: 100, def: "P"},
+		{inp: "10 KEY 16, CHR$(0)+CHR$(&H48) : KEY(16) ON", key: 16, state: ast.TrapOn, def: "\x1b[A"},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		res := testEvalEnv(tt.inp, "", env)
+		_, failed := res.(*object.Error)
+		assert.Falsef(t, failed, "%s failed", tt.inp)
+
+		traps, ok := env.GetSetting(settings.OnKeys).(*ast.KeyTraps)
+		assert.Truef(t, ok, "%s didn't save key traps", tt.inp)
+		if ok {
+			assert.Equalf(t, tt.line, traps.Keys[tt.key].Line, "%s trap line", tt.inp)
+			assert.Equalf(t, tt.state, traps.Keys[tt.key].State, "%s trap state", tt.inp)
+			assert.Equalf(t, tt.def, traps.Defs[tt.key], "%s key definition", tt.inp)
+			assert.Equal(t, traps, keybuffer.GetKeyBuffer().KeyTraps, "key buffer doesn't have the traps")
+		}
+		env.SaveSetting(settings.OnKeys, nil)
+	}
+}
+
+func Test_TimerTraps(t *testing.T) {
+	tests := []struct {
+		inp string
+		chk string
+		exp float32
+		err int
+	}{
+		{inp: "10 ON TIMER(3) GOSUB 100 : TIMER ON\n20 I = I + 1 : IF C < 2 AND I < 100 THEN 20\n30 END\n100 C = C + 1 : RETURN", chk: "C", exp: 5},
+		{inp: "10 ON TIMER(5) GOSUB 100 : TIMER ON\n20 I = I + 1 : IF C < 1 THEN 20\n30 END\n100 C = C + 1 : RETURN", chk: "I", exp: 2},
+		{inp: "10 ON TIMER(3) GOSUB 100 : TIMER OFF\n20 I = I + 1 : IF I < 10 THEN 20\n30 END\n100 C = C + 1 : RETURN", chk: "C"},
+		{inp: "10 ON TIMER(3) GOSUB 100 : TIMER STOP\n20 I = I + 1 : IF I < 10 THEN 20\n30 TIMER ON : A = C : END\n100 C = C + 1 : RETURN", chk: "A", exp: 1},
+		{inp: "10 ON TIMER(0) GOSUB 100\n100 RETURN", err: berrors.IllegalFuncCallErr},
+		{inp: "10 ON TIMER(86401) GOSUB 100\n100 RETURN", err: berrors.IllegalFuncCallErr},
+		{inp: `10 ON TIMER("A") GOSUB 100` + "\n100 RETURN", err: berrors.Syntax},
+		{inp: "10 ON TIMER(10) GOSUB 500", err: berrors.UnDefinedLineNumber},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		// every look at the clock moves it ahead a second
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		env.SetClock(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+
+		res := testEvalEnv(tt.inp, tt.chk, env)
+
+		if tt.err != 0 {
+			assert.IsTypef(t, &object.Error{}, res, "%s didn't fail", tt.inp)
+			if err, ok := res.(*object.Error); ok {
+				assert.Equalf(t, tt.err, err.Code, "%s gave the wrong error", tt.inp)
+			}
+			continue
+		}
+
+		testSingleObject(t, res, tt.exp)
+	}
+}
+
+func Test_ClockStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		chk string
+		exp string
+		err int
+	}{
+		{inp: `10 D$ = DATE$`, chk: "D$", exp: "07-04-2025"},
+		{inp: `10 T$ = TIME$`, chk: "T$", exp: "13:02:03"},
+		{inp: `10 DATE$ = "1-2-99" : D$ = DATE$`, chk: "D$", exp: "01-02-1999"},
+		{inp: `10 DATE$ = "12/31/2001" : D$ = DATE$`, chk: "D$", exp: "12-31-2001"},
+		{inp: `10 DATE$ = "3-4-05" : D$ = DATE$ + " " + TIME$`, chk: "D$", exp: "03-04-2005 13:02:03"},
+		{inp: `10 TIME$ = "8" : T$ = TIME$`, chk: "T$", exp: "08:00:00"},
+		{inp: `10 TIME$ = "13:05" : T$ = DATE$ + " " + TIME$`, chk: "T$", exp: "07-04-2025 13:05:00"},
+		{inp: `10 DATE$ = "2-30-2020"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 DATE$ = "1-1-1979"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 DATE$ = "13-1-20"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 DATE$ = "1-1"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 TIME$ = "25:00"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 TIME$ = "12:60"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 TIME$ = "1:2:3:4"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 TIME$ = "AB"`, err: berrors.IllegalFuncCallErr},
+		{inp: `10 TIME$ = 5`, err: berrors.TypeMismatch},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		env.SetClock(func() time.Time { return time.Date(2025, 7, 4, 13, 2, 3, 0, time.Local) })
+
+		res := testEvalEnv(tt.inp, tt.chk, env)
+
+		if tt.err != 0 {
+			assert.IsTypef(t, &object.Error{}, res, "%s didn't fail", tt.inp)
+			if err, ok := res.(*object.Error); ok {
+				assert.Equalf(t, tt.err, err.Code, "%s gave the wrong error", tt.inp)
+			}
+			continue
+		}
+
+		if tv, ok := res.(*object.TypedVar); ok {
+			res = tv.Value
+		}
+		str, ok := res.(*object.String)
+		assert.Truef(t, ok, "%s gave %T", tt.inp, res)
+		if ok {
+			assert.Equalf(t, tt.exp, str.Value, "%s gave the wrong result", tt.inp)
+		}
+	}
+}
+
 func Test_LetStatements(t *testing.T) {
 	tests := []struct {
 		inp string
 		chk string
-		exp int16
+		exp float32
 	}{
 		{inp: "10 LET a = 5", chk: "a", exp: 5},
 		{inp: "20 LET a = 5 * 5", chk: "a", exp: 25},
@@ -1312,7 +1920,7 @@ func Test_LetStatements(t *testing.T) {
 		{inp: `50 LET a = 2*(4+1)`, chk: "a", exp: 10},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.inp, tt.chk), tt.exp)
+		testSingleObject(t, testEval(tt.inp, tt.chk), tt.exp)
 	}
 }
 
@@ -1526,24 +2134,257 @@ func Test_OnGoStatement(t *testing.T) {
 			// should be the expected error
 			err, ok := rc.(*object.Error)
 
-			assert.True(t, ok, "didn't get the expected error")
-			assert.EqualValues(t, tt.msg, err.Message, "didn't get the expected error")
-		} else {
-			// check the setting
-			assert.Equal(t, tt.jmp, code.CurLine(), "Jumped to wrong line")
-		}
+			assert.True(t, ok, "didn't get the expected error")
+			assert.EqualValues(t, tt.msg, err.Message, "didn't get the expected error")
+		} else {
+			// check the setting
+			assert.Equal(t, tt.jmp, code.CurLine(), "Jumped to wrong line")
+		}
+	}
+}
+
+func Test_OpenStatement(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp object.Object
+	}{
+		{inp: `10 OPEN "test.dat" FOR OUTPUT AS #1`},
+		// test his trash detection
+		{inp: `60 open "test3.out" FOR OUTPUT ACCESS WRITE LOCK READ AS #3 LEN = 128`,
+			exp: &object.Error{Message: "Syntax error in 60", Code: 2}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := parser.New(l)
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		env.SetClient(&mocks.MockClient{})
+		p.ParseProgram(env)
+
+		errs := p.Errors()
+
+		assert.Zero(t, len(errs), "parser threw some errors")
+
+		code := env.StatementIter()
+		env.SetRun(true)
+		rc := Eval(&ast.Program{}, code, env)
+		env.SetRun(false)
+
+		if tt.exp == nil {
+			assert.Nil(t, rc, "got %T back", rc)
+		} else {
+			assert.Equal(t, rc, tt.exp)
+		}
+
+	}
+}
+
+func Test_SequentialFiles(t *testing.T) {
+	tests := []struct {
+		inp    string
+		srvr   string // contents of the file on the server
+		status int    // http status the server returns
+		vars   map[string]object.Object
+		err    int16
+	}{
+		{inp: `OPEN "O", #1, "seq1.dat" : PRINT #1, "Hello" : CLOSE #1 : OPEN "seq1.dat" FOR INPUT AS #1 : LINE INPUT #1, A$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "Hello"}}},
+		{inp: `OPEN "seq2.dat" FOR OUTPUT AS #2 : WRITE #2, "Smith, John", 42, 1.5 : CLOSE : OPEN "I", #2, "seq2.dat" : INPUT #2, N$, A%, B : E = EOF(2)`,
+			vars: map[string]object.Object{"N$": &object.String{Value: "Smith, John"}, "A%": &object.Integer{Value: 42}, "B": &object.FloatSgl{Value: 1.5}, "E": &object.FloatSgl{Value: -1}}},
+		{inp: `OPEN "seq3.dat" FOR OUTPUT AS #1 : PRINT #1, "one" : CLOSE : OPEN "seq3.dat" FOR APPEND AS #1 : PRINT #1, "two" : CLOSE : OPEN "seq3.dat" FOR INPUT AS #1 : INPUT #1, A$, B$ : L = LOF(1)`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "one"}, "B$": &object.String{Value: "two"}, "L": &object.FloatSgl{Value: 10}}},
+		{inp: `OPEN "seq4.dat" FOR INPUT AS #1 : INPUT #1, A, B : E = EOF(1)`, srvr: "12 34\r\n",
+			vars: map[string]object.Object{"A": &object.FloatSgl{Value: 12}, "B": &object.FloatSgl{Value: 34}, "E": &object.FloatSgl{Value: -1}}},
+		{inp: `OPEN "seq5.dat" FOR INPUT AS #1 : INPUT #1, A, B`, srvr: "12\r\n", err: berrors.InputPastEnd},
+		{inp: `OPEN "seq6.dat" FOR INPUT AS #1`, status: 404, err: berrors.FileNotFound},
+		{inp: `OPEN "seq7.dat" FOR OUTPUT AS #1 : OPEN "seq8.dat" FOR OUTPUT AS #1`, err: berrors.FileAlreadyOpen},
+		{inp: `OPEN "seq9.dat" FOR OUTPUT AS #1 : OPEN "seq9.dat" FOR APPEND AS #2`, err: berrors.FileAlreadyOpen},
+		{inp: `PRINT #3, "nope"`, err: berrors.BadFileNum},
+		{inp: `OPEN "seq10.dat" FOR OUTPUT AS #1 : INPUT #1, A`, err: berrors.BadFileMode},
+		{inp: `OPEN "seq11.dat" FOR INPUT AS #1 : PRINT #1, A`, srvr: "1", err: berrors.BadFileMode},
+		{inp: `E = EOF(4)`, err: berrors.BadFileNum},
+		{inp: `OPEN "X", #1, "seq12.dat"`, err: berrors.BadFileMode},
+		{inp: `OPEN "seq13.dat" FOR OUTPUT AS #1 : WRITE #1, "Hi", 1.5; -2, 1 / 3 : WRITE #1, : CLOSE : OPEN "seq13.dat" FOR INPUT AS #1 : LINE INPUT #1, A$ : LINE INPUT #1, B$ : L = LOF(1)`,
+			vars: map[string]object.Object{"A$": &object.String{Value: `"Hi",1.5,-2,.3333333`}, "B$": &object.String{Value: ""}, "L": &object.FloatSgl{Value: 24}}},
+		{inp: `OPEN "seq14.dat" FOR OUTPUT AS #1 : PRINT #1, "A"; : PRINT #1, "B", 5; TAB(20); "C" : CLOSE : OPEN "seq14.dat" FOR INPUT AS #1 : LINE INPUT #1, A$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "AB             5   C"}}},
+		{inp: `F = 1 : OPEN "seq15.dat" FOR OUTPUT AS #F + 1 : PRINT #2, "X" : CLOSE #F + 1 : OPEN "I", #F * 3, "seq15.dat" : LINE INPUT #3, A$ : CLOSE F * 3 : E = EOF(3)`,
+			err: berrors.BadFileNum},
+		{inp: `F = 1 : OPEN "seq16.dat" FOR OUTPUT AS #F + 1 : PRINT #2, "X" : CLOSE #F + 1 : OPEN "I", #F * 3, "seq16.dat" : LINE INPUT #3, A$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "X"}}},
+		{inp: `OPEN "seq17.dat" FOR OUTPUT AS #"1"`, err: berrors.TypeMismatch},
+		{inp: `CLOSE #"1"`, err: berrors.TypeMismatch},
+		// the file name can come from any string expression
+		{inp: `F$ = "seq18.dat" : OPEN F$ FOR OUTPUT AS #1 : PRINT #1, "one" : CLOSE : OPEN F$ FOR APPEND AS #1 : PRINT #1, "two" : CLOSE : OPEN "I", #1, "SEQ" + "18.DAT" : INPUT #1, A$, B$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "one"}, "B$": &object.String{Value: "two"}}},
+		{inp: `N$ = "seq19.dat" : OPEN "O",#1,N$ : PRINT #1, "Hi" : CLOSE : OPEN N$ FOR INPUT AS #1 : LINE INPUT #1, A$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "Hi"}}},
+		{inp: `OPEN N$ FOR OUTPUT AS #1`, err: berrors.BadFileName},
+		{inp: `N = 1 : OPEN "O", #1, N`, err: berrors.TypeMismatch},
+	}
+
+	for _, tt := range tests {
+		env := testClientEnv(&mocks.MockClient{Contents: tt.srvr, StatusCode: tt.status})
+		testRunEnv(t, tt.inp, tt.vars, tt.err, env)
+	}
+}
+
+func Test_StringStatements(t *testing.T) {
+	tests := []struct {
+		inp  string
+		vars map[string]object.Object
+		err  int16
+	}{
+		{inp: `A = 1 : B = 2 : SWAP A, B`, vars: map[string]object.Object{"A": &object.FloatSgl{Value: 2}, "B": &object.FloatSgl{Value: 1}}},
+		{inp: `A$ = "X" : B$ = "Y" : SWAP A$, B$`, vars: map[string]object.Object{"A$": &object.String{Value: "Y"}, "B$": &object.String{Value: "X"}}},
+		{inp: `DIM A(3) : A(1) = 5 : A(3) = 7 : SWAP A(1), A(3) : X = A(1) : Y = A(3)`, vars: map[string]object.Object{"X": &object.FloatSgl{Value: 7}, "Y": &object.FloatSgl{Value: 5}}},
+		{inp: `A# = 1.5 : DIM B#(2) : SWAP A#, B#(2) : X# = B#(2)`, vars: map[string]object.Object{"A#": &object.FloatDbl{Value: 0}, "X#": &object.FloatDbl{Value: 1.5}}},
+		{inp: `A% = 1 : B# = 2 : SWAP A%, B#`, err: berrors.TypeMismatch},
+		{inp: `A! = 1 : B = 2 : SWAP A!, B`, vars: map[string]object.Object{"A!": &object.FloatSgl{Value: 2}, "B": &object.FloatSgl{Value: 1}}},
+		{inp: `DEFINT I-N : I = 1 : K% = 2 : SWAP I, K%`, vars: map[string]object.Object{"I": &object.Integer{Value: 2}, "K%": &object.Integer{Value: 1}}},
+		{inp: `DEFINT I-N : I = 1 : A = 2 : SWAP I, A`, err: berrors.TypeMismatch},
+		{inp: `A$ = "X" : SWAP A$, B`, err: berrors.TypeMismatch},
+		{inp: `DIM A(3) : SWAP A(1), A(4)`, err: berrors.SubscriptRange},
+		{inp: `A$ = "KANSAS CITY, MO" : MID$(A$, 14) = "KS"`, vars: map[string]object.Object{"A$": &object.String{Value: "KANSAS CITY, KS"}}},
+		{inp: `A$ = "ABCDEF" : MID$(A$, 2, 2) = "xyz"`, vars: map[string]object.Object{"A$": &object.String{Value: "AxyDEF"}}},
+		{inp: `A$ = "ABCDEF" : MID$(A$, 5) = "123456"`, vars: map[string]object.Object{"A$": &object.String{Value: "ABCD12"}}},
+		{inp: `A$ = "ABCDEF" : MID$(A$, 2) = "x"`, vars: map[string]object.Object{"A$": &object.String{Value: "AxCDEF"}}},
+		{inp: `DIM A$(2) : A$(1) = "ABC" : MID$(A$(1), 3) = "Z" : X$ = A$(1)`, vars: map[string]object.Object{"X$": &object.String{Value: "ABZ"}}},
+		{inp: `A$ = "ABC" : MID$(A$, 4) = "Z"`, err: berrors.IllegalFuncCallErr},
+		{inp: `A$ = "ABC" : MID$(A$, 0) = "Z"`, err: berrors.IllegalFuncCallErr},
+		{inp: `A$ = "ABC" : MID$(A$, 1, -1) = "Z"`, err: berrors.IllegalFuncCallErr},
+		{inp: `A$ = "ABC" : MID$(A$, 1) = 5`, err: berrors.TypeMismatch},
+		{inp: `MID$(A%, 1) = "Z"`, err: berrors.TypeMismatch},
+	}
+
+	for _, tt := range tests {
+		testRun(t, tt.inp, tt.vars, tt.err)
+	}
+}
+
+func Test_WidthStatement(t *testing.T) {
+	tests := []struct {
+		inp   string
+		vars  map[string]object.Object
+		width int // what the console got switched to, 0 if it wasn't
+		err   int16
+	}{
+		{inp: `WIDTH 40`, width: 40},
+		{inp: `WIDTH 80`},
+		{inp: `WIDTH 40, 25`, width: 40},
+		{inp: `WIDTH "scrn:", 40`, width: 40},
+		{inp: `WIDTH 40, 24`, err: berrors.IllegalFuncCallErr},
+		{inp: `WIDTH 60`, err: berrors.IllegalFuncCallErr},
+		{inp: `WIDTH "40"`, err: berrors.TypeMismatch},
+		{inp: `WIDTH "LPT1:", 256`, err: berrors.IllegalFuncCallErr},
+		{inp: `WIDTH "LPT1:", 0`, err: berrors.IllegalFuncCallErr},
+		{inp: `WIDTH "XYZ:", 80`, err: berrors.BadFileName},
+		{inp: `WIDTH #1, 80`, err: berrors.BadFileNum},
+		{inp: `OPEN "wdth1.dat" FOR OUTPUT AS #1 : WIDTH #1, 10 : PRINT #1, "ABCDEFGHIJKL"; : PRINT #1, 1234567 : CLOSE : OPEN "wdth1.dat" FOR INPUT AS #1 : LINE INPUT #1, A$ : LINE INPUT #1, B$ : LINE INPUT #1, C$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "ABCDEFGHIJ"}, "B$": &object.String{Value: "KL"}, "C$": &object.String{Value: " 1234567 "}}},
+		{inp: `WIDTH "LPT1:", 5 : OPEN "LPT1:" FOR OUTPUT AS #1 : PRINT #1, "ABCDEFG" : CLOSE : OPEN "LPT1:" FOR INPUT AS #1 : LINE INPUT #1, A$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "ABCDE"}}},
+		{inp: `OPEN "wdth2.dat" FOR OUTPUT AS #1 : WIDTH #1, 0`, err: berrors.IllegalFuncCallErr},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		mt.SawWidth = new(int)
+		env := object.NewTermEnvironment(mt)
+		env.SetClient(&mocks.MockClient{})
+
+		testRunEnv(t, tt.inp, tt.vars, tt.err, env)
+		assert.Equalf(t, tt.width, *mt.SawWidth, "%s set the console width", tt.inp)
 	}
 }
 
-func Test_OpenStatement(t *testing.T) {
+func Test_PrintWidth(t *testing.T) {
+	p := parser.New(lexer.New(`10 WIDTH 40 : PRINT STRING$(45, "A") : PRINT "A", "B", "C"`))
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	mt.SawWidth = new(int)
+	mt.ExpMsg = &mocks.Expector{Exp: []string{strings.Repeat("A", 40), "", "AAAAA", "", "A", "             ", "B", "", "C", ""}}
+	env := object.NewTermEnvironment(mt)
+	p.ParseProgram(env)
+
+	env.SetRun(true)
+	rc := Eval(&ast.Program{}, env.StatementIter(), env)
+
+	assert.Nil(t, rc, "PRINT at WIDTH 40 returned %T", rc)
+	assert.False(t, mt.ExpMsg.Failed, "PRINT didn't wrap at WIDTH 40")
+	assert.Nil(t, mt.ExpMsg.Exp, "PRINT at WIDTH 40 missed some output")
+}
+
+func Test_RandomFiles(t *testing.T) {
+	tests := []struct {
+		inp    string
+		file   string // contents of an existing data file
+		status int    // http status the server returns
+		vars   map[string]object.Object
+		err    int16
+	}{
+		{inp: `OPEN "R", #1, "rnd1.dat", 16 : FIELD #1, 2 AS I$, 4 AS S$, 8 AS D$ : LSET I$ = MKI$(-5) : LSET S$ = MKS$(70000) : LSET D$ = MKD$(123456) : PUT #1, 2 : CLOSE
+20 OPEN "rnd1.dat" FOR RANDOM AS #1 LEN = 16 : FIELD #1, 2 AS A$, 4 AS B$, 8 AS C$ : GET #1, 2 : I% = CVI(A$) : S = CVS(B$) : D# = CVD(C$) : L = LOF(1) : R = LOC(1)`, status: 200,
+			vars: map[string]object.Object{"I%": &object.Integer{Value: -5}, "S": &object.FloatSgl{Value: 70000}, "D#": &object.FloatDbl{Value: 123456}, "L": &object.FloatSgl{Value: 32}, "R": &object.FloatSgl{Value: 2}}},
+		{inp: `OPEN "R", #1, "rnd8.dat", 12 : FIELD #1, 4 AS S$, 8 AS D$ : GET #1, 1 : S = CVS(S$) : D# = CVD(D$)`,
+			file: "\x00\x00\xa0\x84\xd0\xcc\xcc\xcc\xcc\xcc\xcc\x7d",
+			vars: map[string]object.Object{"S": &object.FloatSgl{Value: -10}, "D#": &object.FloatDbl{Value: -0.1}}},
+		{inp: `OPEN "R", #1, "rnd2.dat", 10 : FIELD #1, 5 AS N$, 5 AS M$ : LSET N$ = "AB" : A$ = N$ : RSET N$ = "AB" : B$ = N$ : LSET M$ = "TOO LONG" : C$ = M$`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "AB   "}, "B$": &object.String{Value: "   AB"}, "C$": &object.String{Value: "TOO L"}}},
+		{inp: `X$ = "12345" : LSET X$ = "ab" : Y$ = "12345" : RSET Y$ = "ab"`,
+			vars: map[string]object.Object{"X$": &object.String{Value: "ab   "}, "Y$": &object.String{Value: "   ab"}}},
+		{inp: `OPEN "R", #1, "rnd9.dat", 6 : FIELD #1, 6 AS A$ : LSET A$ = "abcdef" : MID$(A$, 2, 3) = "XYZW" : B$ = A$`,
+			vars: map[string]object.Object{"B$": &object.String{Value: "aXYZef"}}},
+		{inp: `OPEN "R", #1, "rnd3.dat", 4 : FIELD #1, 4 AS A$ : LSET A$ = "abcd" : PUT #1 : LSET A$ = "efgh" : PUT #1 : GET #1, 1 : B$ = A$ : GET #1 : E = EOF(1) : GET #1 : F = EOF(1)`,
+			vars: map[string]object.Object{"B$": &object.String{Value: "abcd"}, "A$": &object.String{Value: "\x00\x00\x00\x00"}, "E": &object.FloatSgl{Value: 0}, "F": &object.FloatSgl{Value: -1}}},
+		{inp: `OPEN "R", #1, "rnd4.dat", 4 : FIELD #1, 3 AS A$, 2 AS B$`, err: berrors.FieldOverflow},
+		{inp: `OPEN "R", #1, "rnd12.dat", 4 : FIELD #1, 4 AS A$ : LSET A$ = "abcd" : A$ = "zz" : LSET A$ = "wxyz" : PUT #1, 1 : FIELD #1, 4 AS C$ : GET #1, 1`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "wx"}, "C$": &object.String{Value: "abcd"}}},
+		{inp: `OPEN "R", #1, "rnd5.dat", 4 : PUT #1, 0`, err: berrors.BadRecordNum},
+		{inp: `OPEN "R", #1, "rnd5.dat", 4 : PUT #1, 2000000000`, err: berrors.BadRecordNum},
+		{inp: `OPEN "R", #1, "rnd5.dat", 4 : GET #1, 16777216`, err: berrors.BadRecordNum},
+		{inp: `OPEN "O", #1, "rnd6.dat" : GET #1`, err: berrors.BadFileMode},
+		{inp: `OPEN "R", #1, "rnd7.dat", 4 : FIELD #1, 4 AS A`, err: berrors.TypeMismatch},
+		{inp: `GET #2, 1`, err: berrors.BadFileNum},
+		{inp: `OPEN "R", #1, "rnd10.dat", 0`, err: berrors.IllegalFuncCallErr},
+		{inp: `OPEN "rnd10.dat" FOR RANDOM AS #1 LEN = 40000`, err: berrors.IllegalFuncCallErr},
+		{inp: `OPEN "rnd10.dat" FOR RANDOM AS #1 LEN = -5`, err: berrors.IllegalFuncCallErr},
+		{inp: `OPEN "R", #1, "rnd10.dat", -5`, err: berrors.IllegalFuncCallErr},
+		{inp: `OPEN "R", #1, "rnd11.dat", 32767 : FIELD #1, 32767 AS A$ : LSET A$ = "Z" : PUT #1, 1 : L = LOF(1)`,
+			vars: map[string]object.Object{"L": &object.FloatSgl{Value: 32767}}},
+	}
+
+	for _, tt := range tests {
+		env := testClientEnv(&mocks.MockClient{Contents: tt.file, StatusCode: tt.status})
+		testRunEnv(t, tt.inp, tt.vars, tt.err, env)
+	}
+}
+
+func ExampleWriteStatement() {
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	env := object.NewTermEnvironment(mt)
+
+	testEvalEnv(`10 WRITE "A", 1, "B"`, "A", env)
+	testEvalEnv(`10 WRITE 1.5; -2, .5, 1 / 3, 1 / 3#, 1E+20, 25D-31, "Hi"`, "A", env)
+	testEvalEnv(`10 WRITE`, "A", env)
+	testEvalEnv(`10 WRITE MKI$(16961), 32767`, "A", env)
+	// Output:
+	// "A",1,"B"
+	// 1.5,-2,.5,.3333333,.3333333333333333,1E+20,2.5D-30,"Hi"
+	//
+	// "AB",32767
+}
+
+func Test_PrintStatement(t *testing.T) {
 	tests := []struct {
 		inp string
-		exp object.Object
 	}{
-		{inp: `10 OPEN "test.dat" FOR OUTPUT AS #1`},
-		// test his trash detection
-		{inp: `60 open "test3.out" FOR OUTPUT ACCESS WRITE LOCK READ AS #3 LEN = 128`,
-			exp: &object.Error{Message: "Syntax error in 60", Code: 2}},
+		{inp: `10 PRINT TAB(30);"Hello World!"`},
+		{inp: `10 PRINT "x";USING "##.#";Z;:PRINT " ";A(5);  'comment`},
 	}
 
 	for _, tt := range tests {
@@ -1563,21 +2404,21 @@ func Test_OpenStatement(t *testing.T) {
 		rc := Eval(&ast.Program{}, code, env)
 		env.SetRun(false)
 
-		if tt.exp == nil {
-			assert.Nil(t, rc, "got %T back", rc)
-		} else {
-			assert.Equal(t, rc, tt.exp)
-		}
-
+		assert.Nil(t, nil, "got %T back", rc)
 	}
 }
 
-func Test_PrintStatement(t *testing.T) {
+func Test_PrintNumbers(t *testing.T) {
 	tests := []struct {
 		inp string
+		exp []string
 	}{
-		{inp: `10 PRINT TAB(30);"Hello World!"`},
-		{inp: `10 PRINT "x";USING "##.#";Z;:PRINT " ";A(5);  'comment`},
+		{inp: `10 PRINT 5; -2; .5`, exp: []string{" 5 ", "-2 ", " .5 ", ""}},
+		{inp: `10 PRINT 3.1415926535; 1/3#`, exp: []string{" 3.1415926535 ", " .3333333333333333 ", ""}},
+		{inp: `10 PRINT 3.14159265; 3.141593; 0.12345678`, exp: []string{" 3.14159265 ", " 3.141593 ", " .12345678 ", ""}},
+		{inp: `10 X = 3.14159265 : Y# = 3.14159265 : PRINT X; Y#`, exp: []string{" 3.141593 ", " 3.14159265 ", ""}},
+		{inp: `10 PRINT 1E+20; -1.5E-20`, exp: []string{" 1E+20 ", "-1.5E-20 ", ""}},
+		{inp: `10 PRINT "A"; 32767; "B"`, exp: []string{"A", " 32767 ", "B", ""}},
 	}
 
 	for _, tt := range tests {
@@ -1585,19 +2426,17 @@ func Test_PrintStatement(t *testing.T) {
 		p := parser.New(l)
 		var mt mocks.MockTerm
 		initMockTerm(&mt)
+		mt.ExpMsg = &mocks.Expector{Exp: tt.exp}
 		env := object.NewTermEnvironment(mt)
 		p.ParseProgram(env)
 
-		errs := p.Errors()
-
-		assert.Zero(t, len(errs), "parser threw some errors")
+		assert.Zero(t, len(p.Errors()), "parser threw some errors")
 
-		code := env.StatementIter()
 		env.SetRun(true)
-		rc := Eval(&ast.Program{}, code, env)
+		Eval(&ast.Program{}, env.StatementIter(), env)
 		env.SetRun(false)
 
-		assert.Nil(t, nil, "got %T back", rc)
+		assert.False(t, mt.ExpMsg.Failed, "%s printed the wrong thing", tt.inp)
 	}
 }
 
@@ -1664,8 +2503,8 @@ func TestFunctionExecution(t *testing.T) {
 		res object.Object
 		vbl string
 	}{
-		{inp: `10 DEF FNMUL(x,y)= x * y : Y = FNMUL(2,5)`, res: &object.Integer{Value: 10}, vbl: "Y"},
-		{inp: `10 DEF FNSKIP(x)= x + 2 : Y = FNSKIP(1)`, res: &object.Integer{Value: 3}, vbl: "Y"},
+		{inp: `10 DEF FNMUL(x,y)= x * y : Y = FNMUL(2,5)`, res: &object.FloatSgl{Value: 10}, vbl: "Y"},
+		{inp: `10 DEF FNSKIP(x)= x + 2 : Y = FNSKIP(1)`, res: &object.FloatSgl{Value: 3}, vbl: "Y"},
 		{inp: `10 DEF FNSKIP(x)= x + 2 : Y = FNSKIP(1)`, res: &object.Function{}, vbl: "FNSKIP"},
 	}
 
@@ -1705,14 +2544,14 @@ func TestInvalidFunctionName(t *testing.T) {
 func TestFunctionApplication(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected int16
+		expected float32
 	}{
 		{"10 DEF FNID(x) = x : y = FNID(5)", 5},
 		//{"20 DEF FNMUL(x,y) = x*y : y = FNMUL(2,3)", 6},
 		//{"30 DEF FNSKIP(x)= (x + 2): y = FNSKIP(3)", 5},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input, "y"), tt.expected)
+		testSingleObject(t, testEval(tt.input, "y"), tt.expected)
 	}
 }
 
@@ -1721,22 +2560,22 @@ func TestHexOctalConstants(t *testing.T) {
 		inp string
 		exp interface{}
 	}{
-		{`10 X = &H7F`, int16(127)},
+		{`10 X = &H7F`, float32(127)},
 		{`20 &HG7F`, "Syntax error in 20"},
 		{`30 &H7FFFFF`, "Overflow in 30"},
-		{`40 X = &O7`, int16(7)},
-		{`50 X = &O77`, int16(63)},
-		{`60 x = &O77777`, int16(32767)},
+		{`40 X = &O7`, float32(7)},
+		{`50 X = &O77`, float32(63)},
+		{`60 x = &O77777`, float32(32767)},
 		{`70 &O777777`, "Overflow in 70"},
-		{`80 x = &77777`, int16(32767)},
+		{`80 x = &77777`, float32(32767)},
 		{`90 &O78777`, "Syntax error in 90"},
 	}
 
 	for _, tt := range tests {
 		evald := testEval(tt.inp, "X")
 		switch expected := tt.exp.(type) {
-		case int16:
-			testIntegerObject(t, evald, expected)
+		case float32:
+			testSingleObject(t, evald, expected)
 		case string:
 			errObj, ok := evald.(*object.Error)
 			if !ok {
@@ -1751,20 +2590,18 @@ func TestHexOctalConstants(t *testing.T) {
 }
 
 func Test_ReadStatement(t *testing.T) {
-	fixedInt, _ := decimal.NewFromString("999.99")
-
 	tests := []struct {
 		inp string
 		chk string
 		exp object.Object
 	}{
 		{inp: `10 DATA "Fred", "George" : READ A$`, chk: `A$`, exp: &object.String{Value: "Fred"}},
-		{inp: `20 DATA 123 : READ A`, chk: `A`, exp: &object.Integer{Value: 123}},
-		{inp: `30 DATA 99999 : READ A`, chk: `A`, exp: &object.IntDbl{Value: 99999}},
-		{inp: `40 DATA 999.99 : READ A`, chk: `A`, exp: &object.Fixed{Value: fixedInt}},
+		{inp: `20 DATA 123 : READ A`, chk: `A`, exp: &object.FloatSgl{Value: 123}},
+		{inp: `30 DATA 99999 : READ A`, chk: `A`, exp: &object.FloatSgl{Value: 99999}},
+		{inp: `40 DATA 999.99 : READ A`, chk: `A`, exp: &object.FloatSgl{Value: 999.99}},
 		{inp: `50 DATA 2.35123412341234E+4 : READ A`, chk: `A`, exp: &object.FloatSgl{Value: 23512.341796875}},
-		{inp: `60 DATA 2.35123412341234D+4 : READ A`, chk: `A`, exp: &object.FloatDbl{Value: 23512.3412341234}},
-		{inp: `70 DATA -2.35123412341234D+4 : READ A`, chk: `A`, exp: &object.FloatDbl{Value: -23512.3412341234}},
+		{inp: `60 DATA 2.35123412341234D+4 : READ A#`, chk: `A#`, exp: &object.FloatDbl{Value: 23512.3412341234}},
+		{inp: `70 DATA -2.35123412341234D+4 : READ A#`, chk: `A#`, exp: &object.FloatDbl{Value: -23512.3412341234}},
 		{inp: `80 DATA "Fred" : READ A$ : READ B$`, chk: `A$`, exp: &object.Error{Message: "Out of DATA in 80"}},
 		{inp: `90 DATA 3,4,5 : READ 3+5`, chk: ``, exp: &object.Error{Message: "Syntax error in 90"}},
 		{inp: `100 DATA 3,4,5 : READ`, exp: &object.Error{Message: "Syntax error in 100"}},
@@ -1781,6 +2618,46 @@ func Test_ReadStatement(t *testing.T) {
 	}
 }
 
+func Test_RandomizeStatement(t *testing.T) {
+	tests := []struct {
+		inp  string
+		keys string
+		exp  float32
+		err  int
+	}{
+		{inp: `10 RANDOMIZE 42 : A = RND`, exp: 0.35119325},
+		{inp: `10 S% = -1 : RANDOMIZE S% : A = RND`, exp: 0.93128663},
+		{inp: `10 RANDOMIZE : A = RND`, keys: "42\r", exp: 0.35119325},
+		{inp: `10 RANDOMIZE : A = RND`, keys: "fred\r40000\r42\r", exp: 0.35119325},
+		{inp: `10 RANDOMIZE "A"`, err: berrors.TypeMismatch},
+		{inp: `10 RANDOMIZE -40000`, err: berrors.Overflow},
+		{inp: `10 RANDOMIZE 32768`, err: berrors.Overflow},
+		{inp: `10 RANDOMIZE 1 / 0`, err: berrors.DivByZero},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		*mt.StrVal = tt.keys
+		env := object.NewTermEnvironment(mt)
+
+		res := testEvalEnv(tt.inp, "A", env)
+
+		if tt.err != 0 {
+			assert.IsTypef(t, &object.Error{}, res, "%s didn't fail", tt.inp)
+			if err, ok := res.(*object.Error); ok {
+				assert.Equalf(t, tt.err, err.Code, "%s gave the wrong error", tt.inp)
+			}
+			continue
+		}
+
+		if tv, ok := res.(*object.TypedVar); ok {
+			res = tv.Value
+		}
+		assert.Equalf(t, &object.FloatSgl{Value: tt.exp}, res, "%s gave the wrong value", tt.inp)
+	}
+}
+
 func Test_RestoreStatement(t *testing.T) {
 
 	tests := []struct {
@@ -2084,27 +2961,34 @@ func ExamplePrint() {
 		{`40 PRINT "Test of tab","due to comma"`},
 		{`50 PRINT "Test of a run on";`},
 		{`60 PRINT " sentence"`},
-		{`70 LET X = 45.12 : PRINT X`},
-		{`80 LET Y = 45.12 + 12 : PRINT Y`},
-		{`90 LET Y = 2 * 45.12 : PRINT Y`},
-		{`90 LET Y = 45.12 / 2 : PRINT Y`},
-		{`100 LET Y = 45.12 < 53.6 : PRINT Y`},
-		{`110 LET Y = 45.12 - 12.6 : PRINT Y`},
-		{`120 LET Y = 45.12 < 23.6 : PRINT Y`},
-		{`130 LET Y = 45.12 <= 53.6 : PRINT Y`},
-		{`140 LET Y = 45.12 <= 23.6 : PRINT Y`},
-		{`150 LET Y = 45.12 > 53.6 : PRINT Y`},
-		{`160 LET Y = 45.12 > 23.6 : PRINT Y`},
-		{`170 LET Y = 45.12 >= 53.6 : PRINT Y`},
-		{`180 LET Y = 45.12 >= 23.6 : PRINT Y`},
-		{`190 LET Y = 45.12 <> 53.6 : PRINT Y`},
-		{`200 LET Y = 45.12 <> 45.12 : PRINT Y`},
-		{`210 LET Y = 45.12 * 3.4 : PRINT Y`},
-		{`220 LET Y = 45.12 / 3.4 : PRINT Y`},
-		{`230 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y`},
-		{`240 X = 5 : Y = 3.2 : PRINT X * Y`},
-		{`250 PRINT LEN("Hello")`},
-		{`260 PRINT 10`},
+		{`61 PRINT "A","B","C","D","E","F"`},
+		{`62 PRINT "X";TAB(5);"Y";TAB(3);"Z"`},
+		{`63 PRINT "X";SPC(3);"Y";SPC(83);"Z"`},
+		{`64 PRINT STRING$(75, "-");-12345;"!"`},
+		{`65 PRINT STRING$(78, "=");"ABCD"`},
+		{`66 PRINT "ABC";POS(0);"!"`},
+		{`67 PRINT "A", : PRINT "B"`},
+		{`70 LET X = 45.12 : PRINT X;`},
+		{`80 LET Y = 45.12 + 12 : PRINT Y;`},
+		{`90 LET Y = 2 * 45.12 : PRINT Y;`},
+		{`90 LET Y = 45.12 / 2 : PRINT Y;`},
+		{`100 LET Y = 45.12 < 53.6 : PRINT Y;`},
+		{`110 LET Y = 45.12 - 12.6 : PRINT Y;`},
+		{`120 LET Y = 45.12 < 23.6 : PRINT Y;`},
+		{`130 LET Y = 45.12 <= 53.6 : PRINT Y;`},
+		{`140 LET Y = 45.12 <= 23.6 : PRINT Y;`},
+		{`150 LET Y = 45.12 > 53.6 : PRINT Y;`},
+		{`160 LET Y = 45.12 > 23.6 : PRINT Y;`},
+		{`170 LET Y = 45.12 >= 53.6 : PRINT Y;`},
+		{`180 LET Y = 45.12 >= 23.6 : PRINT Y;`},
+		{`190 LET Y = 45.12 <> 53.6 : PRINT Y;`},
+		{`200 LET Y = 45.12 <> 45.12 : PRINT Y;`},
+		{`210 LET Y = 45.12 * 3.4 : PRINT Y;`},
+		{`220 LET Y = 45.12 / 3.4 : PRINT Y;`},
+		{`230 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y;`},
+		{`240 X = 5 : Y = 3.2 : PRINT X * Y;`},
+		{`250 PRINT LEN("Hello");`},
+		{`260 PRINT 10;`},
 	}
 
 	for _, tt := range tests {
@@ -2116,189 +3000,139 @@ func ExamplePrint() {
 	// Hello World!
 	// This is a test
 	// Another test program.
-	// Test of tab	due to comma
+	// Test of tab   due to comma
 	// Test of a run on sentence
-	// 45.12
-	// 57.12
-	// 90.24
-	// 22.56
-	// 1
-	// 32.52
-	// 0
-	// 1
-	// 0
-	// 0
-	// 1
-	// 0
-	// 1
-	// 1
-	// 0
-	// 153.408
-	// 13.27059
-	// 2.361234E+04
-	// 16
-	// 5
-	// 10
+	// A             B             C             D             E
+	// F
+	// X   Y
+	//   Z
+	// X   Y   Z
+	// ---------------------------------------------------------------------------
+	// -12345 !
+	// ==============================================================================AB
+	// CD
+	// ABC 4 !
+	// A             B
+	//  45.12  57.12  90.24  22.56 -1  32.52  0 -1  0  0 -1  0 -1 -1  0  153.408  13.27059  23612.34  16  5  10
 }
 
 func ExampleT_int() {
 	tests := []struct {
 		input string
 	}{
-		{`10 LET X = 32760 + 300 : PRINT X`},
-		{`20 LET Y = 32767 / 3 : PRINT Y`},
-		{`30 LET Y = 11 MOD 3 : PRINT Y`},
-		{`40 LET Y = 10 <> 10 : PRINT Y`},
-		{`50 LET Y = 10 <> 3 : PRINT Y`},
-		{`60 LET Y = 10 = 10 : PRINT Y`},
-		{`70 LET Y = 10 = 3 : PRINT Y`},
-		{`80 LET Y = 10 / 0 : PRINT Y`},
+		{`10 LET X = 32760 + 300 : PRINT X;`},
+		{`20 LET Y = 32767 / 3 : PRINT Y;`},
+		{`30 LET Y = 11 MOD 3 : PRINT Y;`},
+		{`40 LET Y = 10 <> 10 : PRINT Y;`},
+		{`50 LET Y = 10 <> 3 : PRINT Y;`},
+		{`60 LET Y = 10 = 10 : PRINT Y;`},
+		{`70 LET Y = 10 = 3 : PRINT Y;`},
+		{`80 LET Y = 10 / 0 : PRINT Y;`},
+		{`90 PRINT 123456789 + 1;`},
+		{`100 PRINT 9999999 + 1;`},
+		{`110 LET Y# = 12345678 * 10 : PRINT Y#;`},
 	}
 
 	for _, tt := range tests {
 		testEval(tt.input, "")
 	}
 	// Output:
-	// 33060
-	// 1.092233E+04
-	// 2
-	// 0
-	// 1
-	// 1
-	// 0
+	// 33060  10922.33  2  0 -1 -1  0  123456790  1E+07  123456780
 }
 
 func ExampleT_fixed() {
 	tests := []struct {
 		input string
 	}{
-		{`10 LET X = 45.12 : PRINT X`},
-		{`20 LET Y = 45.12 + 12 : PRINT Y`},
-		{`30 LET Y = 2 * 45.12 : PRINT Y`},
-		{`40 LET Y = 45.12 / 2 : PRINT Y`},
-		{`50 LET Y = 45.12 < 53.6 : PRINT Y`},
-		{`60 LET Y = 45.12 - 12.6 : PRINT Y`},
-		{`70 LET Y = 45.12 < 23.6 : PRINT Y`},
-		{`80 LET Y = 45.12 <= 53.6 : PRINT Y`},
-		{`90 LET Y = 45.12 <= 23.6 : PRINT Y`},
-		{`100 LET Y = 45.12 > 53.6 : PRINT Y`},
-		{`110 LET Y = 45.12 > 23.6 : PRINT Y`},
-		{`120 LET Y = 45.12 >= 53.6 : PRINT Y`},
-		{`130 LET Y = 45.12 >= 23.6 : PRINT Y`},
-		{`140 LET Y = 45.12 <> 53.6 : PRINT Y`},
-		{`150 LET Y = 45.12 <> 45.12 : PRINT Y`},
-		{`160 LET Y = 45.12 * 3.4 : PRINT Y`},
-		{`170 LET Y = 45.12 / 3.4 : PRINT Y`},
-		{`180 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y`},
-		{`190 LET Y = 235.988E+2 = 235.988E+2 : PRINT Y`},
-		{`200 LET Y = 235.988E+2 = 1.354E+1 : PRINT Y`},
-		{`210 LET Y = 45.12 = 45.12 : PRINT Y`},
-		{`220 LET Y = 45.12 = 12 : PRINT Y`},
-		{`230 LET Y = 45 >= 12 : PRINT Y`},
-		{`240 LET Y = 45 <= 12 : PRINT Y`},
-		{`250 LET Y = 10.25 / 0 : PRINT Y`},
+		{`10 LET X = 45.12 : PRINT X;`},
+		{`20 LET Y = 45.12 + 12 : PRINT Y;`},
+		{`30 LET Y = 2 * 45.12 : PRINT Y;`},
+		{`40 LET Y = 45.12 / 2 : PRINT Y;`},
+		{`50 LET Y = 45.12 < 53.6 : PRINT Y;`},
+		{`60 LET Y = 45.12 - 12.6 : PRINT Y;`},
+		{`70 LET Y = 45.12 < 23.6 : PRINT Y;`},
+		{`80 LET Y = 45.12 <= 53.6 : PRINT Y;`},
+		{`90 LET Y = 45.12 <= 23.6 : PRINT Y;`},
+		{`100 LET Y = 45.12 > 53.6 : PRINT Y;`},
+		{`110 LET Y = 45.12 > 23.6 : PRINT Y;`},
+		{`120 LET Y = 45.12 >= 53.6 : PRINT Y;`},
+		{`130 LET Y = 45.12 >= 23.6 : PRINT Y;`},
+		{`140 LET Y = 45.12 <> 53.6 : PRINT Y;`},
+		{`150 LET Y = 45.12 <> 45.12 : PRINT Y;`},
+		{`160 LET Y = 45.12 * 3.4 : PRINT Y;`},
+		{`170 LET Y = 45.12 / 3.4 : PRINT Y;`},
+		{`180 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y;`},
+		{`190 LET Y = 235.988E+2 = 235.988E+2 : PRINT Y;`},
+		{`200 LET Y = 235.988E+2 = 1.354E+1 : PRINT Y;`},
+		{`210 LET Y = 45.12 = 45.12 : PRINT Y;`},
+		{`220 LET Y = 45.12 = 12 : PRINT Y;`},
+		{`230 LET Y = 45 >= 12 : PRINT Y;`},
+		{`240 LET Y = 45 <= 12 : PRINT Y;`},
+		{`250 LET Y = 10.25 / 0 : PRINT Y;`},
 	}
 
 	for _, tt := range tests {
 		testEval(tt.input, "")
 	}
 	// Output:
-	// 45.12
-	// 57.12
-	// 90.24
-	// 22.56
-	// 1
-	// 32.52
-	// 0
-	// 1
-	// 0
-	// 0
-	// 1
-	// 0
-	// 1
-	// 1
-	// 0
-	// 153.408
-	// 13.27059
-	// 2.361234E+04
-	// 1
-	// 0
-	// 1
-	// 0
-	// 1
-	// 0
+	// 45.12  57.12  90.24  22.56 -1  32.52  0 -1  0  0 -1  0 -1 -1  0  153.408  13.27059  23612.34 -1  0 -1  0 -1  0
 }
 
 func ExampleT_float() {
 	tests := []struct {
 		input string
 	}{
-		{`10 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y`},
-		{`20 LET Y = 2.35E+4 + 3.14: PRINT Y`},
-		{`30 LET Y = 2.35E+4 + 3: PRINT Y`},
-		{`40 LET Y = 2.35E+4 - 3: PRINT Y`},
-		{`50 LET Y = 3 * 2.35E+4: PRINT Y`},
-		{`60 LET Y = 45123.62 / 2.35E+4: PRINT Y`},
-		{`70 LET Y = 2.35E+4 < 53.6 : PRINT Y`},
-		{`80 LET Y = 2.35E+4 < 23.6 : PRINT Y`},
-		{`90 LET Y = 2.35E+4 <= 53.6 : PRINT Y`},
-		{`100 LET Y = 2.35E+4 <= 23.6 : PRINT Y`},
-		{`110 LET Y = 2.35E+4 > 53.6 : PRINT Y`},
-		{`120 LET Y = 2.35E+4 > 23.6 : PRINT Y`},
-		{`130 LET Y = 2.35E+4 >= 53.6 : PRINT Y`},
-		{`140 LET Y = 2.35E+4 >= 23.6 : PRINT Y`},
-		{`150 LET Y = 2.35E+4 <> 53.6 : PRINT Y`},
-		{`160 LET Y = 2.35E+4 <> 45.12 : PRINT Y`},
-		{`170 LET Y = 2.35E+4 / 0 : PRINT Y`},
+		{`10 LET Y = 235.988E+2 + 1.354E+1 : PRINT Y;`},
+		{`20 LET Y = 2.35E+4 + 3.14: PRINT Y;`},
+		{`30 LET Y = 2.35E+4 + 3: PRINT Y;`},
+		{`40 LET Y = 2.35E+4 - 3: PRINT Y;`},
+		{`50 LET Y = 3 * 2.35E+4: PRINT Y;`},
+		{`60 LET Y = 45123.62 / 2.35E+4: PRINT Y;`},
+		{`70 LET Y = 2.35E+4 < 53.6 : PRINT Y;`},
+		{`80 LET Y = 2.35E+4 < 23.6 : PRINT Y;`},
+		{`90 LET Y = 2.35E+4 <= 53.6 : PRINT Y;`},
+		{`100 LET Y = 2.35E+4 <= 23.6 : PRINT Y;`},
+		{`110 LET Y = 2.35E+4 > 53.6 : PRINT Y;`},
+		{`120 LET Y = 2.35E+4 > 23.6 : PRINT Y;`},
+		{`130 LET Y = 2.35E+4 >= 53.6 : PRINT Y;`},
+		{`140 LET Y = 2.35E+4 >= 23.6 : PRINT Y;`},
+		{`150 LET Y = 2.35E+4 <> 53.6 : PRINT Y;`},
+		{`160 LET Y = 2.35E+4 <> 45.12 : PRINT Y;`},
+		{`170 LET Y = 2.35E+4 / 0 : PRINT Y;`},
 	}
 
 	for _, tt := range tests {
 		testEval(tt.input, "")
 	}
 	// Output:
-	// 2.361234E+04
-	// 2.350314E+04
-	// 23503
-	// 23497
-	// 70500
-	// 1.920154E+00
-	// 0
-	// 0
-	// 0
-	// 0
-	// 1
-	// 1
-	// 1
-	// 1
-	// 1
-	// 1
+	// 23612.34  23503.14  23503  23497  70500  1.920154  0  0  0  0 -1 -1 -1 -1 -1 -1
 }
 
 func ExampleT_floatDbl() {
 	tests := []struct {
 		input string
 	}{
-		{`10 LET Y = 235.988D+12 + 1.354D+4 : PRINT Y`},
-		{`20 LET Y = -2.35D+4 + 314: PRINT Y`},
-		{`30 LET Y = 2.35D+4 + 3.14159: PRINT Y`},
-		{`40 LET Y = 2.35D+4 - 3.1415E+3: PRINT Y`},
-		{`50 LET Y = 3 * 2.35D+4: PRINT Y`},
-		{`60 LET Y = 123.45 / 2.35D+4: PRINT Y`},
-		{`70 LET Y = 2.35E+4 < 4.56D+4 : PRINT Y`},
-		{`80 LET Y = 2.35D+4 < 23.6 : PRINT Y`},
-		{`90 LET Y = 2.35D+4 <= 53.6 : PRINT Y`},
-		{`100 LET Y = 2.35D+4 <= 23.6 : PRINT Y`},
-		{`110 LET Y = 2.35D+4 > 53.6 : PRINT Y`},
-		{`120 LET Y = 2.35D+4 > 23.6 : PRINT Y`},
-		{`130 LET Y = 2.35D+4 >= 53.6 : PRINT Y`},
-		{`140 LET Y = 2.35D+4 >= 23.6 : PRINT Y`},
-		{`150 LET Y = 2.35D+4 <> 53.6 : PRINT Y`},
-		{`160 LET Y = 2.35D+4 <> 45.12 : PRINT Y`},
-		{`170 LET Y = 2.35D+4 = 2.35D+4 : PRINT Y`},
-		{`180 LET Y = 2.35D+4 = 2.35 : PRINT Y`},
-		{`190 LET X = -2.35123412341234D+4 : PRINT X`},
-		{`200 LET X = -2.35123412341234E+4 : PRINT X`},
-		{`210 LET X = -2.351 : PRINT X`},
+		{`10 LET Y# = 235.988D+12 + 1.354D+4 : PRINT Y#;`},
+		{`20 LET Y# = -2.35D+4 + 314: PRINT Y#;`},
+		{`30 LET Y# = 2.35D+4 + 3.14159: PRINT Y#;`},
+		{`40 LET Y# = 2.35D+4 - 3.1415E+3: PRINT Y#;`},
+		{`50 LET Y# = 3 * 2.35D+4: PRINT Y#;`},
+		{`60 LET Y# = 123.45 / 2.35D+4: PRINT Y#;`},
+		{`70 LET Y = 2.35E+4 < 4.56D+4 : PRINT Y;`},
+		{`80 LET Y = 2.35D+4 < 23.6 : PRINT Y;`},
+		{`90 LET Y = 2.35D+4 <= 53.6 : PRINT Y;`},
+		{`100 LET Y = 2.35D+4 <= 23.6 : PRINT Y;`},
+		{`110 LET Y = 2.35D+4 > 53.6 : PRINT Y;`},
+		{`120 LET Y = 2.35D+4 > 23.6 : PRINT Y;`},
+		{`130 LET Y = 2.35D+4 >= 53.6 : PRINT Y;`},
+		{`140 LET Y = 2.35D+4 >= 23.6 : PRINT Y;`},
+		{`150 LET Y = 2.35D+4 <> 53.6 : PRINT Y;`},
+		{`160 LET Y = 2.35D+4 <> 45.12 : PRINT Y;`},
+		{`170 LET Y = 2.35D+4 = 2.35D+4 : PRINT Y;`},
+		{`180 LET Y = 2.35D+4 = 2.35 : PRINT Y;`},
+		{`190 LET X# = -2.35123412341234D+4 : PRINT X#;`},
+		{`200 LET X = -2.35123412341234E+4 : PRINT X;`},
+		{`210 LET X = -2.351 : PRINT X;`},
 		{`220 LET X = 2.35D+4 / 0 : PRINT`},
 	}
 
@@ -2307,52 +3141,32 @@ func ExampleT_floatDbl() {
 	}
 
 	// Output:
-	// 2.359880E+14
-	// -23186
-	// 2.350314E+04
-	// 2.035850E+04
-	// 70500
-	// 5.253191E-03
-	// 1
-	// 0
-	// 0
-	// 0
-	// 1
-	// 1
-	// 1
-	// 1
-	// 1
-	// 1
-	// 1
-	// 0
-	// -2.351234E+04
-	// -2.351234E+04
-	// -2.351
+	// 235988000013540 -23186  23503.14159  20358.5  70500  5.253191489361702D-03 -1  0  0  0 -1 -1 -1 -1 -1 -1 -1  0 -23512.3412341234 -23512.34 -2.351
 }
 
 func ExampleT_array() {
 	tests := []struct {
 		input string
 	}{
-		{`10 LET Y[0] = 5 : PRINT Y(0)`},
-		{`15 LET Y[0] = 4 : PRINT Y[5]`},
-		{`20 LET Y(0) = 5 : LET Y[1] = 1: PRINT Y[0]`},
-		{`30 LET Y[0] = 5 : LET Y[1] = 1: PRINT Y[1]`},
+		{`10 LET Y[0] = 5 : PRINT Y(0);`},
+		{`15 LET Y[0] = 4 : PRINT Y[5];`},
+		{`20 LET Y(0) = 5 : LET Y[1] = 1: PRINT Y[0];`},
+		{`30 LET Y[0] = 5 : LET Y[1] = 1: PRINT Y[1];`},
 		{`40 LET Y$[0] = "Hello" : PRINT Y$[0]`},
 		{`50 LET Y$[0] = "Hello" : Y$[0] = "Goodbye" : PRINT Y$[0]`},
-		{`60 LET Y$[0] = "Hello" : PRINT Y$[5]`},
-		{`70 LET Y$ = "HELLO" : PRINT Y$[0]`},
-		{`80 LET Y# = 5 : PRINT Y#`},
-		{`90 LET Y#[0] = 5 : PRINT Y#[0]`},
-		{`100 LET Y#[0] = 5 : PRINT Y#[1]`},
-		{`110 LET Y%[0] = 5 : LET Y%[1] = 3 : PRINT Y%[0]`},
-		{`120 LET Y![0] = 5 : LET Y![1] = 3 : PRINT Y![0]`},
-		{`130 DIM A[20] : LET A[11] = 6 : PRINT A[11]`},
-		{`140 DIM M[10,10] : LET M[4,5] = 13 : PRINT M[4,5] : PRINT M[5,4]`},
-		{`150 DIM A[9,10], B[5,6] : LET B[4,5] = 12 : PRINT B[4,5]`},
-		{`160 DIM Y[12.5] : LET Y[1.5] = 5 : PRINT Y[1.5]`},
-		{`170 LET Y[4] = 31 : PRINT Y[3.6E+00]`},
-		{`170 LET Y[4] = 31 : PRINT Y[3.6D+00]`},
+		{`60 LET Y$[0] = "Hello" : PRINT "["; Y$[5]; "]"`},
+		{`70 LET Y$ = "HELLO" : PRINT "["; Y$[0]; "]"`},
+		{`80 LET Y# = 5 : PRINT Y#;`},
+		{`90 LET Y#[0] = 5 : PRINT Y#[0];`},
+		{`100 LET Y#[0] = 5 : PRINT Y#[1];`},
+		{`110 LET Y%[0] = 5 : LET Y%[1] = 3 : PRINT Y%[0];`},
+		{`120 LET Y![0] = 5 : LET Y![1] = 3 : PRINT Y![0];`},
+		{`130 DIM A[20] : LET A[11] = 6 : PRINT A[11];`},
+		{`140 DIM M[10,10] : LET M[4,5] = 13 : PRINT M[4,5]; : PRINT M[5,4];`},
+		{`150 DIM A[9,10], B[5,6] : LET B[4,5] = 12 : PRINT B[4,5];`},
+		{`160 DIM Y[12.5] : LET Y[1.5] = 5 : PRINT Y[1.5];`},
+		{`170 LET Y[4] = 31 : PRINT Y[3.6E+00];`},
+		{`170 LET Y[4] = 31 : PRINT Y[3.6D+00];`},
 	}
 
 	for _, tt := range tests {
@@ -2360,14 +3174,11 @@ func ExampleT_array() {
 	}
 
 	// Output:
-	// 5
-	// 6
-	// 13
-	// 0
-	// 12
-	// 5
-	// 31
-	// 31
+	// 5  0  5  1 Hello
+	// Goodbye
+	// []
+	// []
+	//  5  5  0  5  5  6  13  0  12  5  31  31
 }
 
 func ExampleT_strings() {
@@ -2649,6 +3460,15 @@ func Test_UsingStatement(t *testing.T) {
 		{inp: `PRINT USING "##.##"; X`, err: nil, exp: []string{" 0.00"}},
 		{inp: `PRINT USING "##.##"; X#`, err: nil, exp: []string{" 0.00"}},
 		{inp: `X=2.134E1 : PRINT USING "##.##"; X`, err: nil, exp: []string{"21.34"}},
+		{inp: `PRINT USING "##.## "; 1, 2.5, -3`, err: nil, exp: []string{" 1.00", "  2.50", " -3.00", " "}},
+		{inp: `PRINT USING "Total: $$###.## each"; 12.5`, err: nil, exp: []string{"Total:   $12.50", " each"}},
+		{inp: `A$ = "World" : PRINT USING "Hello &."; A$`, err: nil, exp: []string{"Hello World", "."}},
+		{inp: `PRINT USING "!\  \"; "ABC", "DEFGHI"`, err: nil, exp: []string{"A", "DEFG"}},
+		{inp: `PRINT USING "##"; "ABC"`, err: &object.Error{Code: berrors.TypeMismatch, Message: "Type mismatch"}},
+		{inp: `PRINT USING "&"; 5`, err: &object.Error{Code: berrors.TypeMismatch, Message: "Type mismatch"}},
+		{inp: `PRINT USING "no fields"; 5`, err: &object.Error{Code: berrors.IllegalFuncCallErr, Message: "Illegal function call"}},
+		{inp: `PRINT USING ""; 5`, err: &object.Error{Code: berrors.IllegalFuncCallErr, Message: "Illegal function call"}},
+		{inp: `PRINT USING 5; 5`, err: &object.Error{Code: berrors.TypeMismatch, Message: "Type mismatch"}},
 	}
 
 	for _, tt := range tests {
@@ -2679,6 +3499,69 @@ func Test_UsingStatement(t *testing.T) {
 	}
 }
 
+func Test_UsingFormat(t *testing.T) {
+	tests := []struct {
+		form  string
+		items []object.Object
+		exp   string
+	}{
+		{form: "###.##", items: []object.Object{&object.FloatSgl{Value: 23.456}}, exp: " 23.46"},
+		{form: "###.##", items: []object.Object{&object.FloatDbl{Value: -0.125}}, exp: " -0.13"},
+		{form: "##.##", items: []object.Object{&object.FloatSgl{Value: 2.675}}, exp: " 2.68"},
+		{form: "###", items: []object.Object{&object.Integer{Value: 0}}, exp: "  0"},
+		{form: "###.", items: []object.Object{&object.FloatSgl{Value: 1.5}}, exp: "  2."},
+		{form: ".##", items: []object.Object{&object.FloatSgl{Value: 0.5}}, exp: ".50"},
+		{form: "#.##", items: []object.Object{&object.FloatSgl{Value: -0.5}}, exp: "-.50"},
+		{form: "##.##", items: []object.Object{&object.FloatSgl{Value: 123.456}}, exp: "%123.46"},
+		{form: "##.##", items: []object.Object{&object.FloatSgl{Value: 99.996}}, exp: "%100.00"},
+		{form: "+##.##", items: []object.Object{&object.Integer{Value: 5}}, exp: " +5.00"},
+		{form: "+##.##", items: []object.Object{&object.Integer{Value: -5}}, exp: " -5.00"},
+		{form: "##.##+", items: []object.Object{&object.Integer{Value: 5}}, exp: " 5.00+"},
+		{form: "##.##-", items: []object.Object{&object.Integer{Value: -5}}, exp: " 5.00-"},
+		{form: "##.##-", items: []object.Object{&object.Integer{Value: 5}}, exp: " 5.00 "},
+		{form: "**#.##", items: []object.Object{&object.FloatSgl{Value: 2.5}}, exp: "**2.50"},
+		{form: "**$##.##", items: []object.Object{&object.FloatSgl{Value: 2.5}}, exp: "***$2.50"},
+		{form: "$$###.##", items: []object.Object{&object.FloatSgl{Value: -12.5}}, exp: " -$12.50"},
+		{form: "#,###.##", items: []object.Object{&object.FloatDbl{Value: 1234.5}}, exp: "1,234.50"},
+		{form: "##########,", items: []object.Object{&object.IntDbl{Value: 1234567}}, exp: "   1234567,"},
+		{form: "###,######", items: []object.Object{&object.IntDbl{Value: 1234567}}, exp: " 1,234,567"},
+		{form: "##.##^^^^", items: []object.Object{&object.FloatSgl{Value: 234.56}}, exp: " 2.35E+02"},
+		{form: "##.##^^^^", items: []object.Object{&object.FloatSgl{Value: -0.00234}}, exp: "-2.34E-03"},
+		{form: ".####^^^^-", items: []object.Object{&object.IntDbl{Value: -88888}}, exp: ".8889E+05-"},
+		{form: "+.##^^^^", items: []object.Object{&object.Integer{Value: 123}}, exp: "+.12E+03"},
+		{form: "##.#^^^^", items: []object.Object{&object.FloatDbl{Value: 9.99}}, exp: " 1.0E+01"},
+		{form: "###.##^^^^", items: []object.Object{&object.Integer{Value: 0}}, exp: "  0.00E+00"},
+		{form: "!", items: []object.Object{&object.String{Value: "Yes"}}, exp: "Y"},
+		{form: `\\`, items: []object.Object{&object.String{Value: "Yes"}}, exp: "Ye"},
+		{form: `\   \`, items: []object.Object{&object.String{Value: "Yes"}}, exp: "Yes  "},
+		{form: "&", items: []object.Object{&object.String{Value: "Yes"}}, exp: "Yes"},
+		{form: `\ #`, items: []object.Object{&object.Integer{Value: 5}}, exp: `\ 5`},
+		{form: "_##.#", items: []object.Object{&object.Integer{Value: 7}}, exp: "#7.0"},
+		{form: "_!&_!", items: []object.Object{&object.String{Value: "Hi"}}, exp: "!Hi!"},
+		{form: "[##]", items: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 22}}, exp: "[ 1][22]"},
+		{form: "# and #", items: []object.Object{&object.Integer{Value: 1}}, exp: "1 and "},
+		{form: "$###.##", items: []object.Object{&object.TypedVar{Value: &object.Integer{Value: 4}, TypeID: "%"}}, exp: "$  4.00"},
+		{form: "##.##", items: []object.Object{&object.Fixed{Value: decimal.New(125, -2)}}, exp: " 1.25"},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		uf := newUsingFormat(tt.form)
+		res := ""
+		for _, item := range tt.items {
+			out, err := uf.format(item, env)
+			assert.Nilf(t, err, "%s failed to format %s", tt.form, item.Inspect())
+			res += out
+		}
+		res += uf.finish()
+
+		assert.Equalf(t, tt.exp, res, "%s formatted incorrectly", tt.form)
+	}
+}
+
 func Test_ViewPrintStatement(t *testing.T) {
 	tests := []struct {
 		inp string
@@ -2720,3 +3603,392 @@ func Test_ViewPrintStatement(t *testing.T) {
 	}
 
 }
+
+// SAVE and LIST inside a running program leave it running
+func Test_ListInProgram(t *testing.T) {
+	tests := []struct {
+		inp  string
+		vars map[string]object.Object
+	}{
+		{inp: "SAVE \"after1.bas\" : A$ = \"same\"\n20 B$ = \"after\"",
+			vars: map[string]object.Object{"A$": &object.String{Value: "same"}, "B$": &object.String{Value: "after"}}},
+		{inp: "SAVE \"after2.bas\"\n20 PRINT \"after\" : B$ = \"after\"",
+			vars: map[string]object.Object{"B$": &object.String{Value: "after"}}},
+		{inp: "A$ = \"before\"\n20 LIST : B$ = \"same\"\n30 C$ = \"after\"",
+			vars: map[string]object.Object{"A$": &object.String{Value: "before"}, "B$": &object.String{Value: "same"}, "C$": &object.String{Value: "after"}}},
+	}
+
+	for _, tt := range tests {
+		env := testClientEnv(&mocks.MockClient{})
+		testRunEnv(t, tt.inp, tt.vars, 0, env)
+	}
+}
+
+func Test_SaveCommand(t *testing.T) {
+	prog := `10 REM saved program
+20 A% = 255 : B = 1.5 : C$ = "Hi!" : D = &H1F
+30 IF A% > 10 THEN 50 ELSE PRINT C$ ' comment
+40 DATA 1, "two", 3
+50 END
+`
+	tests := []struct {
+		cmd    string
+		file   string // name the program is stored under
+		status int    // http status the server returns
+		err    int16
+	}{
+		{cmd: `SAVE "save1.bas"`, file: "save1.bas"},
+		{cmd: `SAVE "save2.bas",A`, file: "save2.bas"},
+		{cmd: `SAVE "save3.bas",P`, file: "save3.bas"},
+		{cmd: `SAVE "save4"`, file: "save4.bas"},
+		{cmd: `SAVE "save5.bas"`, status: 403, err: berrors.PermissionDenied},
+		{cmd: `SAVE "\nodir\save6.bas"`, status: 404, err: berrors.PathNotFound},
+		{cmd: `SAVE 5`, err: berrors.TypeMismatch},
+		{cmd: `SAVE ""`, err: berrors.BadFileName},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		mc := &mocks.MockClient{StatusCode: tt.status}
+		env.SetClient(mc)
+
+		parser.New(lexer.New(prog)).ParseProgram(env)
+		p := parser.New(lexer.New(tt.cmd))
+		p.ParseCmd(env)
+		assert.Zerof(t, len(p.Errors()), "%s failed to parse", tt.cmd)
+
+		rc := Eval(&ast.Program{}, env.CmdLineIter(), env)
+
+		if tt.err != 0 {
+			err, ok := rc.(*object.Error)
+			if assert.Truef(t, ok, "%s didn't fail", tt.cmd) {
+				assert.Equalf(t, int(tt.err), err.Code, "%s gave wrong error", tt.cmd)
+			}
+			continue
+		}
+		assert.Nilf(t, rc, "%s returned %T", tt.cmd, rc)
+		assert.Equalf(t, "PUT", mc.Method, "%s didn't write to the server", tt.cmd)
+
+		// the program must come back from what the server was sent
+		assert.Truef(t, object.CreateFileStore().RemoveFile(evalFileKey(tt.file, env)), "%s wasn't kept locally", tt.cmd)
+		env2 := object.NewTermEnvironment(mt)
+		env2.SetClient(&mocks.MockClient{Contents: string(mc.Sent)})
+		p = parser.New(lexer.New(strings.Replace(strings.Split(tt.cmd, ",")[0], "SAVE", "LOAD", 1)))
+		p.ParseCmd(env2)
+		rc = Eval(&ast.Program{}, env2.CmdLineIter(), env2)
+		assert.Nilf(t, rc, "loading %s returned %T", tt.cmd, rc)
+
+		assert.Equalf(t, evalSaveLines(env), evalSaveLines(env2), "%s didn't load back the same", tt.cmd)
+	}
+}
+
+// a saved program loads back and runs the same as the original
+func Test_SaveLoadRoundTrip(t *testing.T) {
+	prog := `10 OPEN "O", #1, "RT.TXT" : PRINT #1, "Hi"; 5 : CLOSE #1
+20 OPEN "RT.TXT" FOR INPUT AS #1 : LINE INPUT #1, A$ : CLOSE
+30 X = 3.25 : Y# = 12345678 : Z = 65536 : GOTO 50
+40 SAVE "A": PRINT 1
+50 REM done
+`
+	lst := []string{
+		`10 OPEN "O", #1, "RT.TXT": PRINT #1, "Hi";5 : CLOSE #1`,
+		`20 OPEN "RT.TXT" FOR INPUT AS #1: LINE INPUT #1, A$: CLOSE`,
+		`30  X = 3.25:  Y# = 12345678:  Z = 65536:  GOTO 50`,
+		`40 SAVE "A": PRINT 1`,
+		`50 REM done`,
+	}
+
+	tests := []struct {
+		cmd  string
+		file string
+		dbl  string // how the double constant lists after loading
+	}{
+		{cmd: `SAVE "rtrip1.bas",A`, file: "rtrip1.bas", dbl: "12345678"},
+		{cmd: `SAVE "rtrip2.bas"`, file: "rtrip2.bas", dbl: "12345678#"},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		mc := &mocks.MockClient{}
+		env.SetClient(mc)
+
+		parser.New(lexer.New(prog)).ParseProgram(env)
+		assert.Equalf(t, lst, evalSaveLines(env), "%s listed wrong", tt.cmd)
+
+		p := parser.New(lexer.New(tt.cmd))
+		p.ParseCmd(env)
+		rc := Eval(&ast.Program{}, env.CmdLineIter(), env)
+		assert.Nilf(t, rc, "%s returned %T", tt.cmd, rc)
+		object.CreateFileStore().RemoveFile(evalFileKey(tt.file, env))
+
+		env2 := object.NewTermEnvironment(mt)
+		env2.SetClient(&mocks.MockClient{Contents: string(mc.Sent)})
+		p = parser.New(lexer.New(`LOAD "` + tt.file + `"`))
+		p.ParseCmd(env2)
+		rc = Eval(&ast.Program{}, env2.CmdLineIter(), env2)
+		assert.Nilf(t, rc, "loading %s returned %T", tt.file, rc)
+		exp := append([]string{}, lst...)
+		exp[2] = strings.Replace(exp[2], "12345678", tt.dbl, 1)
+		assert.Equalf(t, exp, evalSaveLines(env2), "%s didn't load back the same", tt.cmd)
+
+		env2.SetRun(true)
+		rc = Eval(&ast.Program{}, env2.StatementIter(), env2)
+		assert.Equalf(t, &object.String{Value: "Hi 5 "}, env2.Get("A$"), "%s didn't run the same", tt.cmd)
+		assert.Equalf(t, &object.FloatSgl{Value: 3.25}, env2.Get("X"), "%s didn't run the same", tt.cmd)
+		assert.Equalf(t, &object.FloatDbl{Value: 12345678}, env2.Get("Y#"), "%s didn't run the same", tt.cmd)
+		assert.Equalf(t, &object.FloatSgl{Value: 65536}, env2.Get("Z"), "%s didn't run the same", tt.cmd)
+	}
+}
+
+func Test_DiskStatements(t *testing.T) {
+	tests := []struct {
+		inp    string
+		status int      // http status the server returns
+		exists []string // files that should be in local storage afterwards
+		gone   []string // files that should not be
+		err    int16
+	}{
+		{inp: `10 OPEN "kill1.dat" FOR OUTPUT AS #1 : CLOSE : KILL "kill1.dat"`, gone: []string{"kill1.dat"}},
+		{inp: `10 KILL "kill2.dat"`, status: 404, err: berrors.FileNotFound},
+		{inp: `10 KILL "kill3.dat"`},
+		{inp: `10 OPEN "kill4.dat" FOR OUTPUT AS #1 : KILL "kill4.dat"`, err: berrors.FileAlreadyOpen},
+		{inp: `10 KILL 5`, err: berrors.TypeMismatch},
+		{inp: `10 KILL ""`, err: berrors.BadFileName},
+		{inp: `10 OPEN "O", #1, "killw1.tmp" : OPEN "O", #2, "killw2.tmp" : OPEN "O", #3, "killx.tmp" : CLOSE : KILL "killw?.tmp"`,
+			exists: []string{"killx.tmp"}, gone: []string{"killw1.tmp", "killw2.tmp"}},
+		{inp: `10 OPEN "O", #1, "killw3.tmp" : KILL "killw*.*"`, status: 404, err: berrors.FileAlreadyOpen},
+		{inp: `10 KILL "\nodir\*.bas"`, status: 404, err: berrors.PathNotFound},
+		{inp: `10 OPEN "name1.dat" FOR OUTPUT AS #1 : CLOSE : NAME "name1.dat" AS "name2.dat"`,
+			exists: []string{"name2.dat"}, gone: []string{"name1.dat"}},
+		{inp: `10 NAME "name3.dat" AS "name4.dat"`, status: 404, err: berrors.FileNotFound},
+		{inp: `10 OPEN "O", #1, "name5.dat" : OPEN "O", #2, "name6.dat" : CLOSE : NAME "name5.dat" AS "name6.dat"`, err: berrors.FileAlreadyExists},
+		{inp: `10 OPEN "O", #1, "name7.dat" : NAME "name7.dat" AS "name8.dat"`, err: berrors.FileAlreadyOpen},
+		{inp: `10 NAME "name9.dat" AS "a:\name9.dat"`, err: berrors.RenameAcrossDisks},
+		{inp: `10 MKDIR "newdir"`},
+		{inp: `10 MKDIR "newdir"`, status: 403, err: berrors.PermissionDenied},
+		{inp: `10 RMDIR "olddir"`},
+		{inp: `10 RMDIR "olddir"`, status: 409, err: berrors.PathFileAccess},
+		{inp: `10 RMDIR "\"`, err: berrors.PathFileAccess},
+		{inp: `10 OPEN "O", #1, "\full\rmdir1.dat" : CLOSE : RMDIR "\full"`, err: berrors.PathFileAccess},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := parser.New(l)
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		env.SetClient(&mocks.MockClient{StatusCode: tt.status})
+		p.ParseProgram(env)
+
+		assert.Zerof(t, len(p.Errors()), "%s failed to parse", tt.inp)
+
+		env.SetRun(true)
+		rc := Eval(&ast.Program{}, env.StatementIter(), env)
+		env.CloseAllFiles()
+
+		if tt.err != 0 {
+			err, ok := rc.(*object.Error)
+			if assert.Truef(t, ok, "%s didn't fail", tt.inp) {
+				assert.Equalf(t, int(tt.err), err.Code, "%s gave wrong error", tt.inp)
+			}
+			continue
+		}
+
+		assert.Nilf(t, rc, "%s returned %T", tt.inp, rc)
+		for _, f := range tt.exists {
+			assert.Truef(t, object.CreateFileStore().Exists(evalFileKey(f, env)), "%s lost %s", tt.inp, f)
+		}
+		for _, f := range tt.gone {
+			assert.Falsef(t, object.CreateFileStore().Exists(evalFileKey(f, env)), "%s left %s", tt.inp, f)
+		}
+	}
+}
+
+// a wildcard KILL deletes in name order, so a failure always
+// stops at the same file
+func Test_KillWildcardOrder(t *testing.T) {
+	root, err := ioutil.TempDir("", "drivec")
+	assert.Nil(t, err, "couldn't create temp dir")
+	defer os.RemoveAll(root)
+
+	for _, f := range []string{"killo3.tmp", "killo1.tmp", "killo2.tmp"} {
+		ioutil.WriteFile(filepath.Join(root, f), []byte("X"), 0644)
+	}
+
+	// fetch local copies, then KILL them from a read only drive
+	inp := `10 OPEN "I", #1, "killo1.tmp" : OPEN "I", #2, "killo2.tmp" : OPEN "I", #3, "killo3.tmp" : CLOSE : KILL "killo?.tmp"`
+
+	p := parser.New(lexer.New(inp))
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	env := object.NewTermEnvironment(mt)
+	env.SetClient(driveclient.New(root, true))
+	p.ParseProgram(env)
+
+	assert.Zerof(t, len(p.Errors()), "%s failed to parse", inp)
+
+	env.SetRun(true)
+	rc := Eval(&ast.Program{}, env.StatementIter(), env)
+
+	kerr, ok := rc.(*object.Error)
+	if assert.Truef(t, ok, "%s didn't fail", inp) {
+		assert.Equalf(t, int(berrors.PermissionDenied), kerr.Code, "%s gave wrong error", inp)
+	}
+
+	assert.Falsef(t, object.CreateFileStore().Exists(evalFileKey("killo1.tmp", env)), "%s didn't start with killo1.tmp", inp)
+	for _, f := range []string{"killo2.tmp", "killo3.tmp"} {
+		assert.Truef(t, object.CreateFileStore().Exists(evalFileKey(f, env)), "%s went past the failure to %s", inp, f)
+		object.CreateFileStore().RemoveFile(evalFileKey(f, env))
+	}
+}
+
+func Test_CloseWritesToServer(t *testing.T) {
+	root, err := ioutil.TempDir("", "drivec")
+	assert.Nil(t, err, "couldn't create temp dir")
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		inp      string
+		readOnly bool
+		files    map[string]string // what should be on the drive afterwards
+		vars     map[string]object.Object
+		err      int16
+	}{
+		{inp: `10 OPEN "rt1.dat" FOR OUTPUT AS #1 : PRINT #1, "HELLO" : CLOSE #1`, files: map[string]string{"rt1.dat": "HELLO\r\n"}},
+		{inp: `10 OPEN "rt2.dat" FOR OUTPUT AS #1 : WRITE #1, 1, "A" : OPEN "R", #2, "rt3.dat", 2 : FIELD #2, 2 AS A$ : LSET A$ = "XY" : PUT #2, 1 : END`,
+			files: map[string]string{"rt2.dat": "1,\"A\"\r\n", "rt3.dat": "XY"}},
+		{inp: `10 OPEN "rt1.dat" FOR APPEND AS #1 : PRINT #1, "AGAIN" : CLEAR`, files: map[string]string{"rt1.dat": "HELLO\r\nAGAIN\r\n"}},
+		{inp: `10 OPEN "rt1.dat" FOR INPUT AS #1 : LINE INPUT #1, A$ : LINE INPUT #1, B$ : CLOSE`,
+			vars: map[string]object.Object{"A$": &object.String{Value: "HELLO"}, "B$": &object.String{Value: "AGAIN"}}},
+		{inp: `10 OPEN "rt4.dat" FOR OUTPUT AS #1 : PRINT #1, "SCRATCH" : CLOSE #1 : OPEN "rt4.dat" FOR INPUT AS #1 : LINE INPUT #1, A$ : CLOSE`,
+			readOnly: true, vars: map[string]object.Object{"A$": &object.String{Value: "SCRATCH"}}},
+		{inp: `10 OPEN "rt4.dat" FOR OUTPUT AS #1 : PRINT #1, "SCRATCH" : END`, readOnly: true},
+		{inp: `10 OPEN "R", #1, "rt3.dat", 2 : FIELD #1, 2 AS A$ : GET #1, 1 : CLOSE #1`, readOnly: true,
+			vars: map[string]object.Object{"A$": &object.String{Value: "XY"}}},
+		{inp: `10 OPEN "R", #1, "rt3.dat", 2 : FIELD #1, 2 AS A$ : GET #1, 1 : END`, readOnly: true,
+			vars: map[string]object.Object{"A$": &object.String{Value: "XY"}}},
+		{inp: `10 OPEN "rt1.dat" FOR APPEND AS #1 : CLEAR`, readOnly: true},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.inp))
+		var mt mocks.MockTerm
+		initMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+		env.SetClient(driveclient.New(root, tt.readOnly))
+		p.ParseProgram(env)
+
+		assert.Zerof(t, len(p.Errors()), "%s failed to parse", tt.inp)
+
+		env.SetRun(true)
+		rc := Eval(&ast.Program{}, env.StatementIter(), env)
+
+		// drop the local copies so later reads have to go to the drive
+		for _, f := range []string{"rt1.dat", "rt2.dat", "rt3.dat", "rt4.dat"} {
+			object.CreateFileStore().RemoveFile(evalFileKey(f, env))
+		}
+
+		if tt.err != 0 {
+			err, ok := rc.(*object.Error)
+			if assert.Truef(t, ok, "%s didn't fail", tt.inp) {
+				assert.Equalf(t, int(tt.err), err.Code, "%s gave wrong error", tt.inp)
+			}
+			continue
+		}
+
+		if _, ok := rc.(*object.HaltSignal); !ok {
+			assert.Nilf(t, rc, "%s returned %T", tt.inp, rc)
+		}
+		if tt.readOnly {
+			_, err := os.Stat(filepath.Join(root, "rt4.dat"))
+			assert.Truef(t, os.IsNotExist(err), "%s wrote to a read only drive", tt.inp)
+		}
+		for f, exp := range tt.files {
+			data, _ := ioutil.ReadFile(filepath.Join(root, f))
+			assert.Equalf(t, exp, string(data), "%s wrote %s incorrectly", tt.inp, f)
+		}
+		for k, v := range tt.vars {
+			assert.Equalf(t, v, env.Get(k), "%s set %s incorrectly", tt.inp, k)
+		}
+	}
+}
+
+// a program that runs off its last line closes and sends its files
+func Test_CloseAtProgramEnd(t *testing.T) {
+	tests := []struct {
+		inp  string
+		sent string // what reached the server, empty for nothing
+	}{
+		{inp: `OPEN "end1.dat" FOR OUTPUT AS #1 : PRINT #1, "KEPT"`, sent: "KEPT\r\n"},
+		{inp: `OPEN "R", #1, "end2.dat", 4 : FIELD #1, 4 AS A$ : LSET A$ = "abcd" : PUT #1, 1`, sent: "abcd"},
+		{inp: `OPEN "end3.dat" FOR OUTPUT AS #1 : PRINT #1, "HELD" : STOP`},
+	}
+
+	for _, tt := range tests {
+		mc := &mocks.MockClient{}
+		env := testClientEnv(mc)
+
+		testRunEnv(t, tt.inp, nil, 0, env)
+		env.CloseAllFiles()
+
+		if len(tt.sent) == 0 {
+			assert.NotEqualf(t, "PUT", mc.Method, "%s sent its file", tt.inp)
+			continue
+		}
+		assert.Equalf(t, "PUT", mc.Method, "%s didn't send its file", tt.inp)
+		assert.Equalf(t, tt.sent, string(mc.Sent), "%s sent the wrong contents", tt.inp)
+	}
+}
+
+func Test_CloseLostLocalCopy(t *testing.T) {
+	// STOP leaves the file open
+	p := parser.New(lexer.New(`10 OPEN "lost.dat" FOR OUTPUT AS #1 : PRINT #1, "GONE" : STOP`))
+	var mt mocks.MockTerm
+	initMockTerm(&mt)
+	env := object.NewTermEnvironment(mt)
+	mc := &mocks.MockClient{}
+	env.SetClient(mc)
+	p.ParseProgram(env)
+
+	env.SetRun(true)
+	Eval(&ast.Program{}, env.StatementIter(), env)
+
+	object.CreateFileStore().RemoveFile(evalFileKey("lost.dat", env))
+	rc := evalCloseFile(1, env)
+
+	err, ok := rc.(*object.Error)
+	if assert.True(t, ok, "closing a file with no local copy didn't fail") {
+		assert.Equal(t, berrors.FileNotFound, err.Code, "closing a file with no local copy gave wrong error")
+	}
+	assert.Empty(t, mc.Method, "closing a file with no local copy still sent it")
+}
+
+func Test_MatchFileSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+		exp  bool
+	}{
+		{spec: "*.*", name: "test.bas", exp: true},
+		{spec: "*.*", name: "readme", exp: true},
+		{spec: "*.bas", name: "TEST.BAS", exp: true},
+		{spec: "*.bas", name: "test.dat", exp: false},
+		{spec: "t*.bas", name: "test.bas", exp: true},
+		{spec: "t*.bas", name: "best.bas", exp: false},
+		{spec: "test?.bas", name: "test1.bas", exp: true},
+		{spec: "test?.bas", name: "test.bas", exp: true},
+		{spec: "test?.bas", name: "test12.bas", exp: false},
+		{spec: "te?t", name: "test", exp: true},
+		{spec: "test", name: "test.bas", exp: false},
+		{spec: "test.*", name: "test", exp: true},
+	}
+
+	for _, tt := range tests {
+		assert.Equalf(t, tt.exp, matchFileSpec(tt.spec, tt.name), "matchFileSpec(%s, %s)", tt.spec, tt.name)
+	}
+}
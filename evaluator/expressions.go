@@ -1,10 +1,22 @@
 package evaluator
 
 import (
+	"math"
+
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/builtins"
 	"github.com/navionguy/basicwasm/decimal"
 	"github.com/navionguy/basicwasm/object"
 )
 
+// maxSglDigits is the largest whole number a single precision
+// constant can have, longer ones are double precision
+const maxSglDigits = 9999999
+
+// sglConstDigits is the most digits a single precision constant
+// such as 3.14 can be written with, more makes it double precision
+const sglConstDigits = 7
+
 type expression func(string, object.Object, object.Object, *object.Environment) object.Object
 
 var typeConverters = map[string]expression{
@@ -97,3 +109,227 @@ var typeConverters = map[string]expression{
 
 	*/
 }
+
+// evalLogicalInfixExpression does the bitwise operators on 16 bit integers
+func evalLogicalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
+	lVal, err := coerceInteger(left, env)
+	if err != nil {
+		return err
+	}
+
+	rVal, err := coerceInteger(right, env)
+	if err != nil {
+		return err
+	}
+
+	switch operator {
+	case "AND":
+		return &object.Integer{Value: lVal & rVal}
+	case "OR":
+		return &object.Integer{Value: lVal | rVal}
+	case "XOR":
+		return &object.Integer{Value: lVal ^ rVal}
+	case "EQV":
+		return &object.Integer{Value: ^(lVal ^ rVal)}
+	case "IMP":
+		return &object.Integer{Value: ^lVal | rVal}
+	}
+
+	return newError(env, "unsupported operator %s", operator)
+}
+
+// evalNotPrefixExpression flips every bit of a 16 bit integer
+func evalNotPrefixExpression(right object.Object, env *object.Environment) object.Object {
+	val, err := coerceInteger(right, env)
+	if err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: ^val}
+}
+
+// evalIntDivExpression does \ and MOD, both sides are rounded to integers first
+func evalIntDivExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
+	lVal, err := coerceInteger(left, env)
+	if err != nil {
+		return err
+	}
+
+	rVal, err := coerceInteger(right, env)
+	if err != nil {
+		return err
+	}
+
+	if rVal == 0 {
+		return object.StdError(env, berrors.DivByZero)
+	}
+
+	if operator == "MOD" {
+		return &object.Integer{Value: lVal % rVal}
+	}
+
+	// the one quotient that doesn't fit back in 16 bits
+	if (lVal == math.MinInt16) && (rVal == -1) {
+		return object.StdError(env, berrors.Overflow)
+	}
+
+	return &object.Integer{Value: lVal / rVal}
+}
+
+// evalPowerExpression raises left to the power of right
+// the math is single precision unless either side is a double
+func evalPowerExpression(left, right object.Object, env *object.Environment) object.Object {
+	if tv, ok := left.(*object.TypedVar); ok {
+		left = tv.Value
+	}
+	if tv, ok := right.(*object.TypedVar); ok {
+		right = tv.Value
+	}
+
+	base, ok := floatValue(left)
+	exp, ok2 := floatValue(right)
+	if !ok || !ok2 {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if (base == 0) && (exp < 0) {
+		return object.StdError(env, berrors.DivByZero)
+	}
+
+	// a negative number only has real roots for whole powers
+	if (base < 0) && (exp != math.Trunc(exp)) {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	res := math.Pow(base, exp)
+
+	if (left.Type() == object.FLOATDBL_OBJ) || (right.Type() == object.FLOATDBL_OBJ) {
+		if math.IsInf(res, 0) {
+			return object.StdError(env, berrors.Overflow)
+		}
+		return builtins.FixType(env, res)
+	}
+
+	if math.Abs(res) > math.MaxFloat32 {
+		return object.StdError(env, berrors.Overflow)
+	}
+
+	return builtins.FixType(env, float32(res))
+}
+
+// coerceInteger converts a numeric value to the 16 bit integer the
+// logical and integer division operators work on
+func coerceInteger(val object.Object, env *object.Environment) (int16, object.Object) {
+	if isError(val) {
+		return 0, val
+	}
+
+	if iv, ok := val.(*object.Integer); ok {
+		return iv.Value, nil
+	}
+
+	fv, ok := floatValue(val)
+	if !ok {
+		return 0, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	fv = math.Round(fv)
+	if (fv < math.MinInt16) || (fv > math.MaxInt16) {
+		return 0, object.StdError(env, berrors.Overflow)
+	}
+
+	return int16(fv), nil
+}
+
+// floatValue gets the value of any numeric object as a float64
+func floatValue(val object.Object) (float64, bool) {
+	switch nv := val.(type) {
+	case *object.Integer:
+		return float64(nv.Value), true
+	case *object.IntDbl:
+		return float64(nv.Value), true
+	case *object.Fixed:
+		f, _ := nv.Value.Float64()
+		return f, true
+	case *object.FloatSgl:
+		return float64(nv.Value), true
+	case *object.FloatDbl:
+		return nv.Value, true
+	case *object.TypedVar:
+		return floatValue(nv.Value)
+	}
+
+	return 0, false
+}
+
+// numericOperand gets an operand ready for the type converters
+// a long integer does its math in single precision like GW-BASIC would,
+// unless it has more digits than a single can hold
+func numericOperand(val object.Object) object.Object {
+	if tv, ok := val.(*object.TypedVar); ok {
+		val = tv.Value
+	}
+
+	if iv, ok := val.(*object.IntDbl); ok {
+		if (iv.Value > maxSglDigits) || (iv.Value < -maxSglDigits) {
+			return &object.FloatDbl{Value: float64(iv.Value)}
+		}
+		return &object.FloatSgl{Value: float32(iv.Value)}
+	}
+
+	return val
+}
+
+// constDigits counts the digits written in a constant, leading
+// zeros don't count
+func constDigits(lit string) int {
+	digits := 0
+	for _, ch := range lit {
+		if (ch < '0') || (ch > '9') || ((ch == '0') && (digits == 0)) {
+			continue
+		}
+		digits++
+	}
+
+	return digits
+}
+
+// coerceVariable converts val into the type a variable holds
+// typeid is the type character from the variable name, an empty
+// typeid takes whatever number it is given
+func coerceVariable(typeid string, val object.Object, env *object.Environment) object.Object {
+	if tv, ok := val.(*object.TypedVar); ok {
+		val = tv.Value
+	}
+
+	switch val.(type) {
+	case *object.String, *object.BStr:
+		if typeid == "$" {
+			return val
+		}
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	f, ok := floatValue(val)
+	if !ok || (typeid == "$") {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	switch typeid {
+	case "%":
+		i, err := coerceInteger(val, env)
+		if err != nil {
+			return err
+		}
+		return &object.Integer{Value: i}
+	case "!":
+		if math.Abs(f) > math.MaxFloat32 {
+			return object.StdError(env, berrors.Overflow)
+		}
+		return &object.FloatSgl{Value: float32(f)}
+	case "#":
+		return &object.FloatDbl{Value: f}
+	}
+
+	return val
+}
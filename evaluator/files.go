@@ -0,0 +1,457 @@
+package evaluator
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// evalFileNumber finds the open file a statement is directed to
+func evalFileNumber(fn *ast.FileNumber, code *ast.Code, env *object.Environment) (*object.FileHandle, object.Object) {
+	num, err := evalFileNumberValue(fn, code, env)
+	if err != nil {
+		return nil, err
+	}
+
+	fh := env.GetFile(num)
+	if fh == nil {
+		return nil, object.StdError(env, berrors.BadFileNum)
+	}
+
+	return fh, nil
+}
+
+// evalFileNumberValue works out the number in a file number expression
+func evalFileNumberValue(fn *ast.FileNumber, code *ast.Code, env *object.Environment) (int16, object.Object) {
+	if fn.Numbr == nil {
+		return 0, object.StdError(env, berrors.BadFileNum)
+	}
+
+	val := evalExpressionNode(fn.Numbr, code, env)
+	if isError(val) {
+		return 0, val
+	}
+
+	num, err := coerceIndex(val, env)
+	if err != nil {
+		return 0, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	return num, nil
+}
+
+// evalFileForOutput returns the file if it is allowed to be written to
+func evalFileForOutput(fn *ast.FileNumber, code *ast.Code, env *object.Environment) (*object.FileHandle, object.Object) {
+	fh, err := evalFileNumber(fn, code, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if fh.Mode == object.InputFile {
+		return nil, object.StdError(env, berrors.BadFileMode)
+	}
+
+	return fh, nil
+}
+
+// evalFileForInput returns the file if it is allowed to be read from
+func evalFileForInput(fn *ast.FileNumber, code *ast.Code, env *object.Environment) (*object.FileHandle, object.Object) {
+	fh, err := evalFileNumber(fn, code, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if fh.Mode != object.InputFile {
+		return nil, object.StdError(env, berrors.BadFileMode)
+	}
+
+	return fh, nil
+}
+
+// WRITE outputs comma delimited items, strings are quoted
+func evalWriteStatement(wrt *ast.WriteStatement, code *ast.Code, env *object.Environment) object.Object {
+	var out printer = env.Terminal()
+
+	if wrt.FileNum != nil {
+		fh, err := evalFileForOutput(wrt.FileNum, code, env)
+		if err != nil {
+			return err
+		}
+		out = fh
+	}
+
+	var line []string
+	for _, item := range wrt.Items {
+		val := evalExpressionNode(item, code, env)
+		if isError(val) {
+			return val
+		}
+
+		line = append(line, evalWriteItem(val))
+	}
+
+	out.Println(strings.Join(line, ","))
+
+	return nil
+}
+
+// evalWriteItem turns a single value into its WRITE form
+// strings are quoted, numbers print without the space for the sign
+func evalWriteItem(item object.Object) string {
+	if num, ok := object.FormatNumber(item); ok {
+		return strings.TrimPrefix(num, " ")
+	}
+
+	switch val := item.(type) {
+	case *object.String:
+		return `"` + val.Value + `"`
+	case *object.BStr:
+		return `"` + string(val.Value) + `"`
+	case *object.TypedVar:
+		return evalWriteItem(val.Value)
+	}
+
+	return item.Inspect()
+}
+
+// INPUT # reads data items from a file into variables
+func evalInputFileStatement(inp *ast.InputStatement, code *ast.Code, env *object.Environment) object.Object {
+	fh, err := evalFileForInput(inp.FileNum, code, env)
+	if err != nil {
+		return err
+	}
+
+	for _, vr := range inp.Vars {
+		fld, ok := readFileField(fh, inputIsString(vr, env))
+		if !ok {
+			return object.StdError(env, berrors.InputPastEnd)
+		}
+
+		var val object.Object = &object.String{Value: fld.value}
+		if !inputIsString(vr, env) {
+			val, ok = inputNumeric(fld.value, vr, env)
+			if !ok || fld.quoted {
+				return object.StdError(env, berrors.TypeMismatch)
+			}
+		}
+
+		rc := saveVariable(code, env, vr, val)
+		if rc != nil {
+			return rc
+		}
+	}
+
+	return nil
+}
+
+// LINE INPUT # reads everything up to the next carriage return
+func evalLineInputFileStatement(inp *ast.LineInputStatement, code *ast.Code, env *object.Environment) object.Object {
+	fh, err := evalFileForInput(inp.FileNum, code, env)
+	if err != nil {
+		return err
+	}
+
+	if !inputIsString(inp.Var, env) {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if fh.EOF() {
+		return object.StdError(env, berrors.InputPastEnd)
+	}
+
+	var line bytes.Buffer
+	for bt, rerr := fh.ReadByte(); rerr == nil; bt, rerr = fh.ReadByte() {
+		if bt == '\r' {
+			readFileLineFeed(fh)
+			break
+		}
+		line.WriteByte(bt)
+	}
+
+	return saveVariable(code, env, inp.Var, &object.String{Value: line.String()})
+}
+
+// readFileField reads the next data item from a file
+// strings end at a comma or carriage return, numbers also end at a space
+// returns false if there was no data left to read
+func readFileField(fh *object.FileHandle, str bool) (inputField, bool) {
+	var fld inputField
+
+	// skip over any leading white space
+	bt, err := fh.ReadByte()
+	for (err == nil) && ((bt == ' ') || (bt == '\r') || (bt == '\n')) {
+		bt, err = fh.ReadByte()
+	}
+
+	if err != nil {
+		return fld, false
+	}
+
+	var val bytes.Buffer
+	if bt == '"' {
+		fld.quoted = true
+		for bt, err = fh.ReadByte(); (err == nil) && (bt != '"'); bt, err = fh.ReadByte() {
+			val.WriteByte(bt)
+		}
+		fld.value = val.String()
+		readFileDelimiter(fh)
+		return fld, true
+	}
+
+	for ; err == nil; bt, err = fh.ReadByte() {
+		switch {
+		case bt == ',':
+			fld.value = strings.TrimRight(val.String(), " ")
+			return fld, true
+		case bt == '\r':
+			readFileLineFeed(fh)
+			fld.value = strings.TrimRight(val.String(), " ")
+			return fld, true
+		case (bt == ' ') && !str:
+			fld.value = val.String()
+			readFileDelimiter(fh)
+			return fld, true
+		}
+		val.WriteByte(bt)
+	}
+
+	fld.value = strings.TrimRight(val.String(), " ")
+	return fld, true
+}
+
+// readFileDelimiter consumes the comma or CR/LF that ends a data item
+func readFileDelimiter(fh *object.FileHandle) {
+	bt, err := fh.ReadByte()
+	for (err == nil) && (bt == ' ') {
+		bt, err = fh.ReadByte()
+	}
+
+	if err != nil {
+		return
+	}
+
+	switch bt {
+	case ',':
+	case '\r':
+		readFileLineFeed(fh)
+	default:
+		fh.UnreadByte()
+	}
+}
+
+// a carriage return may be followed by a line feed, skip it if it is
+func readFileLineFeed(fh *object.FileHandle) {
+	bt, err := fh.ReadByte()
+	if (err == nil) && (bt != '\n') {
+		fh.UnreadByte()
+	}
+}
+
+// evalFileForRecords returns the file if it is open for random access
+func evalFileForRecords(fn *ast.FileNumber, code *ast.Code, env *object.Environment) (*object.FileHandle, object.Object) {
+	if fn == nil {
+		return nil, object.StdError(env, berrors.Syntax)
+	}
+
+	fh, err := evalFileNumber(fn, code, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if fh.Mode != object.RandomFile {
+		return nil, object.StdError(env, berrors.BadFileMode)
+	}
+
+	return fh, nil
+}
+
+// FIELD maps string variables onto the record buffer
+func evalFieldStatement(fld *ast.FieldStatement, code *ast.Code, env *object.Environment) object.Object {
+	fh, err := evalFileForRecords(fld.FileNum, code, env)
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	for _, item := range fld.Fields {
+		if !inputIsString(item.Var, env) || item.Var.Array {
+			return object.StdError(env, berrors.TypeMismatch)
+		}
+
+		val := evalExpressionNode(item.Width, code, env)
+		if isError(val) {
+			return val
+		}
+
+		width, err := coerceIndex(val, env)
+		if err != nil {
+			return object.StdError(env, berrors.TypeMismatch)
+		}
+
+		if (width < 0) || (offset+int(width) > fh.RecLen) {
+			return object.StdError(env, berrors.FieldOverflow)
+		}
+
+		fh.Field(item.Var.Value, offset, int(width))
+		env.Set(item.Var.Value, &object.String{Value: fh.FieldValue(fh.FindField(item.Var.Value))})
+		offset += int(width)
+	}
+
+	return nil
+}
+
+// GET reads a record and refreshes the FIELD variables
+func evalGetStatement(get *ast.GetStatement, code *ast.Code, env *object.Environment) object.Object {
+	fh, err := evalFileForRecords(get.FileNum, code, env)
+	if err != nil {
+		return err
+	}
+
+	rec, err := evalRecordNumber(get.Record, code, env)
+	if err != nil {
+		return err
+	}
+
+	if rc := fh.GetRecord(rec); rc != 0 {
+		return object.StdError(env, rc)
+	}
+
+	for i := range fh.Fields {
+		env.Set(fh.Fields[i].Name, &object.String{Value: fh.FieldValue(&fh.Fields[i])})
+	}
+
+	return nil
+}
+
+// PUT writes the record buffer to the file
+func evalPutStatement(put *ast.PutStatement, code *ast.Code, env *object.Environment) object.Object {
+	fh, err := evalFileForRecords(put.FileNum, code, env)
+	if err != nil {
+		return err
+	}
+
+	rec, err := evalRecordNumber(put.Record, code, env)
+	if err != nil {
+		return err
+	}
+
+	if rc := fh.PutRecord(rec); rc != 0 {
+		return object.StdError(env, rc)
+	}
+
+	return nil
+}
+
+// evalRecordNumber returns the record number, zero if not specified
+func evalRecordNumber(rec ast.Expression, code *ast.Code, env *object.Environment) (int, object.Object) {
+	if rec == nil {
+		return 0, nil
+	}
+
+	val := evalExpressionNode(rec, code, env)
+	if isError(val) {
+		return 0, val
+	}
+
+	num, err := coerceDblInteger(val, env)
+	if err != nil {
+		return 0, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if num < 1 {
+		return 0, object.StdError(env, berrors.BadRecordNum)
+	}
+
+	return int(num), nil
+}
+
+// LSET and RSET copy a string into a variable without changing its length
+func evalSetStatement(name *ast.Identifier, value ast.Expression, right bool, code *ast.Code, env *object.Environment) object.Object {
+	if !inputIsString(name, env) {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	val := evalExpressionNode(value, code, env)
+	if isError(val) {
+		return val
+	}
+
+	var bt []byte
+	switch str := val.(type) {
+	case *object.String:
+		bt = []byte(str.Value)
+	case *object.BStr:
+		bt = str.Value
+	default:
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	// FIELD variables go into the record buffer
+	fh, fv := env.FindField(name.Value)
+	if fh != nil {
+		fh.SetField(fv, bt, right)
+		env.Set(name.Value, &object.String{Value: fh.FieldValue(fv)})
+		return nil
+	}
+
+	// anything else keeps its current length
+	cur, ok := evalExpressionNode(name, code, env).(*object.String)
+	if !ok {
+		return nil
+	}
+
+	return saveVariable(code, env, name, &object.String{Value: string(object.PadField(bt, len(cur.Value), right))})
+}
+
+// evalCloseFile closes an open file, if it was written to
+// it is sent on to the server
+// a read only drive refuses the copy, the local one stays
+// and the program carries on as it always has
+func evalCloseFile(f int16, env *object.Environment) object.Object {
+	fh := env.GetFile(f)
+	if (fh == nil) || !env.CloseFile(f) {
+		return object.StdError(env, berrors.BadFileNum)
+	}
+
+	if !fh.Dirty() || isDeviceFile(fh, env) {
+		return nil
+	}
+
+	data, ok := object.CreateFileStore().ReadFile(fh.Name)
+	if !ok {
+		return object.StdError(env, berrors.FileNotFound)
+	}
+	rc := fileserv.PutFile(fh.Name, data, env)
+	if err, ok := rc.(*object.Error); ok && (err.Code == berrors.PermissionDenied) {
+		return nil
+	}
+	return rc
+}
+
+// isDeviceFile is true for files such as "LPT1:" that never
+// reach the server
+func isDeviceFile(fh *object.FileHandle, env *object.Environment) bool {
+	_, ok := env.DeviceWidth(fh.Name[strings.LastIndex(fh.Name, `\`)+1:])
+	return ok
+}
+
+// evalCloseAllFiles closes every open file, the first
+// error stops nothing but is the one reported
+func evalCloseAllFiles(env *object.Environment) object.Object {
+	var rc object.Object
+
+	for f := int16(1); f <= object.MaxFiles; f++ {
+		if env.GetFile(f) == nil {
+			continue
+		}
+		if err := evalCloseFile(f, env); (err != nil) && (rc == nil) {
+			rc = err
+		}
+	}
+
+	return rc
+}
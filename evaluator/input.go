@@ -0,0 +1,241 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// a single value typed in response to an INPUT
+type inputField struct {
+	value  string // the characters entered
+	quoted bool   // was it entered inside quotes
+}
+
+// INPUT reads a comma seperated list of values into variables
+func evalInputStatement(inp *ast.InputStatement, code *ast.Code, env *object.Environment) object.Object {
+	if len(inp.Vars) == 0 {
+		return object.StdError(env, berrors.Syntax)
+	}
+
+	for {
+		evalInputPrompt(inp.Prompt, inp.QMark, env)
+
+		line, brk := readInputLine(inp.SameLine, env)
+		if brk {
+			return evalStatementsBreakChk(code, env)
+		}
+
+		vals, ok := evalInputValues(line, inp.Vars, env)
+		if ok {
+			return evalInputSave(vals, inp.Vars, code, env)
+		}
+
+		env.Terminal().Println("?Redo from start")
+	}
+}
+
+// LINE INPUT reads an entire line into a string variable
+func evalLineInputStatement(inp *ast.LineInputStatement, code *ast.Code, env *object.Environment) object.Object {
+	if inp.Var == nil {
+		return object.StdError(env, berrors.Syntax)
+	}
+
+	if !inputIsString(inp.Var, env) {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	evalInputPrompt(inp.Prompt, false, env)
+
+	line, brk := readInputLine(inp.SameLine, env)
+	if brk {
+		return evalStatementsBreakChk(code, env)
+	}
+
+	return saveVariable(code, env, inp.Var, &object.String{Value: line})
+}
+
+// display the prompt, if there is one, and an optional question mark
+func evalInputPrompt(prompt string, qmark bool, env *object.Environment) {
+	if qmark {
+		prompt += "? "
+	}
+
+	if len(prompt) > 0 {
+		env.Terminal().Print(prompt)
+	}
+}
+
+// readInputLine echoes keystrokes until the user hits enter
+// returns true if the user hit CTRL-C to stop the program
+// or the keyboard input ran dry
+func readInputLine(sameLine bool, env *object.Environment) (string, bool) {
+	var line []byte
+
+	for {
+		keys := env.Terminal().ReadKeys(1)
+
+		// input has been closed, nothing more will come
+		if len(keys) == 0 {
+			return string(line), true
+		}
+
+		switch k := keys[0]; {
+		case k == '\r' || k == '\n':
+			if !sameLine {
+				env.Terminal().Println("")
+			}
+			return string(line), false
+		case k == 0x03: // ctrl-c
+			env.Terminal().Println("")
+			return "", true
+		case k == 0x00: // extended keys don't edit the line
+			env.Terminal().ReadKeys(1)
+		case k == 0x08 || k == 0x7f: // backspace
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				env.Terminal().Print("\b \b")
+			}
+		case k >= ' ':
+			line = append(line, k)
+			env.Terminal().Print(string(k))
+		}
+	}
+}
+
+// convert the line entered into values for each variable
+// returns false if the user needs to try again
+func evalInputValues(line string, vars []*ast.Identifier, env *object.Environment) ([]object.Object, bool) {
+	fields, ok := splitInputFields(line)
+
+	if !ok || (len(fields) != len(vars)) {
+		return nil, false
+	}
+
+	var vals []object.Object
+	for i, fld := range fields {
+		if inputIsString(vars[i], env) {
+			vals = append(vals, &object.String{Value: fld.value})
+			continue
+		}
+
+		// quoted strings can't go into numeric variables
+		if fld.quoted {
+			return nil, false
+		}
+
+		val, ok := inputNumeric(fld.value, vars[i], env)
+		if !ok {
+			return nil, false
+		}
+		vals = append(vals, val)
+	}
+
+	return vals, true
+}
+
+// save the values entered into their variables
+func evalInputSave(vals []object.Object, vars []*ast.Identifier, code *ast.Code, env *object.Environment) object.Object {
+	for i, vr := range vars {
+		rc := saveVariable(code, env, vr, vals[i])
+		if rc != nil {
+			return rc
+		}
+	}
+
+	return nil
+}
+
+// splitInputFields breaks a line up on commas
+// a field starting with a quote runs until the closing quote
+func splitInputFields(line string) ([]inputField, bool) {
+	var fields []inputField
+
+	for i := 0; ; i++ {
+		// skip any leading spaces
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+
+		var fld inputField
+		if i < len(line) && line[i] == '"' {
+			end := strings.IndexByte(line[i+1:], '"')
+			if end == -1 {
+				end = len(line) - i - 1
+			}
+			fld = inputField{value: line[i+1 : i+1+end], quoted: true}
+			i += end + 2
+
+			// only spaces allowed between the closing quote and the comma
+			for i < len(line) && line[i] == ' ' {
+				i++
+			}
+			if i < len(line) && line[i] != ',' {
+				return nil, false
+			}
+		} else {
+			end := strings.IndexByte(line[i:], ',')
+			if end == -1 {
+				end = len(line) - i
+			}
+			fld = inputField{value: strings.TrimRight(line[i:i+end], " ")}
+			i += end
+		}
+		fields = append(fields, fld)
+
+		if i >= len(line) {
+			return fields, true
+		}
+	}
+}
+
+// inputIsString returns true if the variable holds a string
+func inputIsString(vr *ast.Identifier, env *object.Environment) bool {
+	typeid, _ := parseVarName(vr.Value, env)
+
+	return typeid == "$"
+}
+
+// inputNumeric converts the characters entered into the variable's type
+func inputNumeric(fld string, vr *ast.Identifier, env *object.Environment) (object.Object, bool) {
+	if len(fld) == 0 {
+		fld = "0"
+	}
+
+	// don't let ParseFloat accept things like Inf or hex values
+	if strings.Trim(fld, "0123456789+-.EeDd") != "" {
+		return nil, false
+	}
+
+	// GW-BASIC allows D for double precision exponents
+	val, err := strconv.ParseFloat(strings.Replace(strings.ToUpper(fld), "D", "E", 1), 64)
+	if err != nil {
+		return nil, false
+	}
+
+	typeid, _ := parseVarName(vr.Value, env)
+
+	switch typeid {
+	case "%":
+		val = math.Round(val)
+		if (val < math.MinInt16) || (val > math.MaxInt16) {
+			return nil, false
+		}
+		return &object.Integer{Value: int16(val)}, true
+	case "!":
+		return &object.FloatSgl{Value: float32(val)}, true
+	case "#":
+		return &object.FloatDbl{Value: val}, true
+	}
+
+	// whole numbers that fit are stored as integers
+	if (val == math.Trunc(val)) && (val >= math.MinInt16) && (val <= math.MaxInt16) && !strings.ContainsAny(fld, ".EeDd") {
+		return &object.Integer{Value: int16(val)}, true
+	}
+
+	return &object.FloatSgl{Value: float32(val)}, true
+}
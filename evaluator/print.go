@@ -0,0 +1,201 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/object"
+)
+
+const (
+	printZone  = 14 // columns in each comma print zone
+	screenRows = 25 // the only row count WIDTH accepts
+)
+
+// printCursor keeps track of the column PRINT is writing to
+// so commas, TAB() and SPC() line up and long lines wrap
+type printCursor struct {
+	out   printer
+	col   int // zero based
+	width int
+}
+
+// newPrintCursor starts at the current column of the screen or file
+func newPrintCursor(out printer, env *object.Environment) *printCursor {
+	pc := &printCursor{out: out}
+
+	if fh, ok := out.(*object.FileHandle); ok {
+		pc.col = fh.Column()
+		pc.width = fh.Width
+		if pc.width < 1 {
+			pc.width = object.NoWrap
+		}
+		return pc
+	}
+
+	pc.width, _ = env.DeviceWidth(object.ScreenDevice)
+	_, pc.col = env.Terminal().GetCursor()
+	if pc.col >= pc.width {
+		pc.col = pc.width - 1
+	}
+	return pc
+}
+
+// newline ends the current line
+func (pc *printCursor) newline() {
+	pc.out.Println("")
+	pc.col = 0
+}
+
+// write prints text, starting a new line whenever it reaches the width
+func (pc *printCursor) write(text string) {
+	for len(text) > 0 {
+		if pc.width != object.NoWrap && pc.col >= pc.width {
+			pc.newline()
+		}
+
+		n := len(text)
+		if pc.width != object.NoWrap && pc.col+n > pc.width {
+			n = pc.width - pc.col
+		}
+
+		pc.out.Print(text[:n])
+		pc.col += n
+		text = text[n:]
+	}
+}
+
+// writeNumber moves to a new line first if the number won't fit
+func (pc *printCursor) writeNumber(num string) {
+	if pc.width != object.NoWrap && pc.col > 0 && pc.col+len(num) > pc.width {
+		pc.newline()
+	}
+	pc.write(num)
+}
+
+// zone advances to the start of the next print zone
+// if there isn't one left on the line, it starts a new line
+func (pc *printCursor) zone() {
+	next := (pc.col/printZone + 1) * printZone
+
+	if pc.width != object.NoWrap && next/printZone >= pc.width/printZone {
+		pc.newline()
+		return
+	}
+	pc.write(strings.Repeat(" ", next-pc.col))
+}
+
+// tab moves to column n, counting from 1
+// if already past it, the move is on the next line
+func (pc *printCursor) tab(n int) {
+	if pc.width != object.NoWrap && n > pc.width {
+		n = n % pc.width
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	if n-1 < pc.col {
+		pc.newline()
+	}
+	pc.write(strings.Repeat(" ", n-1-pc.col))
+}
+
+// spc prints n spaces, never more than a line's worth
+func (pc *printCursor) spc(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if pc.width != object.NoWrap {
+		n = n % pc.width
+	}
+	pc.write(strings.Repeat(" ", n))
+}
+
+// WIDTH sets how many characters fit on a line of the screen,
+// an open file or a device
+func evalWidthStatement(wdth *ast.WidthStatement, code *ast.Code, env *object.Environment) object.Object {
+	var vals []object.Object
+	for _, prm := range wdth.Params {
+		val := evalExpressionNode(prm, code, env)
+		if isError(val) {
+			return val
+		}
+		vals = append(vals, val)
+	}
+
+	if wdth.FileNum != nil {
+		fh, err := evalFileNumber(wdth.FileNum, code, env)
+		if err != nil {
+			return err
+		}
+
+		size, err := evalWidthSize(vals[0], env)
+		if err != nil {
+			return err
+		}
+		fh.Width = size
+		return nil
+	}
+
+	// a device name comes before its size
+	if dev, ok := vals[0].(*object.String); ok && (len(vals) == 2) {
+		return evalWidthDevice(dev.Value, vals[1], env)
+	}
+
+	// the screen can also be given its rows, but only 25 will do
+	if len(vals) == 2 {
+		rows, err := evalWidthSize(vals[1], env)
+		if err != nil {
+			return err
+		}
+		if rows != screenRows {
+			return object.StdError(env, berrors.IllegalFuncCallErr)
+		}
+	}
+
+	return evalWidthDevice(object.ScreenDevice, vals[0], env)
+}
+
+// evalWidthDevice changes the width of the named device
+// the screen only comes in 40 or 80 columns
+func evalWidthDevice(dev string, val object.Object, env *object.Environment) object.Object {
+	size, err := evalWidthSize(val, env)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(dev, object.ScreenDevice) {
+		return env.SetDeviceWidth(dev, size)
+	}
+
+	if (size != 40) && (size != 80) {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	// the screen only gets cleared if the width changes
+	if cur, _ := env.DeviceWidth(dev); cur == size {
+		return nil
+	}
+
+	return env.SetDeviceWidth(dev, size)
+}
+
+// evalWidthSize turns val into a line width between 1 and 255
+func evalWidthSize(val object.Object, env *object.Environment) (int, object.Object) {
+	if isStringValue(val) {
+		return 0, object.StdError(env, berrors.TypeMismatch)
+	}
+
+	size, err := coerceIndex(val, env)
+	if err != nil {
+		return 0, err
+	}
+
+	if (size < 1) || (size > object.NoWrap) {
+		return 0, object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	return int(size), nil
+}
@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/fileserv"
+	"github.com/navionguy/basicwasm/gwtoken"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// SAVE writes the current program to the server, a copy is
+// also kept in the local file store
+func evalSaveCommand(cmd *ast.SaveCommand, code *ast.Code, env *object.Environment) object.Object {
+	if cmd.Path == nil {
+		return object.StdError(env, berrors.Syntax)
+	}
+
+	res := Eval(cmd.Path, code, env)
+	str, ok := res.(*object.String)
+
+	if !ok {
+		return object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if len(str.Value) == 0 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	// listing walks the program, a running one carries on from here
+	rp := code.GetReturnPoint()
+	lines := evalSaveLines(env)
+	code.JumpToRetPoint(rp)
+
+	var data []byte
+	switch cmd.Format {
+	case "A":
+		var buf bytes.Buffer
+		for _, line := range lines {
+			buf.WriteString(line + "\r\n")
+		}
+		data = buf.Bytes()
+	case "P":
+		data = gwtoken.EncodeProtectedFile(lines)
+	default:
+		data = gwtoken.EncodeFile(lines)
+	}
+
+	file := evalProgramName(str.Value)
+	if err := fileserv.PutFile(file, data, env); err != nil {
+		return err
+	}
+	object.CreateFileStore().StoreFile(evalFileKey(file, env), data)
+
+	return nil
+}
+
+// evalProgramName gives a program file the default .BAS extension
+// if it doesn't have one of its own
+func evalProgramName(file string) string {
+	base := file[strings.LastIndexAny(file, `\/:`)+1:]
+	if strings.Contains(base, ".") {
+		return file
+	}
+
+	return file + ".BAS"
+}
+
+// evalSaveLines lists every line of the program
+func evalSaveLines(env *object.Environment) []string {
+	var lines []string
+
+	for _, line := range listProgram(0, env.StatementIter().MaxLineNum(), env) {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// evalFileKey builds the name a file is kept under in the local store
+func evalFileKey(file string, env *object.Environment) string {
+	return strings.TrimSuffix(fileserv.BuildFullPath(file, env), `\`)
+}
+
+// evalGetProgram finds a program file, local copies are used before
+// asking the server for it
+func evalGetProgram(file string, env *object.Environment) (*bufio.Reader, object.Object) {
+	if len(file) > 0 {
+		file = evalProgramName(file)
+		if data, ok := object.CreateFileStore().ReadFile(evalFileKey(file, env)); ok {
+			return bufio.NewReader(bytes.NewReader(data)), nil
+		}
+	}
+
+	return fileserv.GetFile(file, env)
+}
@@ -0,0 +1,221 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/keybuffer"
+	"github.com/navionguy/basicwasm/object"
+	"github.com/navionguy/basicwasm/settings"
+)
+
+// ON KEY(n) GOSUB sets the line to jump to when key n is pressed
+func evalOnKeyStatement(stmt *ast.OnKeyStatement, code *ast.Code, env *object.Environment) object.Object {
+	n, err := evalTrapKeyNum(stmt.Key, code, env)
+	if err != nil {
+		return err
+	}
+
+	if err := evalTrapLineCheck(stmt.Jump, code, env); err != nil {
+		return err
+	}
+
+	traps := evalGetKeyTraps(env)
+	traps.Keys[n].Line = stmt.Jump
+	env.SaveSetting(settings.OnKeys, traps)
+
+	return nil
+}
+
+// KEY(n) ON/OFF/STOP controls trapping of key n
+func evalKeyTrapStatement(stmt *ast.KeyTrapStatement, code *ast.Code, env *object.Environment) object.Object {
+	n, err := evalTrapKeyNum(stmt.Key, code, env)
+	if err != nil {
+		return err
+	}
+
+	traps := evalGetKeyTraps(env)
+	if !evalTrapAction(stmt.Action, &traps.Keys[n]) {
+		return object.StdError(env, berrors.Syntax)
+	}
+	env.SaveSetting(settings.OnKeys, traps)
+
+	return nil
+}
+
+// ON TIMER(n) GOSUB sets the line to jump to every n seconds
+func evalOnTimerStatement(stmt *ast.OnTimerStatement, code *ast.Code, env *object.Environment) object.Object {
+	val := evalExpressionNode(stmt.Interval, code, env)
+	if isError(val) {
+		return val
+	}
+
+	secs, err := coerceDblInteger(val, env)
+	if err != nil {
+		return err
+	}
+
+	// the interval has to fit in a day
+	if (secs < 1) || (secs > 86400) {
+		return object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	if err := evalTrapLineCheck(stmt.Jump, code, env); err != nil {
+		return err
+	}
+
+	tt := evalGetTimerTrap(env)
+	tt.Trap.Line = stmt.Jump
+	tt.Interval = time.Duration(secs) * time.Second
+	tt.Next = env.Now().Add(tt.Interval)
+	env.SaveSetting(settings.OnTimer, tt)
+
+	return nil
+}
+
+// TIMER ON/OFF/STOP controls the timer trap
+func evalTimerStatement(stmt *ast.TimerStatement, code *ast.Code, env *object.Environment) object.Object {
+	tt := evalGetTimerTrap(env)
+	wasOff := tt.Trap.State == ast.TrapOff
+
+	if !evalTrapAction(stmt.Action, &tt.Trap) {
+		return object.StdError(env, berrors.Syntax)
+	}
+
+	// the timer starts counting when it gets turned on
+	if wasOff && (tt.Trap.State != ast.TrapOff) {
+		tt.Next = env.Now().Add(tt.Interval)
+	}
+	env.SaveSetting(settings.OnTimer, tt)
+
+	return nil
+}
+
+// evalTrapAction applies ON, OFF or STOP to a trap
+// returns false if the action isn't one of those
+func evalTrapAction(action string, trap *ast.EventTrap) bool {
+	switch action {
+	case "ON":
+		trap.State = ast.TrapOn
+	case "OFF":
+		// events seen while stopped are forgotten
+		trap.State = ast.TrapOff
+		trap.Pending = false
+	case "STOP":
+		trap.State = ast.TrapStop
+	default:
+		return false
+	}
+
+	return true
+}
+
+// evalTrapKeyNum gets the key number for a KEY(n) trap, valid keys are 1-20
+func evalTrapKeyNum(key ast.Expression, code *ast.Code, env *object.Environment) (int, object.Object) {
+	val := evalExpressionNode(key, code, env)
+	if isError(val) {
+		return 0, val
+	}
+
+	n, err := coerceIndex(val, env)
+	if err != nil {
+		return 0, err
+	}
+
+	if (n < 1) || (n > 20) {
+		return 0, object.StdError(env, berrors.IllegalFuncCallErr)
+	}
+
+	return int(n), nil
+}
+
+// evalTrapLineCheck makes sure the handler exists, line 0 turns the trap off
+func evalTrapLineCheck(line int, code *ast.Code, env *object.Environment) object.Object {
+	// from the command line, check the program not the command
+	if !env.ProgramRunning() {
+		code = env.StatementIter()
+	}
+
+	if (line != 0) && !code.Exists(line) {
+		return object.StdError(env, berrors.UnDefinedLineNumber)
+	}
+
+	return nil
+}
+
+// retrieve or create the current key traps
+func evalGetKeyTraps(env *object.Environment) *ast.KeyTraps {
+	if traps, ok := env.GetSetting(settings.OnKeys).(*ast.KeyTraps); ok {
+		return traps
+	}
+
+	return &ast.KeyTraps{}
+}
+
+// retrieve or create the current timer trap
+func evalGetTimerTrap(env *object.Environment) *ast.TimerTrap {
+	if tt, ok := env.GetSetting(settings.OnTimer).(*ast.TimerTrap); ok {
+		return tt
+	}
+
+	return &ast.TimerTrap{}
+}
+
+// evalClearTraps turns off all the traps when a program starts
+// user defined keys stay defined
+func evalClearTraps(env *object.Environment) {
+	if traps, ok := env.GetSetting(settings.OnKeys).(*ast.KeyTraps); ok {
+		traps.Keys = [21]ast.EventTrap{}
+	}
+	env.ClrSetting(settings.OnTimer)
+}
+
+// evalEventTraps runs between statements looking for events
+// if a trap is ready, it does a GOSUB to the handler
+func evalEventTraps(code *ast.Code, env *object.Environment) {
+	if !env.ProgramRunning() {
+		return
+	}
+
+	var ready []*ast.EventTrap
+
+	if traps, ok := env.GetSetting(settings.OnKeys).(*ast.KeyTraps); ok {
+		kb := keybuffer.GetKeyBuffer()
+		for i := 1; i < len(traps.Keys); i++ {
+			if kb.TrapSeen(i) && (traps.Keys[i].State != ast.TrapOff) {
+				traps.Keys[i].Pending = true
+			}
+			ready = append(ready, &traps.Keys[i])
+		}
+	}
+
+	if tt, ok := env.GetSetting(settings.OnTimer).(*ast.TimerTrap); ok && (tt.Trap.State != ast.TrapOff) {
+		now := env.Now()
+		if !now.Before(tt.Next) {
+			tt.Trap.Pending = true
+			tt.Next = now.Add(tt.Interval)
+		}
+		ready = append(ready, &tt.Trap)
+	}
+
+	for _, trap := range ready {
+		// stopped traps hang on to the event until turned back on
+		if trap.Pending && (trap.State == ast.TrapOn) && !trap.Active && (trap.Line > 0) {
+			evalTrapGosub(trap, code, env)
+			return
+		}
+	}
+}
+
+// evalTrapGosub jumps to a trap handler, RETURN brings you back
+// to the statement after the one that was interrupted
+func evalTrapGosub(trap *ast.EventTrap, code *ast.Code, env *object.Environment) {
+	rp := code.GetReturnPoint()
+	rp.Trap = trap
+	env.Push(rp)
+
+	trap.Pending = false
+	trap.Active = true
+	code.Jump(trap.Line)
+}
@@ -0,0 +1,426 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/navionguy/basicwasm/berrors"
+	"github.com/navionguy/basicwasm/object"
+)
+
+// usingFormat steps through a PRINT USING format string
+// each value printed fills the next field, when the fields run
+// out it starts over from the beginning of the format
+type usingFormat struct {
+	form string
+	pos  int
+}
+
+// usingField describes one field of a format string
+type usingField struct {
+	kind     byte // '#' for numbers, '!', '\' or '&' for strings
+	width    int  // characters in a \  \ field
+	digits   int  // positions to the left of the decimal point
+	decimal  bool // field has a decimal point
+	frac     int  // digits after the decimal point
+	plus     bool // leading + sign
+	trailing byte // trailing '+' or '-' sign
+	fill     bool // ** asterisk fill
+	dollar   bool // $$ floating dollar sign
+	commas   bool // comma every third digit
+	exponent bool // ^^^^ exponential format
+}
+
+// newUsingFormat gets ready to walk through form
+func newUsingFormat(form string) *usingFormat {
+	return &usingFormat{form: form}
+}
+
+// format returns any literal text up to the next field followed by
+// item formatted to fit that field
+func (uf *usingFormat) format(item object.Object, env *object.Environment) (string, object.Object) {
+	var out strings.Builder
+
+	wrapped := false
+	for {
+		lit, fld, ok := uf.nextField()
+		out.WriteString(lit)
+		if ok {
+			res, err := fld.formatItem(item, env)
+			if err != nil {
+				return "", err
+			}
+			return out.String() + res, nil
+		}
+
+		// a format with no fields can't print anything
+		if wrapped || (uf.pos == 0) {
+			return "", object.StdError(env, berrors.IllegalFuncCallErr)
+		}
+		wrapped = true
+		uf.pos = 0
+	}
+}
+
+// finish returns the literal text that follows the last field used
+func (uf *usingFormat) finish() string {
+	lit, _, _ := uf.nextField()
+
+	return lit
+}
+
+// nextField collects literal text until it finds a field
+// returns false if it reached the end of the format first
+func (uf *usingFormat) nextField() (string, usingField, bool) {
+	var lit strings.Builder
+
+	for uf.pos < len(uf.form) {
+		if fld, end, ok := scanUsingField(uf.form, uf.pos); ok {
+			uf.pos = end
+			return lit.String(), fld, true
+		}
+
+		// underscore prints the next character as is
+		if (uf.form[uf.pos] == '_') && (uf.pos+1 < len(uf.form)) {
+			uf.pos++
+		}
+		lit.WriteByte(uf.form[uf.pos])
+		uf.pos++
+	}
+
+	return lit.String(), usingField{}, false
+}
+
+// scanUsingField checks for a field starting at pos
+// returns the field and where it ends
+func scanUsingField(form string, pos int) (usingField, int, bool) {
+	switch form[pos] {
+	case '!', '&':
+		return usingField{kind: form[pos], width: 1}, pos + 1, true
+	case '\\':
+		end := pos + 1
+		for (end < len(form)) && (form[end] == ' ') {
+			end++
+		}
+		if (end < len(form)) && (form[end] == '\\') {
+			return usingField{kind: '\\', width: end - pos + 1}, end + 1, true
+		}
+		return usingField{}, pos, false
+	}
+
+	return scanUsingNumeric(form, pos)
+}
+
+// scanUsingNumeric checks for a numeric field starting at pos
+func scanUsingNumeric(form string, pos int) (usingField, int, bool) {
+	fld := usingField{kind: '#'}
+	i := pos
+
+	if strings.HasPrefix(form[i:], "+") {
+		fld.plus = true
+		i++
+	}
+
+	switch {
+	case strings.HasPrefix(form[i:], "**$"):
+		fld.fill, fld.dollar, fld.digits = true, true, 3
+		i += 3
+	case strings.HasPrefix(form[i:], "**"):
+		fld.fill, fld.digits = true, 2
+		i += 2
+	case strings.HasPrefix(form[i:], "$$"):
+		fld.dollar, fld.digits = true, 2
+		i += 2
+	case strings.HasPrefix(form[i:], "#"), strings.HasPrefix(form[i:], ".#"):
+	default:
+		return usingField{}, pos, false
+	}
+
+	// digits and commas in front of the decimal point
+	for ; i < len(form); i++ {
+		if form[i] == '#' {
+			fld.digits++
+			continue
+		}
+		// a comma only counts if the field carries on after it
+		if (form[i] == ',') && (i+1 < len(form)) && strings.ContainsRune("#,.", rune(form[i+1])) {
+			fld.commas = true
+			fld.digits++
+			continue
+		}
+		break
+	}
+
+	if (i < len(form)) && (form[i] == '.') {
+		fld.decimal = true
+		for i++; (i < len(form)) && (form[i] == '#'); i++ {
+			fld.frac++
+		}
+	}
+
+	if strings.HasPrefix(form[i:], "^^^^") {
+		fld.exponent = true
+		i += 4
+	}
+
+	if !fld.plus && (i < len(form)) && ((form[i] == '+') || (form[i] == '-')) {
+		fld.trailing = form[i]
+		i++
+	}
+
+	return fld, i, true
+}
+
+// formatItem turns item into the text for the field
+func (fld usingField) formatItem(item object.Object, env *object.Environment) (string, object.Object) {
+	if tv, ok := item.(*object.TypedVar); ok {
+		item = tv.Value
+	}
+
+	if fld.kind != '#' {
+		str, ok := item.(*object.String)
+		if !ok {
+			return "", object.StdError(env, berrors.TypeMismatch)
+		}
+		return fld.formatString(str.Value), nil
+	}
+
+	val, ok := usingValue(item)
+	if !ok {
+		return "", object.StdError(env, berrors.TypeMismatch)
+	}
+
+	if fld.exponent {
+		return fld.formatExponent(val), nil
+	}
+
+	return fld.formatNumber(val), nil
+}
+
+// formatString fits a string into a string field
+func (fld usingField) formatString(str string) string {
+	if fld.kind == '&' {
+		return str
+	}
+
+	if len(str) >= fld.width {
+		return str[:fld.width]
+	}
+
+	return str + strings.Repeat(" ", fld.width-len(str))
+}
+
+// formatNumber fits a number into a fixed point field
+func (fld usingField) formatNumber(val float64) string {
+	num := usingRound(strconv.FormatFloat(math.Abs(val), 'f', -1, 64), fld.frac)
+	neg := (val < 0) && (strings.Trim(num, "0.") != "")
+
+	intPart, fracPart := num, ""
+	if i := strings.IndexByte(num, '.'); i != -1 {
+		intPart, fracPart = num[:i], num[i+1:]
+	}
+
+	if fld.commas {
+		intPart = usingCommas(intPart)
+	}
+
+	sign := ""
+	switch {
+	case fld.plus && neg:
+		sign = "-"
+	case fld.plus:
+		sign = "+"
+	case neg && (fld.trailing == 0):
+		sign = "-"
+	}
+
+	if fld.dollar {
+		sign += "$"
+	}
+
+	width := fld.digits
+	if fld.plus {
+		width++
+	}
+
+	// a zero in front of the decimal point is dropped if there isn't room
+	if (intPart == "0") && fld.decimal && (len(sign)+1 > width) {
+		intPart = ""
+	}
+
+	res := sign + intPart
+	if fld.decimal {
+		res += "." + fracPart
+		width += 1 + fld.frac
+	}
+
+	if len(res) > width {
+		res = "%" + res
+	} else {
+		pad := " "
+		if fld.fill {
+			pad = "*"
+		}
+		res = strings.Repeat(pad, width-len(res)) + res
+	}
+
+	return res + fld.trailingSign(neg)
+}
+
+// formatExponent fits a number into a ^^^^ field
+func (fld usingField) formatExponent(val float64) string {
+	neg := val < 0
+	digits := fld.digits
+	sign := ""
+
+	switch {
+	case fld.plus && neg:
+		sign = "-"
+	case fld.plus:
+		sign = "+"
+	case fld.trailing != 0:
+	case digits > 0:
+		// without a sign field one position is kept for it
+		digits--
+		sign = " "
+		if neg {
+			sign = "-"
+		}
+	case neg:
+		sign = "%-"
+	}
+
+	mant, exp := usingMantissa(math.Abs(val), digits, fld.frac)
+
+	return sign + mant + "E" + usingExponent(exp) + fld.trailingSign(neg)
+}
+
+// trailingSign is the sign that follows the number, if there is one
+func (fld usingField) trailingSign(neg bool) string {
+	switch {
+	case fld.trailing == 0:
+		return ""
+	case neg:
+		return "-"
+	case fld.trailing == '+':
+		return "+"
+	}
+
+	return " "
+}
+
+// usingMantissa scales val so it has digits in front of the decimal point
+// returns the rounded mantissa and the exponent to go with it
+func usingMantissa(val float64, digits int, frac int) (string, int) {
+	if val == 0 {
+		mant := usingRound("0", frac)
+		if digits == 0 {
+			return strings.TrimPrefix(mant, "0"), 0
+		}
+		return strings.Repeat(" ", digits-1) + mant, 0
+	}
+
+	str := strconv.FormatFloat(val, 'e', -1, 64)
+	i := strings.IndexByte(str, 'e')
+	pwr, _ := strconv.Atoi(str[i+1:])
+	sig := strings.Replace(str[:i], ".", "", 1)
+
+	for {
+		for len(sig) < digits {
+			sig += "0"
+		}
+
+		mant := usingRound(sig[:digits]+"."+sig[digits:], frac)
+
+		// rounding up can add a digit, 9.99 becomes 10.0
+		if strings.IndexByte(mant+".", '.') <= digits {
+			return mant, pwr + 1 - digits
+		}
+
+		sig = "1"
+		pwr++
+	}
+}
+
+// usingExponent formats an exponent as a sign and at least two digits
+func usingExponent(exp int) string {
+	str := strconv.Itoa(exp)
+	sign := "+"
+	if exp < 0 {
+		sign = "-"
+		str = str[1:]
+	}
+
+	if len(str) < 2 {
+		str = "0" + str
+	}
+
+	return sign + str
+}
+
+// usingRound rounds a string of decimal digits to places after the
+// decimal point, halves round away from zero like GW-BASIC does
+func usingRound(num string, places int) string {
+	dot := strings.IndexByte(num, '.')
+	if dot == -1 {
+		dot = len(num)
+		num += "."
+	}
+	num += strings.Repeat("0", places+1)
+
+	digits := []byte(num[:dot] + num[dot+1:dot+1+places])
+	if num[dot+1+places] >= '5' {
+		i := len(digits) - 1
+		for ; (i >= 0) && (digits[i] == '9'); i-- {
+			digits[i] = '0'
+		}
+		if i < 0 {
+			digits = append([]byte{'1'}, digits...)
+			dot++
+		} else {
+			digits[i]++
+		}
+	}
+
+	if places == 0 {
+		return string(digits)
+	}
+
+	return string(digits[:dot]) + "." + string(digits[dot:])
+}
+
+// usingCommas puts a comma in front of every third digit
+func usingCommas(digits string) string {
+	var out strings.Builder
+
+	for i := range digits {
+		if (i > 0) && ((len(digits)-i)%3 == 0) {
+			out.WriteByte(',')
+		}
+		out.WriteByte(digits[i])
+	}
+
+	return out.String()
+}
+
+// usingValue gets the value of a numeric object
+// singles only keep the digits they are accurate to
+func usingValue(item object.Object) (float64, bool) {
+	switch val := item.(type) {
+	case *object.Integer:
+		return float64(val.Value), true
+	case *object.IntDbl:
+		return float64(val.Value), true
+	case *object.Fixed:
+		v, _ := val.Value.Float64()
+		return v, true
+	case *object.FloatSgl:
+		v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(val.Value), 'g', 7, 32), 64)
+		return v, true
+	case *object.FloatDbl:
+		return val.Value, true
+	}
+
+	return 0, false
+}
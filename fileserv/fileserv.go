@@ -2,11 +2,17 @@ package fileserv
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"unicode"
 
@@ -41,6 +47,13 @@ var (
 		"drived": flag.String("driveD", "/Users/don/Downloads/HCALC_129", "HamCalc source files"),
 		// TODO: add the rest of the possible drive letter flags
 	}
+	// there is no authentication, so drives stay read only unless asked
+	writable = map[string]*bool{
+		"drivea": flag.Bool("driveAWritable", false, "allow changes to drive A"),
+		"driveb": flag.Bool("driveBWritable", false, "allow changes to drive B"),
+		"drivec": flag.Bool("driveCWritable", false, "allow changes to drive C"),
+		"drived": flag.Bool("driveDWritable", false, "allow changes to drive D"),
+	}
 )
 
 // WrapFileSources builds mux routes to all my resources
@@ -71,15 +84,22 @@ func WrapFileSources(rtr *mux.Router) {
 
 	for key, drv := range drives {
 		if len(*drv) > 0 {
-			ndrv := strings.ToLower(*drv)
-			fs := &fileSource{src: http.Dir(ndrv)}
-			path := "/" + key
-			fs.fullyWrapSource(rtr, path)
-			fs.wrapSubDirs(rtr, *drv, path)
+			WrapDrive(rtr, key, strings.ToLower(*drv), !*writable[key])
 		}
 	}
 }
 
+// WrapDrive maps a drive, eg "drivec", onto the directory dir
+// so it can be read from and, unless readOnly, written to
+func WrapDrive(rtr *mux.Router, drive string, dir string, readOnly bool) {
+	fs := &fileSource{src: http.Dir(dir)}
+	path := "/" + strings.ToLower(drive)
+	wrapDriveWriter(rtr, path, dir, readOnly)
+	fs.fullyWrapSource(rtr, path)
+	fs.wrapSubDirs(rtr, dir, path)
+	fs.wrapNewDirs(rtr, path)
+}
+
 // given a path, create a handler function that will extract the
 // parts of the path and then call the source directory to work
 // on the file
@@ -114,6 +134,14 @@ func (fs *fileSource) fullyWrapSource(rtr *mux.Router, path string) {
 	fs.wrapSource(rtr, path+"/{file}", "text/plain; charset=ASCII")
 }
 
+// Directories made after start-up don't have routes of their own.
+// This catches anything below the drive that the other routes missed.
+func (fs *fileSource) wrapNewDirs(rtr *mux.Router, path string) {
+	rtr.PathPrefix(path + "/").Methods(http.MethodGet).HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fs.serveFile(rw, r, strings.TrimPrefix(r.URL.Path, path), "text/plain; charset=ASCII")
+	}).Name(path + " new dirs")
+}
+
 // After wrapping a directory, I want to wrap any sub-directories
 // he might have.
 func (fs *fileSource) wrapSubDirs(rtr *mux.Router, dir string, path string) {
@@ -285,6 +313,205 @@ func (f dotFileHidingFile) Readdir(n int) (fis []os.FileInfo, err error) {
 	return
 }
 
+// driveWriter handles the requests that change the contents of a drive
+type driveWriter struct {
+	root     string // directory the drive is mapped to
+	route    string // route prefix of the drive, eg /drivec
+	readOnly bool   // refuse all changes
+}
+
+// wrapDriveWriter adds the routes to create, delete and rename files on a drive
+// they have to be added ahead of the read routes, which accept any method
+//
+//	PUT    /driveC/program.bas             create or overwrite with the request body
+//	DELETE /driveC/program.bas             delete
+//	POST   /driveC/program.bas?to=new.bas  rename
+//	PUT    /driveC/subdir/                 make a directory
+//	DELETE /driveC/subdir/                 remove an empty directory
+func wrapDriveWriter(rtr *mux.Router, route string, root string, readOnly bool) {
+	dw := &driveWriter{root: root, route: route, readOnly: readOnly}
+	rtr.PathPrefix(route+"/").Methods(http.MethodPut, http.MethodDelete, http.MethodPost).Handler(dw).Name(route + " writer")
+}
+
+// ServeHTTP makes sure the change is allowed and then dispatches it
+func (dw *driveWriter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if dw.readOnly {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	target, ok := dw.localPath(strings.TrimPrefix(r.URL.Path, dw.route))
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// a trailing slash means the request is for a directory
+	dir := strings.HasSuffix(r.URL.Path, "/")
+
+	switch {
+	case dir && (r.Method == http.MethodPut):
+		dw.makeDir(w, target)
+	case dir && (r.Method == http.MethodDelete):
+		dw.removeDir(w, target)
+	case r.Method == http.MethodPut:
+		dw.putFile(w, r, target)
+	case r.Method == http.MethodDelete:
+		dw.deleteFile(w, target)
+	case r.Method == http.MethodPost:
+		dw.renameFile(w, r, target)
+	}
+}
+
+// localPath converts a path on the drive into a path on disk
+// dot files, and anything that would escape the drive root, are refused
+func (dw *driveWriter) localPath(name string) (string, bool) {
+	if containsDotFile(name) {
+		return "", false
+	}
+
+	// the root of the drive itself can't be changed
+	clean := path.Clean("/" + name)
+	if clean == "/" {
+		return "", false
+	}
+
+	return filepath.Join(dw.root, filepath.FromSlash(clean)), true
+}
+
+// maxUpload is the largest file a client can write, without a
+// limit anyone could make the server hold any amount in memory
+const maxUpload = 8 << 20
+
+// putFile creates or overwrites the file with the request body
+func (dw *driveWriter) putFile(w http.ResponseWriter, r *http.Request, target string) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxUpload))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rc := http.StatusNoContent
+	st, err := os.Stat(target)
+	if err != nil {
+		rc = http.StatusCreated
+	} else if st.IsDir() {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	err = ioutil.WriteFile(target, data, 0644)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	w.WriteHeader(rc)
+}
+
+// deleteFile removes the file, directories are left alone
+func (dw *driveWriter) deleteFile(w http.ResponseWriter, target string) {
+	st, err := os.Stat(target)
+	if (err == nil) && st.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = os.Remove(target)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// makeDir creates a new directory, its parent has to exist
+func (dw *driveWriter) makeDir(w http.ResponseWriter, target string) {
+	err := os.Mkdir(target, 0755)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// removeDir removes a directory, only if it is empty
+func (dw *driveWriter) removeDir(w http.ResponseWriter, target string) {
+	st, err := os.Stat(target)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	if !st.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	files, err := ioutil.ReadDir(target)
+	if (err != nil) || (len(files) > 0) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	err = os.Remove(target)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// renameFile moves the file to the name in the "to" parameter
+// the new name must be on the same drive and must not exist
+func (dw *driveWriter) renameFile(w http.ResponseWriter, r *http.Request, target string) {
+	dest, ok := dw.localPath(r.FormValue("to"))
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if _, err := os.Stat(target); err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	if _, err := os.Stat(dest); err == nil {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	err := os.Rename(target, dest)
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statusForError picks the http status that describes a file system error
+func statusForError(err error) int {
+	switch {
+	case os.IsNotExist(err):
+		return http.StatusNotFound
+	case os.IsExist(err):
+		return http.StatusConflict
+	case os.IsPermission(err):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
+
 /* *********************************************************************************************** */
 // Functions below here are used in the interpreter to request files from the file handlers defined above
 // and to work with the files to process them
@@ -302,6 +529,10 @@ func GetFile(file string, env *object.Environment) (*bufio.Reader, object.Object
 		return nil, err
 	}
 
+	if res.StatusCode == http.StatusNotFound {
+		return nil, object.StdError(env, berrors.FileNotFound)
+	}
+
 	if res.StatusCode != 200 {
 		e := object.StdError(env, berrors.ServerError)
 		e.Message = e.Message + fmt.Sprintf(" %d", res.StatusCode)
@@ -313,6 +544,105 @@ func GetFile(file string, env *object.Environment) (*bufio.Reader, object.Object
 	return rdr, nil
 }
 
+// PutFile writes a file to the remote server, creating or replacing it
+func PutFile(file string, data []byte, env *object.Environment) object.Object {
+	if len(file) == 0 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	// the only thing that can be missing is the directory
+	return sendChange(http.MethodPut, buildRequestURL(file, env), bytes.NewReader(data), berrors.PathNotFound, berrors.FileAlreadyExists, env)
+}
+
+// DeleteFile removes a file from the remote server
+func DeleteFile(file string, env *object.Environment) object.Object {
+	if len(file) == 0 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	return sendChange(http.MethodDelete, buildRequestURL(file, env), nil, berrors.FileNotFound, berrors.FileAlreadyExists, env)
+}
+
+// MakeDir creates a directory on the remote server
+func MakeDir(dir string, env *object.Environment) object.Object {
+	if len(dir) == 0 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	return sendChange(http.MethodPut, buildRequestURL(dir, env)+"/", nil, berrors.PathNotFound, berrors.PathFileAccess, env)
+}
+
+// RemoveDir removes an empty directory from the remote server
+func RemoveDir(dir string, env *object.Environment) object.Object {
+	if len(dir) == 0 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	return sendChange(http.MethodDelete, buildRequestURL(dir, env)+"/", nil, berrors.PathNotFound, berrors.PathFileAccess, env)
+}
+
+// RenameFile gives a file on the remote server a new name
+// both names have to be on the same drive
+func RenameFile(from string, to string, env *object.Environment) object.Object {
+	if (len(from) == 0) || (len(to) == 0) {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	cwd := GetCWD(env)
+	src := strings.SplitN(convertDrive(from, cwd), "/", 2)
+	dst := strings.SplitN(convertDrive(to, cwd), "/", 2)
+
+	if len(dst) < 2 {
+		return object.StdError(env, berrors.BadFileName)
+	}
+
+	if !strings.EqualFold(src[0], dst[0]) {
+		return object.StdError(env, berrors.RenameAcrossDisks)
+	}
+
+	rq := buildRequestURL(from, env) + "?to=" + url.QueryEscape(strings.ToLower(dst[1]))
+
+	return sendChange(http.MethodPost, rq, nil, berrors.FileNotFound, berrors.FileAlreadyExists, env)
+}
+
+// sendChange sends a request that modifies a file on the server
+// notFound is the error to report if the server can't find the target
+// conflict is the error to report if the target is in the way
+func sendChange(method string, rq string, body io.Reader, notFound int, conflict int, env *object.Environment) object.Object {
+	req, err := http.NewRequest(method, rq, body)
+	if err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+
+	t := env.Terminal()
+	if t != nil {
+		t.Log(method + " " + rq)
+	}
+
+	res, err := env.GetClient().Do(req)
+	if res == nil {
+		return &object.Error{Message: err.Error()}
+	}
+	if res.Body != nil {
+		res.Body.Close()
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return object.StdError(env, notFound)
+	case http.StatusForbidden:
+		return object.StdError(env, berrors.PermissionDenied)
+	case http.StatusConflict:
+		return object.StdError(env, conflict)
+	}
+
+	e := object.StdError(env, berrors.ServerError)
+	e.Message = e.Message + fmt.Sprintf(" %d", res.StatusCode)
+	return e
+}
+
 // execute a get via the current HTTPClient
 func sendRequest(rq string, env *object.Environment) (*http.Response, object.Object) {
 	res, err := env.GetClient().Get(rq)
@@ -335,11 +665,16 @@ func sendRequest(rq string, env *object.Environment) (*http.Response, object.Obj
 
 // build up a URL for addressing the target file
 func buildRequestURL(target string, env *object.Environment) string {
-	url := getURL(env)
 	cwd := GetCWD(env)
 	target = convertDrive(target, cwd)
 
-	return strings.ToLower(url + target)
+	// names can hold characters such as '#' that mean something in a URL
+	segs := strings.Split(strings.ToLower(target), "/")
+	for i := range segs {
+		segs[i] = url.PathEscape(segs[i])
+	}
+
+	return strings.ToLower(getURL(env)) + strings.Join(segs, "/")
 }
 
 // Get the URL of my server, he hides it in the HTML
@@ -369,7 +704,7 @@ func BuildFullPath(path string, env *object.Environment) string {
 		path = path + `\`
 	}
 	// is it a full path specification, case 1
-	if strings.EqualFold(path[1:3], ":\\") {
+	if (len(path) > 2) && strings.EqualFold(path[1:3], ":\\") {
 		return strings.ToLower(path)
 	}
 
@@ -538,8 +873,12 @@ func parseAsciiFile(inp *bufio.Reader, env *object.Environment) {
 func readLine(inp *bufio.Reader, env *object.Environment) bool {
 	bt, err := inp.ReadBytes(0x0a)
 
-	if len(bt) > 0 {
-		parseLine(string(bt), env)
+	// the line ending has to go, the parser takes an EOL
+	// followed by EOF to be the end of the program
+	// files saved by GW-BASIC end lines with CR/LF
+	line := strings.TrimRight(string(bt), "\r\n")
+	if len(line) > 0 {
+		parseLine(line, env)
 	}
 
 	if err != nil {
@@ -8,11 +8,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/navionguy/basicwasm/ast"
+	"github.com/navionguy/basicwasm/berrors"
 	"github.com/navionguy/basicwasm/gwtoken"
 	"github.com/navionguy/basicwasm/mocks"
 	"github.com/navionguy/basicwasm/object"
@@ -234,6 +237,146 @@ func Test_WrapFileSources(t *testing.T) {
 
 			assert.Nil(t, err, "http.Get got error")
 			assert.NotEmpty(t, res, "http.Get no body returned")
+
+			// drives can't be changed unless they are made writable
+			req, _ := http.NewRequest(http.MethodPut, ts.URL+"/drivec/never.bas", strings.NewReader("10 END"))
+			res, err = http.DefaultClient.Do(req)
+			if assert.Nil(t, err, "PUT got error") {
+				assert.Equal(t, http.StatusForbidden, res.StatusCode, "PUT to a read only drive")
+			}
+		}
+	}
+}
+
+func Test_WrapDrive(t *testing.T) {
+	root, err := ioutil.TempDir("", "drivee")
+	assert.Nil(t, err, "couldn't create temp dir")
+	defer os.RemoveAll(root)
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "sub", "prog.bas"), []byte("10 PRINT"), 0644)
+
+	tests := []struct {
+		method   string
+		path     string
+		readOnly bool
+		rc       int
+	}{
+		{method: http.MethodGet, path: "/drivee/sub/prog.bas", rc: http.StatusOK},
+		{method: http.MethodGet, path: "/drivee/", rc: http.StatusOK},
+		{method: http.MethodGet, path: "/drivee/none.bas", rc: http.StatusNotFound},
+		{method: http.MethodPut, path: "/drivee/new.bas", rc: http.StatusCreated},
+		{method: http.MethodPut, path: "/drivee/ro.bas", readOnly: true, rc: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		rt := mux.NewRouter()
+		WrapDrive(rt, "driveE", root, tt.readOnly)
+		ts := httptest.NewServer(rt)
+
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader("20 END"))
+		assert.Nil(t, err, "Build rqst failed")
+		res, err := http.DefaultClient.Do(req)
+		ts.Close()
+
+		if assert.Nilf(t, err, "%s %s failed", tt.method, tt.path) {
+			assert.Equalf(t, tt.rc, res.StatusCode, "%s %s wrong status", tt.method, tt.path)
+		}
+	}
+}
+
+func Test_DriveWriter(t *testing.T) {
+	root, err := ioutil.TempDir("", "drivet")
+	assert.Nil(t, err, "couldn't create temp dir")
+	defer os.RemoveAll(root)
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "old.bas"), []byte("old"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "taken.bas"), []byte("taken"), 0644)
+
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		readOnly bool
+		rc       int
+		file     string // file to check afterwards
+		exp      string // expected contents, empty if it shouldn't exist
+	}{
+		{method: http.MethodPut, path: "/drivet/new.bas", body: "10 PRINT", rc: http.StatusCreated, file: "new.bas", exp: "10 PRINT"},
+		{method: http.MethodPut, path: "/drivet/new.bas", body: "20 END", rc: http.StatusNoContent, file: "new.bas", exp: "20 END"},
+		{method: http.MethodPut, path: "/drivet/sub/prog.bas", body: "30 CLS", rc: http.StatusCreated, file: "sub/prog.bas", exp: "30 CLS"},
+		{method: http.MethodPut, path: "/drivet/nodir/prog.bas", body: "30 CLS", rc: http.StatusNotFound},
+		{method: http.MethodPut, path: "/drivet/sub", body: "30 CLS", rc: http.StatusForbidden},
+		{method: http.MethodPut, path: "/drivet/.hidden", body: "secret", rc: http.StatusForbidden, file: ".hidden"},
+		{method: http.MethodPut, path: "/drivet/ro.bas", body: "10 PRINT", readOnly: true, rc: http.StatusForbidden, file: "ro.bas"},
+		{method: http.MethodPut, path: "/drivet/big.dat", body: strings.Repeat("x", maxUpload+1), rc: http.StatusRequestEntityTooLarge, file: "big.dat"},
+		{method: http.MethodPut, path: "/drivet/max.dat", body: strings.Repeat("x", maxUpload), rc: http.StatusCreated},
+		{method: http.MethodPost, path: "/drivet/old.bas?to=renamed.bas", rc: http.StatusNoContent, file: "renamed.bas", exp: "old"},
+		{method: http.MethodPost, path: "/drivet/old.bas?to=other.bas", rc: http.StatusNotFound, file: "other.bas"},
+		{method: http.MethodPost, path: "/drivet/renamed.bas?to=taken.bas", rc: http.StatusConflict, file: "renamed.bas", exp: "old"},
+		{method: http.MethodPost, path: "/drivet/renamed.bas?to=.bashrc", rc: http.StatusForbidden, file: "renamed.bas", exp: "old"},
+		{method: http.MethodDelete, path: "/drivet/renamed.bas", rc: http.StatusNoContent, file: "renamed.bas"},
+		{method: http.MethodDelete, path: "/drivet/renamed.bas", rc: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/drivet/taken.bas", readOnly: true, rc: http.StatusForbidden, file: "taken.bas", exp: "taken"},
+		{method: http.MethodDelete, path: "/drivet/sub", rc: http.StatusNotFound},
+		{method: http.MethodPut, path: "/drivet/newdir/", rc: http.StatusCreated},
+		{method: http.MethodPut, path: "/drivet/newdir/", rc: http.StatusConflict},
+		{method: http.MethodPut, path: "/drivet/nodir/newdir/", rc: http.StatusNotFound},
+		{method: http.MethodPut, path: "/drivet/rodir/", readOnly: true, rc: http.StatusForbidden},
+		{method: http.MethodDelete, path: "/drivet/sub/", rc: http.StatusConflict, file: "sub/prog.bas", exp: "30 CLS"},
+		{method: http.MethodDelete, path: "/drivet/taken.bas/", rc: http.StatusNotFound, file: "taken.bas", exp: "taken"},
+		{method: http.MethodDelete, path: "/drivet/newdir/", rc: http.StatusNoContent},
+		{method: http.MethodDelete, path: "/drivet/newdir/", rc: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rt := mux.NewRouter()
+		wrapDriveWriter(rt, "/drivet", root, tt.readOnly)
+		ts := httptest.NewServer(rt)
+
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		assert.Nil(t, err, "Build rqst failed")
+		res, err := http.DefaultClient.Do(req)
+		ts.Close()
+
+		if assert.Nilf(t, err, "%s %s failed", tt.method, tt.path) {
+			assert.Equalf(t, tt.rc, res.StatusCode, "%s %s wrong status", tt.method, tt.path)
+		}
+
+		if len(tt.file) == 0 {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(tt.file)))
+		if len(tt.exp) == 0 {
+			assert.Truef(t, os.IsNotExist(err), "%s %s left %s", tt.method, tt.path, tt.file)
+			continue
+		}
+		assert.Equalf(t, tt.exp, string(data), "%s %s wrong contents", tt.method, tt.path)
+	}
+}
+
+func Test_LocalPath(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		ok  bool
+	}{
+		{inp: "/menu.bas", exp: "menu.bas", ok: true},
+		{inp: "/sub/menu.bas", exp: "sub/menu.bas", ok: true},
+		{inp: "//sub//menu.bas", exp: "sub/menu.bas", ok: true},
+		{inp: "/../etc/passwd"},
+		{inp: "/sub/.git"},
+		{inp: "/"},
+		{inp: ""},
+	}
+
+	dw := driveWriter{root: "/basic"}
+	for _, tt := range tests {
+		res, ok := dw.localPath(tt.inp)
+
+		assert.Equalf(t, tt.ok, ok, "localPath(%s)", tt.inp)
+		if tt.ok {
+			assert.Equalf(t, filepath.Join("/basic", filepath.FromSlash(tt.exp)), res, "localPath(%s)", tt.inp)
 		}
 	}
 }
@@ -489,6 +632,8 @@ func Test_BuildRequestURL(t *testing.T) {
 	}{
 		{"http://localhost:8080/", `C:\`, "menu1.bas", "http://localhost:8080/drivec/menu1.bas"},
 		{"http://localhost:8080/", `C:\`, `prog\menu1.bas`, "http://localhost:8080/drivec/prog/menu1.bas"},
+		{"http://localhost:8080/", `C:\`, "A#1.DAT", "http://localhost:8080/drivec/a%231.dat"},
+		{"http://localhost:8080/", `C:\`, `my dir\50%?.dat`, "http://localhost:8080/drivec/my%20dir/50%25%3F.dat"},
 	}
 
 	for _, tt := range tests {
@@ -561,6 +706,77 @@ func Test_GetFile(t *testing.T) {
 	}
 }
 
+func Test_FileChanges(t *testing.T) {
+	tests := []struct {
+		op     string
+		file   string
+		to     string
+		rs     int
+		method string
+		url    string
+		err    int
+	}{
+		{op: "put", file: `prog.bas`, method: http.MethodPut, url: "http://localhost:8080/drivec/prog.bas"},
+		{op: "put", file: `\menu\prog.bas`, rs: http.StatusCreated, method: http.MethodPut, url: "http://localhost:8080/drivec/menu/prog.bas"},
+		{op: "put", file: `nodir\prog.bas`, rs: http.StatusNotFound, err: berrors.PathNotFound},
+		{op: "put", file: ``, err: berrors.BadFileName},
+		{op: "put", file: `A#1.DAT`, method: http.MethodPut, url: "http://localhost:8080/drivec/a%231.dat"},
+		{op: "kill", file: `a:prog.bas`, rs: http.StatusNoContent, method: http.MethodDelete, url: "http://localhost:8080/drivea/prog.bas"},
+		{op: "kill", file: `prog.bas`, rs: http.StatusNotFound, err: berrors.FileNotFound},
+		{op: "kill", file: `prog.bas`, rs: http.StatusForbidden, err: berrors.PermissionDenied},
+		{op: "kill", file: ``, err: berrors.BadFileName},
+		{op: "kill", file: `A#1.DAT`, method: http.MethodDelete, url: "http://localhost:8080/drivec/a%231.dat"},
+		{op: "name", file: `old.bas`, to: `New.bas`, method: http.MethodPost, url: "http://localhost:8080/drivec/old.bas?to=new.bas"},
+		{op: "name", file: `old.bas`, to: `menu\new.bas`, method: http.MethodPost, url: "http://localhost:8080/drivec/old.bas?to=menu%2Fnew.bas"},
+		{op: "name", file: `old.bas`, to: `new.bas`, rs: http.StatusConflict, err: berrors.FileAlreadyExists},
+		{op: "name", file: `old.bas`, to: `new.bas`, rs: http.StatusTeapot, err: berrors.ServerError},
+		{op: "name", file: `old.bas`, to: `a:new.bas`, err: berrors.RenameAcrossDisks},
+		{op: "name", file: `old.bas`, to: ``, err: berrors.BadFileName},
+		{op: "name", file: `A#1.DAT`, to: `B#2.DAT`, method: http.MethodPost, url: "http://localhost:8080/drivec/a%231.dat?to=b%232.dat"},
+		{op: "mkdir", file: `newdir`, rs: http.StatusCreated, method: http.MethodPut, url: "http://localhost:8080/drivec/newdir/"},
+		{op: "mkdir", file: `newdir`, rs: http.StatusConflict, err: berrors.PathFileAccess},
+		{op: "mkdir", file: `nodir\newdir`, rs: http.StatusNotFound, err: berrors.PathNotFound},
+		{op: "rmdir", file: `b:\olddir`, rs: http.StatusNoContent, method: http.MethodDelete, url: "http://localhost:8080/driveb/olddir/"},
+		{op: "rmdir", file: `olddir`, rs: http.StatusConflict, err: berrors.PathFileAccess},
+		{op: "rmdir", file: `olddir`, rs: http.StatusForbidden, err: berrors.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		var trm object.Console
+		env := object.NewTermEnvironment(trm)
+		mc := &mocks.MockClient{StatusCode: tt.rs, Url: tt.url}
+		env.SetClient(mc)
+
+		var res object.Object
+		switch tt.op {
+		case "put":
+			res = PutFile(tt.file, []byte("10 END"), env)
+		case "kill":
+			res = DeleteFile(tt.file, env)
+		case "name":
+			res = RenameFile(tt.file, tt.to, env)
+		case "mkdir":
+			res = MakeDir(tt.file, env)
+		case "rmdir":
+			res = RemoveDir(tt.file, env)
+		}
+
+		if tt.err != 0 {
+			err, ok := res.(*object.Error)
+			if assert.Truef(t, ok, "%s %s didn't fail", tt.op, tt.file) {
+				assert.Equalf(t, tt.err, err.Code, "%s %s gave wrong error", tt.op, tt.file)
+			}
+			continue
+		}
+
+		assert.Nilf(t, res, "%s %s failed", tt.op, tt.file)
+		assert.Equal(t, tt.method, mc.Method)
+		if tt.op == "put" {
+			assert.Equal(t, "10 END", string(mc.Sent))
+		}
+	}
+}
+
 func Test_ParseFile(t *testing.T) {
 	tests := []struct {
 		inp   []byte
@@ -581,6 +797,8 @@ func Test_ParseFile(t *testing.T) {
 			0x6D, 0x2E, 0x22, 0x0A, 0x32, 0x30, 0x20, 0x50, 0x52, 0x49, 0x4E, 0x54,
 			0x20, 0x22, 0x53, 0x61, 0x76, 0x65, 0x64, 0x20, 0x61, 0x73, 0x20, 0x41,
 			0x53, 0x43, 0x49, 0x49, 0x2E, 0x22}, stmts: 4},
+		{inp: []byte("10 PRINT \"CR/LF\"\r\n20 END\r\n"), stmts: 4},
+		{inp: []byte("10 X = 1\n20 PRINT X\n"), stmts: 4},
 	}
 
 	for _, tt := range tests {
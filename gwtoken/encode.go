@@ -0,0 +1,492 @@
+package gwtoken
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/navionguy/basicwasm/mbf"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// address GW-BASIC loads the first program line at
+// the line links in a saved file are relative to this
+const progBase = 0x126e
+
+// a keyword and the bytes it crunches down to
+type keyword struct {
+	text string
+	toks []byte
+}
+
+// keywords sorted longest first, so the longest match wins
+var crunchTable = buildCrunchTable()
+
+// keywords that can be followed by line numbers
+var lineRefKeywords = map[string]bool{"GOTO": true, "GOSUB": true, "THEN": true, "ELSE": true, "RESTORE": true, "RESUME": true, "RUN": true}
+
+// buildCrunchTable runs every token value through the decoder
+// so that the encoder always produces what ParseFile expects
+func buildCrunchTable() []keyword {
+	found := make(map[string][]byte)
+
+	// paged tokens first, FIX is in both tables and belongs on the 0xff page
+	for _, page := range []byte{ff_TOK, fe_TOK, fd_TOK} {
+		for tok := 0x81; tok <= 0xff; tok++ {
+			addCrunchEntry(found, []byte{page, byte(tok)})
+		}
+	}
+
+	for tok := 0x81; tok < fd_TOK; tok++ {
+		addCrunchEntry(found, []byte{byte(tok)})
+	}
+
+	// these two get a colon in front of them
+	found["ELSE"] = []byte{':', else_TOK}
+	found["'"] = []byte{':', rem_TOK, ticrem_TOK}
+
+	var table []keyword
+	for k, v := range found {
+		table = append(table, keyword{text: k, toks: v})
+	}
+
+	sort.Slice(table, func(i, j int) bool {
+		if len(table[i].text) != len(table[j].text) {
+			return len(table[i].text) > len(table[j].text)
+		}
+		return table[i].text < table[j].text
+	})
+
+	return table
+}
+
+// addCrunchEntry decodes toks and records the text it produced
+func addCrunchEntry(found map[string][]byte, toks []byte) {
+	rdr := progRdr{src: bufio.NewReader(bytes.NewReader(toks))}
+	txt := rdr.readToken()
+
+	if len(txt) == 0 {
+		return
+	}
+
+	if _, ok := found[txt]; !ok {
+		found[txt] = toks
+	}
+}
+
+// EncodeFile crunches the lines of a program into GW-BASIC's tokenized format
+func EncodeFile(lines []string) []byte {
+	return append([]byte{TOKEN_FILE}, append(encodeProgram(lines), 0x1a)...)
+}
+
+// EncodeProtectedFile crunches the program and then encrypts it
+// the way GW-BASIC does for SAVE "file",P
+func EncodeProtectedFile(lines []string) []byte {
+	var pw protWriter
+
+	return append([]byte{PROTECTED_FILE}, append(pw.encryptBytes(encodeProgram(lines)), 0x1a)...)
+}
+
+// encodeProgram builds the linked list of crunched lines
+func encodeProgram(lines []string) []byte {
+	var out bytes.Buffer
+	addr := progBase
+
+	for _, line := range lines {
+		num, body, ok := splitLineNum(line)
+		if !ok {
+			continue
+		}
+
+		crunched := crunchLine(body)
+
+		// link to the next line, line number, the line and then a zero
+		addr += 4 + len(crunched) + 1
+		writeInt(&out, addr)
+		writeInt(&out, num)
+		out.Write(crunched)
+		out.WriteByte(eol_TOK)
+	}
+
+	// a zero link ends the program
+	writeInt(&out, 0)
+
+	return out.Bytes()
+}
+
+// splitLineNum pulls the line number off the front of the line
+func splitLineNum(line string) (int, string, bool) {
+	line = strings.TrimLeft(line, " \t")
+
+	i := 0
+	for i < len(line) && isDigit(line[i]) {
+		i++
+	}
+
+	num, err := strconv.Atoi(line[:i])
+	if (err != nil) || (num > 65529) {
+		return 0, "", false
+	}
+
+	// GW-BASIC drops the single space after the line number
+	body := strings.TrimRight(line[i:], " \r\n")
+	if strings.HasPrefix(body, " ") {
+		body = body[1:]
+	}
+
+	return num, body, true
+}
+
+// crunchLine converts the text of a line into tokens
+func crunchLine(line string) []byte {
+	var out bytes.Buffer
+	lineRef := false // true if numbers are line numbers
+
+	for i := 0; i < len(line); {
+		c := line[i]
+
+		switch {
+		case c == '"':
+			i = crunchString(&out, line, i)
+			lineRef = false
+		case isLetter(c) || (c == '\'') || strings.ContainsRune("+-*/^\\=<>", rune(c)):
+			kw, ok := matchKeyword(line[i:])
+			if !ok {
+				i = crunchName(&out, line, i)
+				lineRef = false
+				continue
+			}
+			i += len(kw.text)
+
+			switch kw.text {
+			case "REM", "'":
+				crunchRem(&out, kw)
+				out.Write(encodeText(line[i:]))
+				return out.Bytes()
+			case "DATA":
+				out.Write(kw.toks)
+				i = crunchData(&out, line, i)
+				continue
+			}
+
+			out.Write(kw.toks)
+			lineRef = lineRefKeywords[kw.text]
+		case isDigit(c) || ((c == '.') && (i+1 < len(line)) && isDigit(line[i+1])):
+			i = crunchNumber(&out, line, i, lineRef)
+		case (c == '&') && (i+1 < len(line)):
+			i = crunchRadix(&out, line, i)
+			lineRef = false
+		default:
+			out.Write(encodeText(string(c)))
+			if (c != ' ') && (c != ',') {
+				lineRef = false
+			}
+			i++
+		}
+	}
+
+	return out.Bytes()
+}
+
+// matchKeyword finds the longest keyword at the start of txt
+func matchKeyword(txt string) (keyword, bool) {
+	for _, kw := range crunchTable {
+		if (len(txt) >= len(kw.text)) && strings.EqualFold(txt[:len(kw.text)], kw.text) {
+			return kw, true
+		}
+	}
+
+	return keyword{}, false
+}
+
+// crunchRem writes the REM token
+// a colon followed by REM is how the decoder spots a ' so REM after a colon stays as text
+func crunchRem(out *bytes.Buffer, kw keyword) {
+	bt := out.Bytes()
+	if (kw.text == "REM") && (len(bt) > 0) && (bt[len(bt)-1] == ':') {
+		out.WriteString("REM")
+		return
+	}
+
+	out.Write(kw.toks)
+}
+
+// crunchString copies a quoted string, adding the closing quote if it is missing
+func crunchString(out *bytes.Buffer, line string, i int) int {
+	end := strings.IndexByte(line[i+1:], '"')
+	if end == -1 {
+		out.Write(encodeRaw(line[i:] + `"`))
+		return len(line)
+	}
+
+	out.Write(encodeRaw(line[i : i+end+2]))
+	return i + end + 2
+}
+
+// crunchName copies a variable name, letters digits and periods
+func crunchName(out *bytes.Buffer, line string, i int) int {
+	start := i
+	for i < len(line) && (isLetter(line[i]) || isDigit(line[i]) || (line[i] == '.')) {
+		i++
+	}
+
+	// stand alone operator characters that aren't keywords
+	if i == start {
+		i++
+	}
+
+	out.Write(encodeText(line[start:i]))
+	return i
+}
+
+// crunchData copies DATA items as text up to the end of the statement
+func crunchData(out *bytes.Buffer, line string, i int) int {
+	quoted := false
+	start := i
+
+	for ; i < len(line); i++ {
+		if line[i] == '"' {
+			quoted = !quoted
+		}
+		if !quoted && (line[i] == ':') {
+			break
+		}
+	}
+
+	out.Write(encodeText(line[start:i]))
+	return i
+}
+
+// crunchNumber writes a numeric constant
+// integers become binary integers, everything else becomes a
+// single or double precision MBF constant
+func crunchNumber(out *bytes.Buffer, line string, i int, lineRef bool) int {
+	start := i
+	for i < len(line) && isDigit(line[i]) {
+		i++
+	}
+	digits := line[start:i]
+
+	// check for any non-integer parts
+	end := i
+	for end < len(line) && strings.ContainsRune(".EeDd#!%", rune(line[end])) {
+		if (line[end] == 'E' || line[end] == 'e' || line[end] == 'D' || line[end] == 'd') && (end+1 < len(line)) && strings.ContainsRune("+-", rune(line[end+1])) {
+			end++
+		}
+		end++
+		for end < len(line) && isDigit(line[end]) {
+			end++
+		}
+	}
+
+	if end == i {
+		val, err := strconv.Atoi(digits)
+		switch {
+		case (err == nil) && lineRef && (val <= 65529):
+			out.WriteByte(lineNum_TOK)
+			writeInt(out, val)
+			return i
+		case (err == nil) && (val <= math.MaxInt16):
+			writeIntConst(out, val)
+			return i
+		}
+	}
+
+	num := line[start:end]
+	if !crunchFloat(out, num) {
+		out.WriteString(num)
+	}
+	return end
+}
+
+// crunchFloat writes num as an MBF constant
+// 7 or fewer digits is single precision, more digits, a D exponent
+// or a # makes it double, returns false if num can't be encoded
+func crunchFloat(out *bytes.Buffer, num string) bool {
+	mant := strings.TrimRight(num, "#!%")
+	suffix := num[len(mant):]
+	if len(suffix) > 1 {
+		return false
+	}
+
+	if suffix == "%" {
+		val, err := strconv.ParseInt(mant, 10, 16)
+		if (err != nil) || (val < 0) {
+			return false
+		}
+		writeIntConst(out, int(val))
+		return true
+	}
+
+	exp := strings.IndexAny(mant, "EeDd")
+	digits := mant
+	if exp != -1 {
+		digits = mant[:exp]
+	}
+
+	val, err := strconv.ParseFloat(strings.NewReplacer("D", "E", "d", "E").Replace(mant), 64)
+	if err != nil {
+		return false
+	}
+
+	dbl := (suffix == "#") || strings.ContainsAny(mant, "Dd")
+	if (suffix == "") && (exp == -1) && (countDigits(digits) > 7) {
+		dbl = true
+	}
+
+	if dbl {
+		bts, ok := mbf.EncodeDouble(val)
+		if !ok {
+			return false
+		}
+		out.WriteByte(flt8Byte_TOK)
+		out.Write(bts)
+		return true
+	}
+
+	bts, ok := mbf.EncodeSingle(float32(val))
+	if !ok {
+		return false
+	}
+	out.WriteByte(flt4Byte_TOK)
+	out.Write(bts)
+	return true
+}
+
+// countDigits counts the digits in a constant, leading zeros don't count
+func countDigits(num string) int {
+	digits := 0
+	for _, ch := range num {
+		if !isDigit(byte(ch)) || ((ch == '0') && (digits == 0)) {
+			continue
+		}
+		digits++
+	}
+
+	return digits
+}
+
+// writeIntConst uses the smallest encoding for the integer
+func writeIntConst(out *bytes.Buffer, val int) {
+	switch {
+	case val < 8:
+		out.WriteByte(byte(const0_TOK + val))
+	case val < 10:
+		out.WriteByte(byte(const0_TOK + val + 1))
+	case val < 256:
+		out.WriteByte(int1Byte_TOK)
+		out.WriteByte(byte(val))
+	default:
+		out.WriteByte(int2Byte_TOK)
+		writeInt(out, val)
+	}
+}
+
+// crunchRadix handles &H and &O constants
+func crunchRadix(out *bytes.Buffer, line string, i int) int {
+	tok := byte(hex_TOK)
+	base := 16
+	digits := "0123456789ABCDEFabcdef"
+
+	switch line[i+1] {
+	case 'H', 'h':
+	case 'O', 'o':
+		tok, base, digits = oct_TOK, 8, "01234567"
+	default:
+		out.WriteByte('&')
+		return i + 1
+	}
+
+	end := i + 2
+	for end < len(line) && strings.ContainsRune(digits, rune(line[end])) {
+		end++
+	}
+
+	val, err := strconv.ParseUint(line[i+2:end], base, 16)
+	if err != nil {
+		out.WriteString(line[i:end])
+		return end
+	}
+
+	out.WriteByte(tok)
+	writeInt(out, int(val))
+	return end
+}
+
+// encodeText copies text that is outside of quotes
+// the decoder would read anything but printable ASCII as a token
+func encodeText(txt string) []byte {
+	var bts []byte
+
+	for _, r := range txt {
+		switch {
+		case r < ' ':
+			bts = append(bts, ' ')
+		case r > '~':
+			bts = append(bts, '?')
+		default:
+			bts = append(bts, byte(r))
+		}
+	}
+
+	return bts
+}
+
+// encodeRaw converts quoted text into code page 437 bytes
+func encodeRaw(txt string) []byte {
+	var bts []byte
+
+	for _, r := range txt {
+		bt, ok := charmap.CodePage437.EncodeRune(r)
+		if !ok {
+			bt = '?'
+		}
+		bts = append(bts, bt)
+	}
+
+	return bts
+}
+
+// writes a 16bit integer in little endian notation
+func writeInt(out *bytes.Buffer, val int) {
+	out.WriteByte(byte(val))
+	out.WriteByte(byte(val >> 8))
+}
+
+func isLetter(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// protWriter applies basic's obfuscation to a protected program
+type protWriter struct {
+	protReader
+}
+
+func (pw *protWriter) encryptBytes(bts []byte) []byte {
+	enc := make([]byte, len(bts))
+
+	for i, bt := range bts {
+		enc[i] = pw.encryptByte(bt)
+	}
+
+	return enc
+}
+
+// encryptByte is decryptByte run backwards
+func (pw *protWriter) encryptByte(bt byte) byte {
+	bt = (((bt - addBytesKey2[pw.addKey2Index]) ^ xorBytesKey2[pw.xorKey2Index]) ^ xorBytesKey1[pw.xorKey1Index]) + subBytesKey1[pw.subKey1Index]
+
+	pw.subKey1Index = pw.advIndex(pw.subKey1Index, 11)
+	pw.xorKey1Index = pw.advIndex(pw.xorKey1Index, 11)
+	pw.xorKey2Index = pw.advIndex(pw.xorKey2Index, 13)
+	pw.addKey2Index = pw.advIndex(pw.addKey2Index, 13)
+
+	return bt
+}
@@ -0,0 +1,160 @@
+package gwtoken
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/navionguy/basicwasm/mocks"
+	"github.com/navionguy/basicwasm/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// decodes an encoded program back into its lines
+func decodeLines(src basReader) []string {
+	var lines []string
+	rdr := progRdr{src: src}
+
+	for {
+		rdr.readLineHeader()
+		if rdr.linenum == 0 {
+			return lines
+		}
+		rdr.readLine(nil)
+		lines = append(lines, rdr.lineInp)
+	}
+}
+
+func Test_EncodeFile(t *testing.T) {
+	tests := []struct {
+		inp []string
+		exp []byte
+	}{
+		{inp: []string{}, exp: []byte{TOKEN_FILE, 0x00, 0x00, 0x1a}},
+		{inp: []string{`10 PRINT "Hi!"`}, exp: []byte{TOKEN_FILE, 0x7a, 0x12, 0x0a, 0x00, print_TOK, ' ', '"', 'H', 'i', '!', '"', 0x00, 0x00, 0x00, 0x1a}},
+		{inp: []string{"20 GOTO 10"}, exp: []byte{TOKEN_FILE, 0x78, 0x12, 0x14, 0x00, goto_TOK, ' ', lineNum_TOK, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x1a}},
+		{inp: []string{"bad line"}, exp: []byte{TOKEN_FILE, 0x00, 0x00, 0x1a}},
+	}
+
+	for _, tt := range tests {
+		res := EncodeFile(tt.inp)
+
+		assert.Equal(t, tt.exp, res, "EncodeFile(%v)", tt.inp)
+	}
+}
+
+func Test_CrunchLine(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp []byte
+	}{
+		{inp: "X=5", exp: []byte{'X', eq_TOK, const5_TOK}},
+		{inp: "X=9", exp: []byte{'X', eq_TOK, const9_TOK}},
+		{inp: "X=200", exp: []byte{'X', eq_TOK, int1Byte_TOK, 200}},
+		{inp: "X=1000", exp: []byte{'X', eq_TOK, int2Byte_TOK, 0xe8, 0x03}},
+		{inp: "X=40000", exp: []byte{'X', eq_TOK, flt4Byte_TOK, 0x00, 0x40, 0x1c, 0x90}},
+		{inp: "X=1.5", exp: []byte{'X', eq_TOK, flt4Byte_TOK, 0x00, 0x00, 0x40, 0x81}},
+		{inp: "X=1.5#", exp: []byte{'X', eq_TOK, flt8Byte_TOK, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x81}},
+		{inp: "X=1.5D+0", exp: []byte{'X', eq_TOK, flt8Byte_TOK, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x81}},
+		{inp: "X=12345678", exp: []byte{'X', eq_TOK, flt8Byte_TOK, 0x00, 0x00, 0x00, 0x00, 0x4e, 0x61, 0x3c, 0x98}},
+		{inp: "X=5%", exp: []byte{'X', eq_TOK, const5_TOK}},
+		{inp: "X=1E+39", exp: []byte{'X', eq_TOK, '1', 'E', '+', '3', '9'}},
+		{inp: "X=.5", exp: []byte{'X', eq_TOK, flt4Byte_TOK, 0x00, 0x00, 0x00, 0x80}},
+		{inp: "X=2E3", exp: []byte{'X', eq_TOK, flt4Byte_TOK, 0x00, 0x00, 0x7a, 0x8b}},
+		{inp: "X=&HFF", exp: []byte{'X', eq_TOK, hex_TOK, 0xff, 0x00}},
+		{inp: "X=&O17", exp: []byte{'X', eq_TOK, oct_TOK, 0x0f, 0x00}},
+		{inp: "X=&", exp: []byte{'X', eq_TOK, '&'}},
+		{inp: "ON X GOSUB 10, 20", exp: []byte{on_TOK, ' ', 'X', ' ', gosub_TOK, ' ', lineNum_TOK, 10, 0, ',', ' ', lineNum_TOK, 20, 0}},
+		{inp: "IF X THEN 10 ELSE 20", exp: []byte{if_TOK, ' ', 'X', ' ', then_TOK, ' ', lineNum_TOK, 10, 0, ' ', ':', else_TOK, ' ', lineNum_TOK, 20, 0}},
+		{inp: "print left$(a$,2)", exp: []byte{print_TOK, ' ', ff_TOK, 0x81, '(', 'a', '$', ',', const2_TOK, ')'}},
+		{inp: "REM 10 PRINT", exp: []byte{rem_TOK, ' ', '1', '0', ' ', 'P', 'R', 'I', 'N', 'T'}},
+		{inp: "CLS ' clear", exp: []byte{cls_TOK, ' ', ':', rem_TOK, ticrem_TOK, ' ', 'c', 'l', 'e', 'a', 'r'}},
+		{inp: "CLS:REM", exp: []byte{cls_TOK, ':', 'R', 'E', 'M'}},
+		{inp: `DATA 1, "A:B":PRINT`, exp: []byte{data_TOK, ' ', '1', ',', ' ', '"', 'A', ':', 'B', '"', ':', print_TOK}},
+		{inp: `PRINT "open`, exp: []byte{print_TOK, ' ', '"', 'o', 'p', 'e', 'n', '"'}},
+		{inp: "PRINT\tX1", exp: []byte{print_TOK, ' ', 'X', '1'}},
+	}
+
+	for _, tt := range tests {
+		res := crunchLine(tt.inp)
+
+		assert.Equal(t, tt.exp, res, "crunchLine(%s)", tt.inp)
+	}
+}
+
+func Test_EncodeRoundTrip(t *testing.T) {
+	prog := []string{
+		`10 REM test program`,
+		`20 DIM A$(10): FOR I = 1 TO 10: A$(I) = MID$("ABCDEFGHIJ", I, 1): NEXT I`,
+		`30 IF INKEY$ = "" THEN 30 ELSE PRINT "ok" ' done`,
+		`40 ON ERROR GOTO 100`,
+		`50 PRINT USING "##.##"; 3.14159, X# * 2, &H1F, &O17`,
+		`60 DATA 1, 2, "three", four`,
+		`70 X = 32767: Y = 255: Z = 8 MOD 3`,
+		`80 LOCATE 1, 1: COLOR 7, 0: PRINT CHR$(219)`,
+		`90 WHILE X > 0: X = X \ 2: WEND`,
+		`100 RESUME NEXT`,
+	}
+
+	res := EncodeFile(prog)
+	assert.Equal(t, byte(TOKEN_FILE), res[0])
+	src := bufio.NewReader(bytes.NewReader(res[1:]))
+	assert.Equal(t, prog, decodeLines(src), "plain round trip")
+
+	res = EncodeProtectedFile(prog)
+	assert.Equal(t, byte(PROTECTED_FILE), res[0])
+	pr := protReader{src: bufio.NewReader(bytes.NewReader(res[1:]))}
+	assert.Equal(t, prog, decodeLines(&pr), "protected round trip")
+}
+
+// numeric constants come back as text that encodes to the same bytes
+func Test_EncodeConstants(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		tok byte
+	}{
+		{inp: `10 X = 3.25`, exp: `10 X = 3.25`, tok: flt4Byte_TOK},
+		{inp: `10 X = 65536`, exp: `10 X = 65536`, tok: flt4Byte_TOK},
+		{inp: `10 X = 2.5E-10`, exp: `10 X = 2.5E-10`, tok: flt4Byte_TOK},
+		{inp: `10 X = 1234567`, exp: `10 X = 1234567`, tok: flt4Byte_TOK},
+		{inp: `10 X# = 12345678`, exp: `10 X# = 12345678#`, tok: flt8Byte_TOK},
+		{inp: `10 X# = 3.14159265`, exp: `10 X# = 3.14159265#`, tok: flt8Byte_TOK},
+		{inp: `10 X# = 1.5D+30`, exp: `10 X# = 1.5D+30`, tok: flt8Byte_TOK},
+		{inp: `10 X# = .1#`, exp: `10 X# = .1#`, tok: flt8Byte_TOK},
+		{inp: `10 X# = 65536#`, exp: `10 X# = 65536#`, tok: flt8Byte_TOK},
+	}
+
+	for _, tt := range tests {
+		res := EncodeFile([]string{tt.inp})
+		assert.Containsf(t, string(res), string([]byte{eq_TOK, ' ', tt.tok}), "%s didn't encode to a binary constant", tt.inp)
+
+		lines := decodeLines(bufio.NewReader(bytes.NewReader(res[1:])))
+		if assert.Lenf(t, lines, 1, "%s didn't decode", tt.inp) {
+			assert.Equal(t, tt.exp, lines[0], "%s decoded wrong", tt.inp)
+			assert.Equal(t, res, EncodeFile(lines), "%s changed on a second save", tt.inp)
+		}
+	}
+}
+
+func Test_EncodeParse(t *testing.T) {
+	prog := []string{`10 A = 1E5: B$ = "Hi": PRINT A; B$`, `20 END`}
+
+	tests := []struct {
+		inp   []byte
+		parse func(*bufio.Reader, *object.Environment)
+	}{
+		{inp: EncodeFile(prog), parse: ParseFile},
+		{inp: EncodeProtectedFile(prog), parse: ParseProtectedFile},
+	}
+
+	for _, tt := range tests {
+		var mt mocks.MockTerm
+		mocks.InitMockTerm(&mt)
+		env := object.NewTermEnvironment(mt)
+
+		tt.parse(bufio.NewReader(bytes.NewReader(tt.inp)), env)
+
+		assert.Equal(t, 6, env.StatementIter().Len(), "encoded program parsed wrong")
+	}
+}
@@ -2,11 +2,11 @@ package gwtoken
 
 import (
 	"bufio"
-	"encoding/binary"
 	"fmt"
-	"math"
+	"strings"
 
 	"github.com/navionguy/basicwasm/lexer"
+	"github.com/navionguy/basicwasm/mbf"
 	"github.com/navionguy/basicwasm/object"
 	"github.com/navionguy/basicwasm/parser"
 )
@@ -718,45 +718,18 @@ func (rdr *progRdr) read8ByteFloat() string {
 		return "0"
 	}
 
-	/* MS Binary Format                                             */
-	/* byte order =>    m7 | m6 | m5 | m4 | m3 | m2 | m1 | exponent */
-	/* m1 is most significant byte => smmm|mmmm                     */
-	/* m7 is the least significant byte                             */
-	/*      m = mantissa byte                                       */
-	/*      s = sign bit                                            */
-	/*      b = bit                                                 */
-
-	if bts[7] == 0 {
+	flt, _ := mbf.DecodeDouble(bts)
+	if flt == 0 {
 		return "0"
 	}
 
-	sign := bts[6] & 0x80 /* 1000|0000b  */
-
-	/* IEEE Single Precision Float Format                           */
-	/*  byte 8    byte 7    byte 6    byte 5    byte 4    and so on */
-	/* seee|eeee eeee|mmmm mmmm|mmmm mmmm|mmmm mmmm|mmmm ...        */
-	/*          s = sign bit                                        */
-	/*          e = exponent bit                                    */
-	/*          m = mantissa bit                                    */
-
-	ieee_exp := int(bts[7]) - 128 - 1 + 1023
-
-	bts[7] = sign | byte(ieee_exp>>4)
-
-	for i := 6; i > 0; i-- {
-		bts[i] = bts[i] << 1
-		bts[i] |= bts[i-1] >> 7
+	// the # keeps it double when it is read back in
+	str := (&object.FloatDbl{Value: flt}).Inspect()
+	if !strings.Contains(str, "D") {
+		str += "#"
 	}
-	bts[0] = bts[0] << 1
 
-	for i := 0; i < 6; i++ {
-		bts[i] = (bts[i] >> 4) | (bts[i+1]&0x0f)<<4
-	}
-	bts[6] = (bts[6] >> 4) | (byte(ieee_exp)&0x0f)<<4
-
-	flt := math.Float64frombits(binary.LittleEndian.Uint64(bts))
-
-	return fmt.Sprintf("%E", flt)
+	return str
 }
 
 func (rdr *progRdr) read4ByteFloat() string {
@@ -766,32 +739,13 @@ func (rdr *progRdr) read4ByteFloat() string {
 	if (err != nil) || (n < 4) {
 		return "0"
 	}
-	/* MS Binary Format                         */
-	/* byte order =>    m3 | m2 | m1 | exponent */
-	/* m1 is most significant byte => sbbb|bbbb */
-	/* m3 is the least significant byte         */
-	/*      m = mantissa byte                   */
-	/*      s = sign bit                        */
-	/*      b = bit                             */
-
-	if bts[3] == 0 {
+
+	flt, _ := mbf.DecodeSingle(bts)
+	if flt == 0 {
 		return "0"
 	}
 
-	/* IEEE Single Precision Float Format       */
-	/*    m3        m2        m1     exponent   */
-	/* mmmm|mmmm mmmm|mmmm emmm|mmmm seee|eeee  */
-	/*          s = sign bit                    */
-	/*          e = exponent bit                */
-	/*          m = mantissa bit                */
-
-	sign := bts[2] & 0x80 /* 1000|0000b  */
-	ieee_exp := bts[3] - 2
-	bts[3] = sign | ieee_exp>>1
-	bts[2] = (bts[2] & 0x7f) | (ieee_exp << 7)
-	flt := math.Float32frombits(binary.LittleEndian.Uint32(bts))
-
-	return fmt.Sprintf("%E", flt)
+	return (&object.FloatSgl{Value: flt}).Inspect()
 }
 
 // protReader hides an inner bufio.Reader and
@@ -810,7 +764,8 @@ func (pr *protReader) Peek(n int) ([]byte, error) {
 	xorKey2 := pr.xorKey2Index
 	addKey2 := pr.addKey2Index
 
-	bt = pr.decryptBytes(bt)
+	// decrypt a copy so the buffered bytes are still encrypted when read
+	bt = pr.decryptBytes(append([]byte{}, bt...))
 
 	pr.subKey1Index = savSub1
 	pr.xorKey1Index = xorKey1
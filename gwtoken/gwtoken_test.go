@@ -162,7 +162,7 @@ func Test_ReadProtProg(t *testing.T) {
 		stmts int
 	}{
 		{inp: []byte{0xCD, 0xA9, 0xBF, 0x54, 0xE2, 0x12, 0xBD, 0x59, 0x20, 0x65, 0x0D, 0x8F, 0xA2, 0x30, 0x98, 0xD3, 0x3E, 0xD3, 0xF1, 0xE6, 0x13, 0xA4}, stmts: 2},
-		{inp: []byte{0xCB, 0xA9, 0xBF, 0x54, 0xE2, 0x12, 0xBD, 0x59, 0x1C, 0x18, 0x7B, 0x02, 0xC8, 0x87, 0x78, 0xC5, 0x19, 0xCF, 0x94, 0x74, 0x87, 0xA4, 0x6C, 0x03}, stmts: 4},
+		{inp: []byte{0xCB, 0xA9, 0xBF, 0x54, 0xE2, 0x12, 0xBD, 0x59, 0x1C, 0x18, 0x7B, 0x02, 0xC8, 0x87, 0x78, 0xC5, 0x19, 0xCF, 0x94, 0x74, 0x87, 0xA4, 0x6C, 0x03}, stmts: 3},
 	}
 
 	for _, tt := range tests {
@@ -530,9 +530,9 @@ func Test_Read4ByteFloat(t *testing.T) {
 	}{
 		{inp: []byte{0x00, 0x00}, exp: "0"},
 		{inp: []byte{0x00, 0x00, 0x00, 0x00}, exp: "0"},
-		{inp: []byte{0x09, 0xF6, 0x45, 0x71}, exp: "2.359880E-05"},
-		{inp: []byte{0x40, 0xF6, 0x45, 0x71}, exp: "2.359890E-05"},
-		{inp: []byte{0x2F, 0xFD, 0x6B, 0x88}, exp: "2.359890E+02"},
+		{inp: []byte{0x09, 0xF6, 0x45, 0x71}, exp: "2.35988E-05"},
+		{inp: []byte{0x40, 0xF6, 0x45, 0x71}, exp: "2.35989E-05"},
+		{inp: []byte{0x2F, 0xFD, 0x6B, 0x88}, exp: "235.989"},
 	}
 
 	for _, tt := range tests {
@@ -549,9 +549,9 @@ func Test_Read8ByteFloat(t *testing.T) {
 	}{
 		{inp: []byte{0x00, 0x00}, exp: "0"},
 		{inp: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, exp: "0"},
-		{inp: []byte{0xB1, 0xAE, 0x1C, 0x84, 0x8C, 0xE0, 0x12, 0x6D}, exp: "1.094320E-06"},
-		{inp: []byte{0x2B, 0xD4, 0xF2, 0x79, 0x40, 0xF6, 0x45, 0x71}, exp: "2.359890E-05"},
-		{inp: []byte{0x77, 0xBE, 0x9F, 0x1A, 0x2F, 0xFD, 0x6B, 0x88}, exp: "2.359890E+02"},
+		{inp: []byte{0xB1, 0xAE, 0x1C, 0x84, 0x8C, 0xE0, 0x12, 0x6D}, exp: ".00000109432#"},
+		{inp: []byte{0x2B, 0xD4, 0xF2, 0x79, 0x40, 0xF6, 0x45, 0x71}, exp: ".0000235989#"},
+		{inp: []byte{0x77, 0xBE, 0x9F, 0x1A, 0x2F, 0xFD, 0x6B, 0x88}, exp: "235.989#"},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/navionguy/basicwasm/ast"
@@ -26,20 +28,68 @@ const (
 	f14Key = "1b5b42" // cursor down
 )
 
+// scan codes 0 to 0x35 mapped to the character they send
+// the second string is the same keys with shift held down
+const (
+	scanKeys      = "\x00\x1b1234567890-=\b\tqwertyuiop[]\r\x00asdfghjkl;'`\x00\\zxcvbnm,./"
+	scanShiftKeys = "\x00\x1b!@#$%^&*()_+\b\tQWERTYUIOP{}\r\x00ASDFGHJKL:\"~\x00|ZXCVBNM<>?"
+)
+
+// shift flags used when defining keys 15-20
+const (
+	shiftRight = 0x01
+	shiftLeft  = 0x02
+	ctrlFlag   = 0x04
+	altFlag    = 0x08
+	capsLock   = 0x40
+)
+
+// function keys in order, F1 is trapped as KEY(1) and so on
+var fkeys = []string{f1Key, f2Key, f3Key, f4Key, f5Key, f6Key, f7Key, f8Key, f9Key, f10Key, f11Key, f12Key, f13Key, f14Key}
+
+// escape sequences mapped to the scan code GW-BASIC sends after a zero byte
+var extKeys = map[string]byte{
+	f1Key:      0x3b,
+	f2Key:      0x3c,
+	f3Key:      0x3d,
+	f4Key:      0x3e,
+	f5Key:      0x3f,
+	f6Key:      0x40,
+	f7Key:      0x41,
+	f8Key:      0x42,
+	f9Key:      0x43,
+	f10Key:     0x44,
+	f11Key:     0x48, // cursor up
+	f12Key:     0x4b, // cursor left
+	f13Key:     0x4d, // cursor right
+	f14Key:     0x50, // cursor down
+	"1b5b48":   0x47, // home
+	"1b4f48":   0x47,
+	"1b5b317e": 0x47,
+	"1b5b46":   0x4f, // end
+	"1b4f46":   0x4f,
+	"1b5b347e": 0x4f,
+	"1b5b357e": 0x49, // page up
+	"1b5b367e": 0x51, // page down
+	"1b5b327e": 0x52, // insert
+	"1b5b337e": 0x53, // delete
+}
+
 type KeyBuffer struct {
 	KeySettings *ast.KeySettings
+	KeyTraps    *ast.KeyTraps
 	keycodes    chan ([]byte)
 	inp         []byte
 	ind         int
 	sig_break   bool
 	spcKeys     map[string]string
+	trapMu      sync.Mutex
+	trapped     [21]bool
 }
 
 var kbuff KeyBuffer
 
 func GetKeyBuffer() *KeyBuffer {
-	fkeys := []string{f1Key, f2Key, f3Key, f4Key, f5Key, f6Key, f7Key, f8Key, f9Key, f10Key, f11Key, f12Key, f13Key, f14Key}
-
 	if kbuff.spcKeys == nil {
 		kbuff.spcKeys = make(map[string]string)
 	}
@@ -59,6 +109,11 @@ func (buff *KeyBuffer) SaveKeyStroke(key []byte) {
 		buff.keycodes = make(chan []byte, 20)
 	}
 
+	// trapped keys never reach the program
+	if buff.checkForTrap(key) {
+		return
+	}
+
 	// check for an escape sequence, like a function key
 	if (len(key) > 1) && (key[0] == 0x1b) {
 		// go see if maps to something have a macro set for
@@ -87,18 +142,129 @@ func (buff *KeyBuffer) checkForCtrlC(inp []byte) {
 }
 
 // check for special keys
+// a key with a macro sends the macro, otherwise it sends its extended code
 func (buff *KeyBuffer) checkForSpecialKeys(inp []byte) []byte {
-	if buff.KeySettings == nil {
-		// no macros have been set
-		return []byte("")
+	if buff.KeySettings != nil {
+		// convert the bytes to a string for checking
+		a := kbuff.spcKeys[hex.EncodeToString(inp)]
+
+		// map the key label to the string to send and return it
+		if mac := buff.KeySettings.Keys[a]; len(mac) > 0 {
+			return []byte(mac)
+		}
+	}
+
+	return []byte(ExtendedKey(inp))
+}
+
+// ExtendedKey converts the escape sequence for a cursor or function key
+// into the CHR$(0) + scan code string GW-BASIC returns for it
+// returns empty string if the key isn't one I know
+func ExtendedKey(inp []byte) string {
+	scan, ok := extKeys[hex.EncodeToString(inp)]
+	if !ok {
+		return ""
+	}
+
+	return string([]byte{0, scan})
+}
+
+// checkForTrap flags a key that has an ON KEY trap turned on or stopped
+func (buff *KeyBuffer) checkForTrap(inp []byte) bool {
+	if buff.KeyTraps == nil {
+		return false
+	}
+
+	n := buff.trapKeyNum(inp)
+	if (n == 0) || (buff.KeyTraps.Keys[n].State == ast.TrapOff) {
+		return false
+	}
+
+	buff.trapMu.Lock()
+	buff.trapped[n] = true
+	buff.trapMu.Unlock()
+
+	return true
+}
+
+// trapKeyNum figures out the KEY(n) number for a keystroke
+// returns 0 if it isn't a key that can be trapped
+func (buff *KeyBuffer) trapKeyNum(inp []byte) int {
+	code := hex.EncodeToString(inp)
+	for i, key := range fkeys {
+		if key == code {
+			return i + 1
+		}
+	}
+
+	for i := 15; i < len(buff.KeyTraps.Defs); i++ {
+		if (len(buff.KeyTraps.Defs[i]) > 0) && (buff.KeyTraps.Defs[i] == string(inp)) {
+			return i
+		}
 	}
 
-	// convert the bytes to a string for checking
-	a := kbuff.spcKeys[hex.EncodeToString(inp)]
-	mac := kbuff.KeySettings.Keys[a]
+	return 0
+}
+
+// TrapSeen reports if key n has been pressed since the last time I was asked
+func (buff *KeyBuffer) TrapSeen(n int) bool {
+	buff.trapMu.Lock()
+	defer buff.trapMu.Unlock()
 
-	// map the key label to the string to send and return it
-	return []byte(mac)
+	seen := buff.trapped[n]
+	buff.trapped[n] = false
+
+	return seen
+}
+
+// ScanCodeKeys converts the shift flags and scan code of a user defined
+// key into the characters the terminal sends for it
+// returns empty string for keys the terminal can't tell me about
+func ScanCodeKeys(flags byte, scan byte) string {
+	switch scan {
+	case 0x39:
+		return " "
+	case 0x48:
+		return decodeKey(f11Key)
+	case 0x4b:
+		return decodeKey(f12Key)
+	case 0x4d:
+		return decodeKey(f13Key)
+	case 0x50:
+		return decodeKey(f14Key)
+	}
+
+	// no way to see the alt key
+	if (int(scan) >= len(scanKeys)) || (scanKeys[scan] == 0) || (flags&altFlag != 0) {
+		return ""
+	}
+
+	ch := scanKeys[scan]
+	if flags&(shiftRight|shiftLeft) != 0 {
+		ch = scanShiftKeys[scan]
+	}
+
+	isLetter := strings.IndexByte("abcdefghijklmnopqrstuvwxyz", scanKeys[scan]) != -1
+	if isLetter && (flags&capsLock != 0) {
+		// caps lock flips the case of letters
+		ch ^= 0x20
+	}
+
+	if flags&ctrlFlag != 0 {
+		if !isLetter {
+			return ""
+		}
+		ch &= 0x1f
+	}
+
+	return string([]byte{ch})
+}
+
+// decodeKey turns one of my escape sequence constants back into bytes
+func decodeKey(key string) string {
+	bts, _ := hex.DecodeString(key)
+
+	return string(bts)
 }
 
 // has a Ctrl-C been entered
@@ -112,6 +278,26 @@ func (buff *KeyBuffer) ClearBreak() {
 	buff.sig_break = false
 }
 
+// ReadKey returns the next keystroke without waiting for one
+// extended keys come back as both bytes, empty string if nothing is waiting
+func (buff *KeyBuffer) ReadKey() string {
+	bt, err := buff.ReadByte()
+	if err != nil {
+		return ""
+	}
+
+	if bt != 0 {
+		return string([]byte{bt})
+	}
+
+	scan, err := buff.ReadByte()
+	if err != nil {
+		return string([]byte{bt})
+	}
+
+	return string([]byte{bt, scan})
+}
+
 // ReadByte returns the next byte, caller has to decide if he needs more
 func (buff *KeyBuffer) ReadByte() (byte, error) {
 	// if I'm working a byte array keep going
@@ -53,6 +53,44 @@ func Test_FuncKeys(t *testing.T) {
 
 }
 
+func Test_ExtendedKeys(t *testing.T) {
+	tests := []struct {
+		inp []byte
+		exp []string
+	}{
+		{inp: []byte{0x1b, 0x5b, 0x41}, exp: []string{"\x00H"}},
+		{inp: []byte{0x1b, 0x5b, 0x44}, exp: []string{"\x00K"}},
+		{inp: []byte{0x1b, 0x4f, 0x50}, exp: []string{"\x00;"}},
+		{inp: []byte{0x1b, 0x5b, 0x32, 0x31, 0x7e}, exp: []string{"\x00D"}},
+		{inp: []byte{0x1b, 0x5b, 0x48}, exp: []string{"\x00G"}},
+		{inp: []byte{0x1b, 0x5b, 0x34, 0x7e}, exp: []string{"\x00O"}},
+		{inp: []byte{0x1b, 0x5b, 0x36, 0x7e}, exp: []string{"\x00Q"}},
+		{inp: []byte{0x1b, 0x5b, 0x33, 0x7e}, exp: []string{"\x00S"}},
+		{inp: []byte{0x1b, 0x5b, 0x5a}, exp: []string{""}},
+		{inp: []byte("ab"), exp: []string{"a", "b", ""}},
+	}
+
+	for _, tt := range tests {
+		buff := new(KeyBuffer)
+		buff.SaveKeyStroke(tt.inp)
+
+		for _, exp := range tt.exp {
+			assert.Equal(t, exp, buff.ReadKey(), "ReadKey() after %q", tt.inp)
+		}
+	}
+
+	// a function key sends its macro, if it has one
+	buff := new(KeyBuffer)
+	buff.KeySettings = &ast.KeySettings{Keys: map[string]string{"F1": "LIST", "F2": ""}}
+	GetKeyBuffer()
+	buff.SaveKeyStroke([]byte{0x1b, 0x4f, 0x50})
+	buff.SaveKeyStroke([]byte{0x1b, 0x4f, 0x51})
+	for _, exp := range "LIST" {
+		assert.Equal(t, string(exp), buff.ReadKey(), "ReadKey() of F1 macro")
+	}
+	assert.Equal(t, "\x00<", buff.ReadKey(), "ReadKey() of F2 without a macro")
+}
+
 func Test_SawBreak(t *testing.T) {
 	var tt []byte
 	tt = append(tt, 0x03)
@@ -109,3 +147,67 @@ func Test_EarlyRead(t *testing.T) {
 		assert.Failf(t, "An early ReadByte return %b", string([]byte{bt}))
 	}
 }
+
+func Test_KeyTraps(t *testing.T) {
+	tests := []struct {
+		inp     []byte
+		state   int
+		key     int
+		trapped bool
+	}{
+		{inp: []byte{0x1b, 0x4f, 0x50}, state: ast.TrapOn, key: 1, trapped: true},
+		{inp: []byte{0x1b, 0x5b, 0x32, 0x31, 0x7e}, state: ast.TrapStop, key: 10, trapped: true},
+		{inp: []byte{0x1b, 0x5b, 0x42}, state: ast.TrapOn, key: 14, trapped: true},
+		{inp: []byte("P"), state: ast.TrapOn, key: 15, trapped: true},
+		{inp: []byte("p"), state: ast.TrapOn, key: 15},
+		{inp: []byte("P"), state: ast.TrapOff, key: 15},
+	}
+
+	for _, tt := range tests {
+		buff := new(KeyBuffer)
+		buff.KeyTraps = &ast.KeyTraps{}
+		buff.KeyTraps.Keys[tt.key].State = tt.state
+		buff.KeyTraps.Defs[15] = "P"
+
+		buff.SaveKeyStroke(tt.inp)
+
+		assert.Equal(t, tt.trapped, buff.TrapSeen(tt.key), "TrapSeen(%d) after %q", tt.key, tt.inp)
+		assert.False(t, buff.TrapSeen(tt.key), "TrapSeen(%d) didn't clear", tt.key)
+
+		// trapped keys don't get passed along
+		_, err := buff.ReadByte()
+		assert.Equal(t, tt.trapped, err != nil, "ReadByte after %q", tt.inp)
+	}
+}
+
+func Test_ScanCodeKeys(t *testing.T) {
+	tests := []struct {
+		flags byte
+		scan  byte
+		exp   string
+	}{
+		{scan: 0x1e, exp: "a"},
+		{flags: shiftLeft, scan: 0x1e, exp: "A"},
+		{flags: shiftRight | shiftLeft, scan: 0x19, exp: "P"},
+		{flags: capsLock, scan: 0x10, exp: "Q"},
+		{flags: capsLock | shiftLeft, scan: 0x10, exp: "q"},
+		{flags: ctrlFlag, scan: 0x2e, exp: "\x03"},
+		{flags: ctrlFlag, scan: 0x02},
+		{flags: altFlag, scan: 0x1e},
+		{scan: 0x02, exp: "1"},
+		{flags: shiftRight, scan: 0x02, exp: "!"},
+		{scan: 0x01, exp: "\x1b"},
+		{scan: 0x1c, exp: "\r"},
+		{scan: 0x39, exp: " "},
+		{scan: 0x48, exp: "\x1b[A"},
+		{flags: 0x80, scan: 0x4b, exp: "\x1b[D"},
+		{scan: 0x4d, exp: "\x1b[C"},
+		{scan: 0x50, exp: "\x1b[B"},
+		{scan: 0x1d},
+		{scan: 0x70},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, ScanCodeKeys(tt.flags, tt.scan), "ScanCodeKeys(%x, %x)", tt.flags, tt.scan)
+	}
+}
@@ -84,6 +84,11 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '.':
+		// a number can start with its decimal point
+		if isDigit(l.peekChar()) {
+			tok.Type, tok.Literal = l.readNumber()
+			return tok
+		}
 		tok = newToken(token.PERIOD, l.ch)
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
@@ -129,6 +134,8 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case '\\':
 		tok = newToken(token.BSLASH, l.ch)
+	case '^':
+		tok = newToken(token.CARET, l.ch)
 	case '"':
 		literal := l.readString()
 		tok = token.Token{Type: token.STRING, Literal: literal}
@@ -209,8 +216,11 @@ func (l *Lexer) readNumber() (token.TokenType, string) {
 				l.readChar()
 			}
 		case '#':
+			// a # ends the number and makes it double precision
 			_, tt = l.chgType(tt, token.INT, token.INTD)
+			_, tt = l.chgType(tt, token.FIXED, token.INTD)
 			l.readChar()
+			err = true
 		default:
 			err = true
 		}
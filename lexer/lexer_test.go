@@ -138,6 +138,18 @@ func TestNextToken(t *testing.T) {
 	assert.Zero(t, l.peekChar(), "peekChar failed to return zero")
 }
 
+func TestOperators(t *testing.T) {
+	l := New("2^3 and NOT x Or y XOR z EQV w imp v")
+
+	exp := []token.TokenType{token.EOL, token.INT, token.CARET, token.INT, token.AND, token.NOT, token.IDENT,
+		token.OR, token.IDENT, token.XOR, token.IDENT, token.EQV, token.IDENT, token.IMP, token.IDENT, token.EOF}
+
+	for i, tt := range exp {
+		tk := l.NextToken()
+		assert.Equalf(t, tt, tk.Type, "token %d wrong", i)
+	}
+}
+
 func TestPassOnOff(t *testing.T) {
 	inp := "a test message"
 
@@ -160,6 +172,8 @@ func TestReadNumber(t *testing.T) {
 		{"235.988E-7", token.FLOAT},
 		{"235D-12", token.FLOAT},
 		{"12#", token.INTD},
+		{"1.5#", token.INTD},
+		{".0000235989#", token.INTD},
 	}
 
 	for _, tt := range tests {
@@ -179,6 +193,27 @@ func TestReadNumber(t *testing.T) {
 	}
 }
 
+func TestLeadingDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		tok   token.TokenType
+		lit   string
+	}{
+		{".5", token.FIXED, ".5"},
+		{".25E-3", token.FLOAT, ".25E-3"},
+		{".X", token.PERIOD, "."},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		l.NextToken() // skip the starting EOL
+
+		tk := l.NextToken()
+		assert.Equal(t, tt.tok, tk.Type, "%s gave the wrong token type", tt.input)
+		assert.Equal(t, tt.lit, tk.Literal, "%s gave the wrong literal", tt.input)
+	}
+}
+
 func TestLineNumbers(t *testing.T) {
 	input := `
 	10
@@ -0,0 +1,123 @@
+package mbf
+
+import "math"
+
+/* Microsoft Binary Format                                      */
+/* byte order =>  lowest mantissa byte ... m1 | exponent        */
+/* m1 is the most significant mantissa byte => smmm|mmmm        */
+/*      s = sign bit                                            */
+/*      m = mantissa bit, the leading one bit is implied        */
+/* the value is 0.1mmm...  * 2^(exponent-128)                   */
+/* an exponent of zero means the value is zero                  */
+
+const (
+	bias     = 128
+	sglBytes = 4 // 24 bit mantissa
+	dblBytes = 8 // 56 bit mantissa
+)
+
+// DecodeSingle converts 4 bytes of MBF into a float32
+// returns false if there aren't enough bytes
+func DecodeSingle(bts []byte) (float32, bool) {
+	val, ok := decode(bts, sglBytes)
+
+	return float32(val), ok
+}
+
+// DecodeDouble converts 8 bytes of MBF into a float64
+// returns false if there aren't enough bytes
+func DecodeDouble(bts []byte) (float64, bool) {
+	return decode(bts, dblBytes)
+}
+
+// EncodeSingle converts val into 4 bytes of MBF
+// numbers too small to hold become zero, numbers too large
+// return false along with the largest value it can hold
+func EncodeSingle(val float32) ([]byte, bool) {
+	return encode(float64(val), sglBytes)
+}
+
+// EncodeDouble converts val into 8 bytes of MBF
+// numbers too small to hold become zero, numbers too large
+// return false along with the largest value it can hold
+func EncodeDouble(val float64) ([]byte, bool) {
+	return encode(val, dblBytes)
+}
+
+// decode works for either size, the exponent is always the last byte
+func decode(bts []byte, size int) (float64, bool) {
+	if len(bts) < size {
+		return 0, false
+	}
+
+	exp := int(bts[size-1])
+	if exp == 0 {
+		return 0, true
+	}
+
+	// put back the implied leading bit
+	var mant uint64
+	for i := size - 2; i >= 0; i-- {
+		bt := bts[i]
+		if i == size-2 {
+			bt |= 0x80
+		}
+		mant = mant<<8 | uint64(bt)
+	}
+
+	val := math.Ldexp(float64(mant), exp-bias-(size-1)*8)
+	if bts[size-2]&0x80 != 0 {
+		val = -val
+	}
+
+	return val, true
+}
+
+// encode works for either size
+func encode(val float64, size int) ([]byte, bool) {
+	bts := make([]byte, size)
+	bits := uint((size - 1) * 8)
+
+	if (val == 0) || math.IsNaN(val) {
+		return bts, true
+	}
+
+	var sign byte
+	if val < 0 {
+		sign = 0x80
+		val = -val
+	}
+
+	if math.IsInf(val, 0) {
+		val = math.MaxFloat64
+	}
+
+	// Frexp gives back 0.1mmm... just like MBF wants
+	frac, exp := math.Frexp(val)
+	mant := uint64(math.Round(math.Ldexp(frac, int(bits))))
+
+	// rounding can carry into a new bit
+	if mant == 1<<bits {
+		mant >>= 1
+		exp++
+	}
+
+	if exp+bias < 1 {
+		return bts, true
+	}
+
+	ok := true
+	if exp+bias > 0xff {
+		mant = 1<<bits - 1
+		exp = 0xff - bias
+		ok = false
+	}
+
+	for i := 0; i < size-1; i++ {
+		bts[i] = byte(mant >> (8 * uint(i)))
+	}
+	bts[size-2] = (bts[size-2] & 0x7f) | sign
+	bts[size-1] = byte(exp + bias)
+
+	return bts, ok
+}
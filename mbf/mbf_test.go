@@ -0,0 +1,88 @@
+package mbf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Single(t *testing.T) {
+	tests := []struct {
+		val float32
+		bts []byte
+	}{
+		{val: 0, bts: []byte{0, 0, 0, 0}},
+		{val: 1, bts: []byte{0, 0, 0, 0x81}},
+		{val: 0.5, bts: []byte{0, 0, 0, 0x80}},
+		{val: -1, bts: []byte{0, 0, 0x80, 0x81}},
+		{val: 10, bts: []byte{0, 0, 0x20, 0x84}},
+		{val: 65999, bts: []byte{0x80, 0xe7, 0x00, 0x91}},
+		{val: 0.1, bts: []byte{0xcd, 0xcc, 0x4c, 0x7d}},
+		{val: 2.35989e-05, bts: []byte{0x40, 0xf6, 0x45, 0x71}},
+		{val: 235.989, bts: []byte{0x2f, 0xfd, 0x6b, 0x88}},
+		{val: -3.5e-20, bts: []byte{0x67, 0x48, 0xa5, 0x40}},
+	}
+
+	for _, tt := range tests {
+		bts, ok := EncodeSingle(tt.val)
+		assert.True(t, ok, "EncodeSingle(%g) ok", tt.val)
+		assert.Equal(t, tt.bts, bts, "EncodeSingle(%g)", tt.val)
+
+		val, ok := DecodeSingle(tt.bts)
+		assert.True(t, ok, "DecodeSingle(% x) ok", tt.bts)
+		assert.Equal(t, tt.val, val, "DecodeSingle(% x)", tt.bts)
+	}
+}
+
+func Test_Double(t *testing.T) {
+	tests := []struct {
+		val float64
+		bts []byte
+	}{
+		{val: 0, bts: []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{val: 1, bts: []byte{0, 0, 0, 0, 0, 0, 0, 0x81}},
+		{val: -12, bts: []byte{0, 0, 0, 0, 0, 0, 0xc0, 0x84}},
+		{val: 0.1, bts: []byte{0xd0, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x4c, 0x7d}},
+		{val: math.Pi, bts: []byte{0xc0, 0x68, 0x21, 0xa2, 0xda, 0x0f, 0x49, 0x82}},
+	}
+
+	for _, tt := range tests {
+		bts, ok := EncodeDouble(tt.val)
+		assert.True(t, ok, "EncodeDouble(%g) ok", tt.val)
+		assert.Equal(t, tt.bts, bts, "EncodeDouble(%g)", tt.val)
+
+		val, ok := DecodeDouble(tt.bts)
+		assert.True(t, ok, "DecodeDouble(% x) ok", tt.bts)
+		assert.Equal(t, tt.val, val, "DecodeDouble(% x)", tt.bts)
+	}
+}
+
+func Test_OutOfRange(t *testing.T) {
+	// too large for MBF gives back the largest value
+	bts, ok := EncodeDouble(1e39)
+	assert.False(t, ok, "EncodeDouble(1e39) ok")
+	assert.Equal(t, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff}, bts, "EncodeDouble(1e39)")
+
+	_, ok = EncodeSingle(3e38)
+	assert.False(t, ok, "EncodeSingle(3e38) ok")
+
+	bts, ok = EncodeSingle(float32(math.Inf(-1)))
+	assert.False(t, ok, "EncodeSingle(-Inf) ok")
+	assert.Equal(t, []byte{0xff, 0xff, 0xff, 0xff}, bts, "EncodeSingle(-Inf)")
+
+	// too small turns into zero
+	bts, ok = EncodeDouble(1e-40)
+	assert.True(t, ok, "EncodeDouble(1e-40) ok")
+	assert.Equal(t, make([]byte, 8), bts, "EncodeDouble(1e-40)")
+
+	// MBF goes lower than IEEE singles without going denormal
+	val, ok := DecodeSingle([]byte{0, 0, 0, 1})
+	assert.True(t, ok, "DecodeSingle smallest ok")
+	assert.Equal(t, float32(math.Ldexp(0.5, -127)), val, "DecodeSingle smallest")
+
+	_, ok = DecodeSingle([]byte{0, 0, 0x81})
+	assert.False(t, ok, "DecodeSingle with too few bytes")
+	_, ok = DecodeDouble([]byte{0, 0, 0, 0, 0, 0x81})
+	assert.False(t, ok, "DecodeDouble with too few bytes")
+}
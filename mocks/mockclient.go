@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"strings"
 )
@@ -12,6 +13,19 @@ type MockClient struct {
 	Url        string // Url to validate
 	Err        error  // Error to return on call
 	StatusCode int    // Status code to return
+	Method     string // method of the last request passed to Do
+	Sent       []byte // body of the last request passed to Do
+}
+
+// Do records the request and then responds like Get
+func (mc *MockClient) Do(req *http.Request) (*http.Response, error) {
+	mc.Method = req.Method
+	mc.Sent = nil
+	if req.Body != nil {
+		mc.Sent, _ = ioutil.ReadAll(req.Body)
+	}
+
+	return mc.Get(req.URL.String())
 }
 
 func (mc *MockClient) Get(url string) (*http.Response, error) {
@@ -37,6 +37,7 @@ type MockTerm struct {
 	SawCls   *bool
 	SawBeep  *bool
 	SawBreak *bool
+	SawWidth *int
 	ExpMsg   *Expector
 }
 
@@ -96,6 +97,12 @@ func (mt MockTerm) Log(msg string) {
 func (mt MockTerm) Locate(int, int) {
 }
 
+func (mt MockTerm) SetWidth(cols int) {
+	if mt.SawWidth != nil {
+		*mt.SawWidth = cols
+	}
+}
+
 func (mt MockTerm) GetCursor() (int, int) {
 	return *mt.Row, *mt.Col
 }
@@ -118,17 +125,25 @@ func (mt MockTerm) ReadKeys(count int) []byte {
 
 	bt := []byte(*mt.StrVal)
 
+	// consume the keys that get returned
 	if count >= len(bt) {
-		mt.StrVal = nil
+		*mt.StrVal = ""
 		return bt
 	}
 
-	v := (*mt.StrVal)[:count]
-	mt.StrVal = &v
+	*mt.StrVal = (*mt.StrVal)[count:]
 
 	return bt[:count]
 }
 
+func (mt MockTerm) InKey() string {
+	if (mt.StrVal != nil) && (len(*mt.StrVal) > 1) && ((*mt.StrVal)[0] == 0) {
+		return string(mt.ReadKeys(2))
+	}
+
+	return string(mt.ReadKeys(1))
+}
+
 func (mt MockTerm) BreakCheck() bool {
 	if mt.SawBreak == nil {
 		return false
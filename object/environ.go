@@ -1,13 +1,16 @@
 package object
 
 import (
-	"math/rand"
+	"math"
 	"net/http"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/navionguy/basicwasm/ast"
 	"github.com/navionguy/basicwasm/berrors"
 	"github.com/navionguy/basicwasm/keybuffer"
+	"github.com/navionguy/basicwasm/mbf"
 	"github.com/navionguy/basicwasm/settings"
 	"golang.org/x/text/encoding/charmap"
 )
@@ -48,6 +51,22 @@ const (
 // size of arrays that haven't been DIM'd
 const DefaultDimSize = 10
 
+// ScreenDevice is the device name WIDTH uses for the screen
+const ScreenDevice = "SCRN:"
+
+// NoWrap is the line width that means lines are never broken up
+const NoWrap = 255
+
+// power on line widths of the devices WIDTH knows about
+var deviceWidths = map[string]int{
+	ScreenDevice: 80,
+	"LPT1:":      80,
+	"LPT2:":      80,
+	"LPT3:":      80,
+	"COM1:":      NoWrap,
+	"COM2:":      NoWrap,
+}
+
 // Console defines how to collect input and display output
 type Console interface {
 	// Cls clears the screen contents
@@ -67,6 +86,10 @@ type Console interface {
 	Read(col, row, len int) string
 	// ReadKeys reads up to (count) keycode values
 	ReadKeys(count int) []byte
+	// InKey returns the next keystroke without waiting, empty if none
+	InKey() string
+	// SetWidth switches the screen to 40 or 80 columns
+	SetWidth(int)
 	// SoundBell emits facsimile of a console beep
 	SoundBell()
 	// BreakCheck returns true if a ctrl-c was entere
@@ -75,38 +98,51 @@ type Console interface {
 
 // HttpClient allows me to mock an http.Client, minimally
 type HttpClient interface {
-	//Do(req *http.Request) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 	Get(url string) (*http.Response, error)
 }
 
 // file access modes
 const (
-	inputFile = 1
-	outputFile
-	rndFile
-	apndFile
+	InputFile = iota + 1
+	OutputFile
+	RandomFile
+	AppendFile
 )
 
+// MaxFiles is the highest file number a program can open
+const MaxFiles = 15
+
+// MaxRecLen is the longest record a random file can have
+const MaxRecLen = 32767
+
+// MaxRecord is the highest record number GET and PUT accept
+const MaxRecord = 16777215
+
 // Environment holds my variables and possibly an outer environment
 type Environment struct {
-	ForLoops []ForBlock           // any For Loops that are active
-	store    map[string]*variable // variables and other program data
-	common   map[string]*variable // variables that live through a CHAIN
-	files    map[int16]*aFile     // currently open files by file number
-	dir      map[string]*aFile    // locally cached files by full name
-	settings map[string]ast.Node  // environment settings
-	readOnly map[string]bool      // my read only environment variables
-	outer    *Environment         // possibly a tempory containing environment
-	program  *ast.Program         // current Abstract Syntax Tree
-	term     Console              // the terminal console object
+	ForLoops   []ForBlock            // any For Loops that are active
+	WhileLoops []WhileBlock          // any While Loops that are active
+	store      map[string]*variable  // variables and other program data
+	common     map[string]*variable  // variables that live through a CHAIN
+	files      map[int16]*FileHandle // currently open files by file number
+	settings   map[string]ast.Node   // environment settings
+	readOnly   map[string]bool       // my read only environment variables
+	outer      *Environment          // possibly a tempory containing environment
+	program    *ast.Program          // current Abstract Syntax Tree
+	term       Console               // the terminal console object
 
 	// The following hold "state" information controlled by commands/statements
-	client  HttpClient     // for making server requests
-	rnd     *rand.Rand     // random number generator
-	rndVal  float32        // most recent generated value
-	run     bool           // program is currently executing, if false, a command is executing
-	stack   []ast.RetPoint // return addresses for GOSUB/RETURN
-	traceOn bool           // is tracing turned on
+	base    int16            // OPTION BASE, the lowest array subscript
+	client  HttpClient       // for making server requests
+	clock   func() time.Time // source of the time of day, nil uses the system clock
+	clkAdj  time.Duration    // DATE$ and TIME$ statements move the clock by this much
+	defs    [26]byte         // type character DEFINT and friends gave each starting letter
+	rndSeed uint32           // random number generator state, 24 bits
+	run     bool             // program is currently executing, if false, a command is executing
+	stack   []ast.RetPoint   // return addresses for GOSUB/RETURN
+	traceOn bool             // is tracing turned on
+	widths  map[string]int   // line widths WIDTH has given the screen and devices
 }
 
 type variable struct {
@@ -124,8 +160,6 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 // NewEnvironment creates a place to store variables and settings
 func newEnvironment() *Environment {
 	e := &Environment{settings: make(map[string]ast.Node)}
-	e.dir = make(map[string]*aFile)
-	e.files = make(map[int16]*aFile)
 	e.ClearCommon()
 	e.CloseAllFiles()
 	e.ClearVars()
@@ -137,8 +171,7 @@ func newEnvironment() *Environment {
 	e.setReadOnlys()
 
 	// initialize my random number generator
-	e.rnd = rand.New(rand.NewSource(37))
-	e.rndVal = e.rnd.Float32()
+	e.ClearRandom()
 	dc := http.DefaultClient
 	e.SetClient(dc)
 	return e
@@ -229,11 +262,10 @@ func (e *Environment) Get(name string) Object {
 
 	// check for my special case
 	if strings.EqualFold(name, "INKEY$") {
-		bt, err := keybuffer.GetKeyBuffer().ReadByte()
-		if err != nil {
+		if e.term == nil {
 			return &String{Value: ""}
 		}
-		return &String{Value: string(bt)}
+		return &String{Value: e.term.InKey()}
 	}
 
 	// am I in an enclosed environment?
@@ -247,18 +279,14 @@ func (e *Environment) Get(name string) Object {
 
 // Variable isn't in memory, create it with correct default value
 func (e *Environment) getDefaultValue(name string) Object {
-	// check for type indicators
-	// if single char name, no type default to integer
-	if len(name) == 1 {
-		return &Integer{Value: 0}
-	}
-
 	// it *may* have a type
 	switch e.getType(name) {
 	case '$': // string
 		return &String{Value: ""}
-	case '%', '!': // single precesion
+	case '%': // integer
 		return &Integer{Value: 0}
+	case '!': // single precesion
+		return &FloatSgl{Value: 0}
 	case '#': // double precision
 		return &IntDbl{Value: 0}
 	case ']': // array of something
@@ -276,7 +304,7 @@ func (e *Environment) getDefaultValue(name string) Object {
 func (e *Environment) buildDefaultArray(name string) Object {
 	def := Array{TypeID: "[]"}
 
-	for i := 0; i < DefaultDimSize; i++ {
+	for i := e.OptionBase(); i <= DefaultDimSize; i++ {
 		def.Elements = append(def.Elements, e.getDefaultValue(name))
 	}
 
@@ -284,7 +312,7 @@ func (e *Environment) buildDefaultArray(name string) Object {
 }
 
 // determine type for variable
-// TODO: implement defined type name ranges eg. DEFDBL
+// without a type character, DEFINT and friends decide
 func (e *Environment) getType(name string) byte {
 
 	if len(name) > 1 {
@@ -295,9 +323,161 @@ func (e *Environment) getType(name string) byte {
 		}
 	}
 
+	if def := e.DefType(name); len(def) > 0 {
+		return def[0]
+	}
+
 	return 0x00
 }
 
+// SetDefType makes typeid the default type for variables starting
+// with the letters first through last
+func (e *Environment) SetDefType(first, last byte, typeid string) {
+	if e.outer != nil {
+		e.outer.SetDefType(first, last, typeid)
+		return
+	}
+
+	for l := unicode.ToUpper(rune(first)); l <= unicode.ToUpper(rune(last)); l++ {
+		if (l >= 'A') && (l <= 'Z') {
+			e.defs[l-'A'] = typeid[0]
+		}
+	}
+}
+
+// DefType returns the type character of variables starting with the
+// same letter as name, single precision unless DEFINT and friends
+// said otherwise, "" if name doesn't start with a letter
+func (e *Environment) DefType(name string) string {
+	if e.outer != nil {
+		return e.outer.DefType(name)
+	}
+
+	if len(name) == 0 {
+		return ""
+	}
+
+	l := unicode.ToUpper(rune(name[0]))
+	if (l < 'A') || (l > 'Z') {
+		return ""
+	}
+	if e.defs[l-'A'] == 0 {
+		return "!"
+	}
+
+	return string(e.defs[l-'A'])
+}
+
+// ClearDefTypes forgets all the DEFtype statements
+func (e *Environment) ClearDefTypes() {
+	if e.outer != nil {
+		e.outer.ClearDefTypes()
+		return
+	}
+
+	e.defs = [26]byte{}
+}
+
+// DeviceWidth returns the line width of a device such as "LPT1:"
+// returns false if there is no such device
+func (e *Environment) DeviceWidth(dev string) (int, bool) {
+	if e.outer != nil {
+		return e.outer.DeviceWidth(dev)
+	}
+
+	dev = strings.ToUpper(dev)
+	if w, ok := e.widths[dev]; ok {
+		return w, true
+	}
+
+	w, ok := deviceWidths[dev]
+	return w, ok
+}
+
+// SetDeviceWidth changes the line width of a device
+// changing the screen width also changes the console
+func (e *Environment) SetDeviceWidth(dev string, width int) Object {
+	if e.outer != nil {
+		return e.outer.SetDeviceWidth(dev, width)
+	}
+
+	dev = strings.ToUpper(dev)
+	if _, ok := deviceWidths[dev]; !ok {
+		return StdError(e, berrors.BadFileName)
+	}
+
+	if e.widths == nil {
+		e.widths = make(map[string]int)
+	}
+	e.widths[dev] = width
+
+	if (dev == ScreenDevice) && (e.term != nil) {
+		e.term.SetWidth(width)
+	}
+
+	return nil
+}
+
+// HasCommon returns true if any variables have been declared COMMON
+func (e *Environment) HasCommon() bool {
+	return len(e.common) > 0
+}
+
+// Exists returns true if name has been given a value
+func (e *Environment) Exists(name string) bool {
+	if _, ok := e.store[strings.ToUpper(name)]; ok {
+		return true
+	}
+
+	if e.outer != nil {
+		return e.outer.Exists(name)
+	}
+
+	return false
+}
+
+// Erase removes an array so it can be DIMmed again
+// returns false if it didn't exist
+func (e *Environment) Erase(name string) bool {
+	name = strings.ToUpper(name)
+	if _, ok := e.store[name]; ok {
+		delete(e.store, name)
+		return true
+	}
+
+	if e.outer != nil {
+		return e.outer.Erase(name)
+	}
+
+	return false
+}
+
+// OptionBase returns the lowest array subscript
+func (e *Environment) OptionBase() int16 {
+	if e.outer != nil {
+		return e.outer.OptionBase()
+	}
+
+	return e.base
+}
+
+// SetOptionBase changes the lowest array subscript
+// that is only allowed before any arrays exist
+func (e *Environment) SetOptionBase(base int16) Object {
+	if e.outer != nil {
+		return e.outer.SetOptionBase(base)
+	}
+
+	for _, v := range e.store {
+		if _, ok := v.value.(*Array); ok {
+			return StdError(e, berrors.DuplicateDefinition)
+		}
+	}
+
+	e.base = base
+	return nil
+}
+
 // Set stores an object in the environment
 func (e *Environment) Set(name string, val Object) Object {
 	// don't store a nil
@@ -351,6 +531,45 @@ func (e *Environment) SaveSetting(name string, obj ast.Node) {
 		}
 		keybuffer.GetKeyBuffer().KeySettings = ks
 	}
+
+	// the key buffer needs to know which keys are trapped
+	if strings.EqualFold(name, settings.OnKeys) {
+		kt, _ := obj.(*ast.KeyTraps)
+		keybuffer.GetKeyBuffer().KeyTraps = kt
+	}
+}
+
+// SetClock replaces the system clock, used for testing
+func (e *Environment) SetClock(clock func() time.Time) {
+	e.clock = clock
+}
+
+// Now returns the current time of day
+func (e *Environment) Now() time.Time {
+	if e.outer != nil {
+		return e.outer.Now()
+	}
+
+	return e.clockTime().Add(e.clkAdj)
+}
+
+// SetNow changes the time of day the program sees, the clock keeps running from there
+func (e *Environment) SetNow(now time.Time) {
+	if e.outer != nil {
+		e.outer.SetNow(now)
+		return
+	}
+
+	e.clkAdj = now.Sub(e.clockTime())
+}
+
+// clockTime reads the clock without any adjustment
+func (e *Environment) clockTime() time.Time {
+	if e.clock == nil {
+		return time.Now()
+	}
+
+	return e.clock()
 }
 
 // Push an address, returns stack size
@@ -375,23 +594,119 @@ func (e *Environment) Pop() *ast.RetPoint {
 // ClearVars empties the map of environment objects
 func (e *Environment) ClearVars() {
 	e.store = make(map[string]*variable)
+	e.base = 0
 }
 
 // CloseAllFiles closes all open files
 func (e *Environment) CloseAllFiles() {
-	e.files = make(map[int16]*aFile)
+	if e.outer != nil {
+		e.outer.CloseAllFiles()
+		return
+	}
+
+	e.files = make(map[int16]*FileHandle)
 }
 
 // CloseFile closes a file based on its handle
 func (e *Environment) CloseFile(f int16) bool {
+	if e.outer != nil {
+		return e.outer.CloseFile(f)
+	}
+
 	if e.files[f] == nil {
 		return false
 	}
-	e.files[f] = nil
+	delete(e.files, f)
 
 	return true
 }
 
+// GetFile returns the open file for a file number, nil if not open
+func (e *Environment) GetFile(f int16) *FileHandle {
+	if e.outer != nil {
+		return e.outer.GetFile(f)
+	}
+
+	return e.files[f]
+}
+
+// FindField looks through the open files for a FIELD variable
+func (e *Environment) FindField(name string) (*FileHandle, *FieldVar) {
+	if e.outer != nil {
+		return e.outer.FindField(name)
+	}
+
+	for _, fh := range e.files {
+		if fv := fh.FindField(name); fv != nil {
+			return fh, fv
+		}
+	}
+
+	return nil, nil
+}
+
+// UnField removes a variable from any record buffer it was mapped onto
+func (e *Environment) UnField(name string) {
+	if e.outer != nil {
+		e.outer.UnField(name)
+		return
+	}
+
+	for _, fh := range e.files {
+		fh.UnField(name)
+	}
+}
+
+// FileIsOpen returns true if the file is open under any file number
+func (e *Environment) FileIsOpen(name string) bool {
+	if e.outer != nil {
+		return e.outer.FileIsOpen(name)
+	}
+
+	for _, fh := range e.files {
+		if fh.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenFile opens a locally stored file under a file number
+func (e *Environment) OpenFile(f int16, name string, mode int, recLen int) Object {
+	if e.outer != nil {
+		return e.outer.OpenFile(f, name, mode, recLen)
+	}
+
+	if (f < 1) || (f > MaxFiles) {
+		return StdError(e, berrors.BadFileNum)
+	}
+
+	if e.files[f] != nil {
+		return StdError(e, berrors.FileAlreadyOpen)
+	}
+
+	// a file can be open more than once, but only for input
+	for _, fh := range e.files {
+		if (fh.Name == name) && ((fh.Mode != InputFile) || (mode != InputFile)) {
+			return StdError(e, berrors.FileAlreadyOpen)
+		}
+	}
+
+	fh, err := CreateFileStore().openHandle(name, mode, recLen)
+	if err != 0 {
+		return StdError(e, err)
+	}
+
+	// a device starts out with the width WIDTH gave it
+	if w, ok := e.DeviceWidth(name[strings.LastIndex(name, `\`)+1:]); ok {
+		fh.Width = w
+	}
+	e.files[f] = fh
+
+	return nil
+}
+
 // ClearCommon variables
 func (e *Environment) ClearCommon() {
 	e.common = make(map[string]*variable)
@@ -433,20 +748,71 @@ func (e *Environment) ProgramRunning() bool {
 	return e.run
 }
 
+// GW-BASIC's random number generator is a 24 bit linear congruential generator
+const (
+	rndStart = 0x4fc752 // seed before any RANDOMIZE
+	rndMul   = 0x343fd
+	rndAdd   = 0x269ec3
+	rndMask  = 0xffffff
+)
+
+// ClearRandom puts the random number generator back to where it starts
+func (e *Environment) ClearRandom() {
+	e.rndSeed = rndStart
+}
+
 // Random returns a random number between 0 and 1
 // if x is greater than zero, a new random number is generated
-// otherwise, the current rndVal is returned
-func (e *Environment) Random(x int) *FloatSgl {
-	if x > 0 {
-		e.rndVal = e.rnd.Float32()
+// if x is zero, the last number is returned again
+// if x is negative, x becomes the seed of a new series
+func (e *Environment) Random(x float64) *FloatSgl {
+	if x < 0 {
+		b, _ := mbf.EncodeSingle(float32(x))
+		e.rndSeed = uint32(b[0]^b[3]) | uint32(b[1])<<8 | uint32(b[2])<<16
 	}
-	return &FloatSgl{Value: e.rndVal}
-}
 
-// Randomize takes in a new seed and starts a new random series
-func (e *Environment) Randomize(seed int64) {
-	e.rnd = rand.New(rand.NewSource(seed))
-	e.rndVal = e.rnd.Float32()
+	if x != 0 {
+		e.rndSeed = (e.rndSeed*rndMul + rndAdd) & rndMask
+	}
+
+	return &FloatSgl{Value: float32(e.rndSeed) / (rndMask + 1)}
+}
+
+// Randomize starts a new random series, the seed replaces the
+// upper two bytes of the generator state
+func (e *Environment) Randomize(seed int16) {
+	e.rndSeed = (e.rndSeed & 0xff) | uint32(uint16(seed))<<8
+}
+
+// RandomSeed converts a RANDOMIZE value into a seed
+// integers are used as is, other numbers are reduced to two bytes
+// by xoring the halves of their single precision MBF value together
+// returns a berrors code if val isn't a number or won't fit in an integer
+func RandomSeed(val Object) (int16, int) {
+	var f float64
+
+	switch v := val.(type) {
+	case *Integer:
+		return v.Value, 0
+	case *IntDbl:
+		f = float64(v.Value)
+	case *Fixed:
+		f, _ = v.Value.Float64()
+	case *FloatSgl:
+		f = float64(v.Value)
+	case *FloatDbl:
+		f = v.Value
+	default:
+		return 0, berrors.TypeMismatch
+	}
+
+	if (f < math.MinInt16) || (f > math.MaxInt16) {
+		return 0, berrors.Overflow
+	}
+
+	b, _ := mbf.EncodeSingle(float32(f))
+
+	return int16(uint16(b[2]^b[0]) | uint16(b[3]^b[1])<<8), 0
 }
 
 // Functions below talk to my program object
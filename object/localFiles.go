@@ -1,7 +1,12 @@
 package object
 
 import (
+	"bytes"
 	"io"
+	"sort"
+	"strings"
+
+	"github.com/navionguy/basicwasm/berrors"
 )
 
 type LockMode int
@@ -14,6 +19,9 @@ const (
 	Default                       // deny all, no other process can access the file, fails if already open
 )
 
+// eofMarker is the CTRL-Z that marks the end of a gwbasic data file
+const eofMarker = 0x1a
+
 // in-memory implementation of data files
 type aFile struct {
 	locked LockMode // if locked for exclusive access
@@ -23,18 +31,229 @@ type aFile struct {
 // an instance of an open local file
 type oFile struct {
 	file *aFile
+	pos  int // offset of the next byte to read or write
 }
 
 // implement a read for the oFile
 func (ofl *oFile) ReadByte() (byte, error) {
-	return 1, nil
+	if ofl.atEnd() {
+		return 0, io.EOF
+	}
+
+	bt := ofl.file.data[ofl.pos]
+	ofl.pos++
+
+	return bt, nil
+}
+
+// UnreadByte backs up so the last byte read will be read again
+func (ofl *oFile) UnreadByte() error {
+	if ofl.pos == 0 {
+		return io.EOF
+	}
+	ofl.pos--
+
+	return nil
 }
 
 // write to the open file
 func (ofl *oFile) WriteByte(c byte) error {
+	if ofl.pos < len(ofl.file.data) {
+		ofl.file.data[ofl.pos] = c
+	} else {
+		ofl.file.data = append(ofl.file.data, c)
+	}
+	ofl.pos++
+
 	return nil
 }
 
+// true if there is nothing left to read
+func (ofl *oFile) atEnd() bool {
+	return (ofl.pos >= len(ofl.file.data)) || (ofl.file.data[ofl.pos] == eofMarker)
+}
+
+// FileHandle is a data file opened by a program under a file number
+type FileHandle struct {
+	oFile
+	Name   string     // fully qualified file name
+	Mode   int        // InputFile, OutputFile, RandomFile or AppendFile
+	RecLen int        // record length for random files
+	Record []byte     // record buffer for random files
+	Fields []FieldVar // string variables mapped onto the record buffer
+	recNum int        // last record read or written
+	Width  int        // line width PRINT # wraps at, NoWrap for none
+	col    int        // column PRINT # has written up to
+	dirty  bool       // written to since it was opened
+}
+
+// FieldVar maps a string variable onto part of the record buffer
+type FieldVar struct {
+	Name   string // variable name
+	Offset int    // where it starts in the record
+	Width  int    // how many bytes it covers
+}
+
+// Print writes a string to the file
+func (fh *FileHandle) Print(msg string) {
+	fh.dirty = true
+	for i := 0; i < len(msg); i++ {
+		fh.WriteByte(msg[i])
+		fh.col++
+		if msg[i] == '\r' || msg[i] == '\n' {
+			fh.col = 0
+		}
+	}
+}
+
+// Dirty returns true if the file has been written to
+func (fh *FileHandle) Dirty() bool {
+	return fh.dirty
+}
+
+// Column returns how far into the current line printing has gone
+func (fh *FileHandle) Column() int {
+	return fh.col
+}
+
+// Println writes a string followed by a CR/LF
+func (fh *FileHandle) Println(msg string) {
+	fh.Print(msg + "\r\n")
+}
+
+// EOF returns true if all the data has been read
+// for random files, true if the last GET was past the end
+func (fh *FileHandle) EOF() bool {
+	if fh.Mode == RandomFile {
+		return fh.recNum*fh.RecLen > len(fh.file.data)
+	}
+	return fh.atEnd()
+}
+
+// LOF returns the length of the file in bytes
+func (fh *FileHandle) LOF() int {
+	return len(fh.file.data)
+}
+
+// LOC returns the number of 128 byte blocks read or written
+// for random files it is the last record number used
+func (fh *FileHandle) LOC() int {
+	if fh.Mode == RandomFile {
+		return fh.recNum
+	}
+	return fh.pos / 128
+}
+
+// Field maps a variable onto the record buffer
+// if the variable was already mapped, it is moved
+func (fh *FileHandle) Field(name string, offset int, width int) {
+	for i := range fh.Fields {
+		if fh.Fields[i].Name == name {
+			fh.Fields[i] = FieldVar{Name: name, Offset: offset, Width: width}
+			return
+		}
+	}
+
+	fh.Fields = append(fh.Fields, FieldVar{Name: name, Offset: offset, Width: width})
+}
+
+// FindField returns the mapping for a variable, nil if it isn't mapped
+func (fh *FileHandle) FindField(name string) *FieldVar {
+	for i := range fh.Fields {
+		if fh.Fields[i].Name == name {
+			return &fh.Fields[i]
+		}
+	}
+
+	return nil
+}
+
+// UnField removes the mapping for a variable, if it has one
+func (fh *FileHandle) UnField(name string) {
+	for i := range fh.Fields {
+		if fh.Fields[i].Name == name {
+			fh.Fields = append(fh.Fields[:i], fh.Fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// FieldValue returns the current contents of a field
+func (fh *FileHandle) FieldValue(fv *FieldVar) string {
+	return string(fh.Record[fv.Offset : fv.Offset+fv.Width])
+}
+
+// SetField copies val into a field, padding with spaces
+// if right is true, the value is right justified
+func (fh *FileHandle) SetField(fv *FieldVar, val []byte, right bool) {
+	copy(fh.Record[fv.Offset:fv.Offset+fv.Width], PadField(val, fv.Width, right))
+}
+
+// PadField truncates or space pads val to exactly width bytes
+// if right is true, the padding goes on the left
+func PadField(val []byte, width int, right bool) []byte {
+	if len(val) >= width {
+		return val[:width]
+	}
+
+	pad := bytes.Repeat([]byte{' '}, width-len(val))
+	if right {
+		return append(pad, val...)
+	}
+
+	return append(append([]byte{}, val...), pad...)
+}
+
+// GetRecord reads a record into the record buffer
+// a rec of zero means the next record
+// reading past the end of the file fills the buffer with zeros
+// returns a berrors code if the record number is out of range
+func (fh *FileHandle) GetRecord(rec int) int {
+	if rec == 0 {
+		rec = fh.recNum + 1
+	}
+	if (rec < 1) || (rec > MaxRecord) {
+		return berrors.BadRecordNum
+	}
+	fh.recNum = rec
+
+	start := (rec - 1) * fh.RecLen
+	for i := range fh.Record {
+		fh.Record[i] = 0
+	}
+	if start < len(fh.file.data) {
+		copy(fh.Record, fh.file.data[start:])
+	}
+	fh.pos = start + fh.RecLen
+
+	return 0
+}
+
+// PutRecord writes the record buffer out to the file
+// a rec of zero means the next record
+// returns a berrors code if the record number is out of range
+func (fh *FileHandle) PutRecord(rec int) int {
+	if rec == 0 {
+		rec = fh.recNum + 1
+	}
+	if (rec < 1) || (rec > MaxRecord) {
+		return berrors.BadRecordNum
+	}
+	fh.recNum = rec
+	fh.dirty = true
+
+	fh.pos = (rec - 1) * fh.RecLen
+	for len(fh.file.data) < fh.pos {
+		fh.file.data = append(fh.file.data, 0)
+	}
+
+	for _, bt := range fh.Record {
+		fh.WriteByte(bt)
+	}
+
+	return 0
+}
+
 // LocalFiles holds all of the data files accessed by programs.
 // In this way, if one program creates a data file, and a later
 // program accessed it, the intended contents are preserved
@@ -59,6 +278,66 @@ func CreateFileStore() *LocalFiles {
 	return &lf
 }
 
+// Exists returns true if the file is already stored locally
+func (lf *LocalFiles) Exists(FQFilename string) bool {
+	return lf.localFiles[FQFilename] != nil
+}
+
+// StoreFile saves the contents of a file, usually fetched from the server
+func (lf *LocalFiles) StoreFile(FQFilename string, data []byte) {
+	lf.localFiles[FQFilename] = &aFile{data: data}
+}
+
+// ReadFile returns the contents of a locally stored file
+// false if the file isn't stored locally
+func (lf *LocalFiles) ReadFile(FQFilename string) ([]byte, bool) {
+	fl := lf.localFiles[FQFilename]
+	if fl == nil {
+		return nil, false
+	}
+
+	return fl.data, true
+}
+
+// RemoveFile deletes a locally stored file
+// returns false if it wasn't stored locally
+func (lf *LocalFiles) RemoveFile(FQFilename string) bool {
+	if lf.localFiles[FQFilename] == nil {
+		return false
+	}
+
+	delete(lf.localFiles, FQFilename)
+	return true
+}
+
+// RenameFile moves a locally stored file to a new name
+// returns false if it wasn't stored locally
+func (lf *LocalFiles) RenameFile(FQFilename string, newName string) bool {
+	fl := lf.localFiles[FQFilename]
+	if fl == nil {
+		return false
+	}
+
+	delete(lf.localFiles, FQFilename)
+	lf.localFiles[newName] = fl
+	return true
+}
+
+// FilesIn returns the names of the files stored locally in a directory
+// dir must end in a back slash
+func (lf *LocalFiles) FilesIn(dir string) []string {
+	var names []string
+
+	for name := range lf.localFiles {
+		if strings.HasPrefix(name, dir) && !strings.Contains(name[len(dir):], `\`) {
+			names = append(names, name[len(dir):])
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
 // Give the fileserve layer read only access to the files data
 // If the file has not been fetched from the server
 func (lf *LocalFiles) OpenLocalReadOnly(FQFilename string, env *Environment) io.ByteReader {
@@ -85,6 +364,49 @@ func (lf *LocalFiles) OpenLocal(FQFilename string, env *Environment) *oFile {
 	return nil
 }
 
+// openHandle creates a FileHandle for the file in the requested mode
+// returns a berrors code if it can't be done
+func (lf *LocalFiles) openHandle(FQFilename string, mode int, recLen int) (*FileHandle, int) {
+	fl := lf.localFiles[FQFilename]
+
+	switch mode {
+	case InputFile:
+		if fl == nil {
+			return nil, berrors.FileNotFound
+		}
+	case OutputFile:
+		// output always starts with an empty file
+		fl = &aFile{}
+		lf.localFiles[FQFilename] = fl
+	case RandomFile, AppendFile:
+		if fl == nil {
+			fl = &aFile{}
+			lf.localFiles[FQFilename] = fl
+		}
+	default:
+		return nil, berrors.BadFileMode
+	}
+
+	fh := &FileHandle{oFile: oFile{file: fl}, Name: FQFilename, Mode: mode, RecLen: recLen, Width: NoWrap}
+
+	// output has already emptied the file, so it needs saving even if nothing is written
+	fh.dirty = (mode == OutputFile)
+
+	if mode == RandomFile {
+		fh.Record = make([]byte, recLen)
+	}
+
+	// appending starts at the end, before any CTRL-Z
+	if mode == AppendFile {
+		for !fh.atEnd() {
+			fh.pos++
+		}
+		fl.data = fl.data[:fh.pos]
+	}
+
+	return fh, 0
+}
+
 // FetchFile calls out to the backend server and tries to download
 // the requested file.
 // If the fetch fails, he returns an error object.
@@ -79,3 +79,25 @@ func Test_CloseFIle(t *testing.T) {
 		lf.CloseFile(tt.num)
 	}
 }
+
+func Test_LocalFileChanges(t *testing.T) {
+	lf := CreateFileStore()
+	lf.StoreFile(`c:\lfc\one.dat`, []byte("one"))
+	lf.StoreFile(`c:\lfc\two.dat`, []byte("two"))
+	lf.StoreFile(`c:\lfc\sub\three.dat`, []byte("three"))
+
+	assert.Equal(t, []string{"one.dat", "two.dat"}, lf.FilesIn(`c:\lfc\`))
+	assert.Equal(t, []string{"three.dat"}, lf.FilesIn(`c:\lfc\sub\`))
+	assert.Nil(t, lf.FilesIn(`c:\none\`))
+
+	assert.True(t, lf.RenameFile(`c:\lfc\one.dat`, `c:\lfc\sub\one.dat`), "RenameFile failed")
+	assert.False(t, lf.RenameFile(`c:\lfc\one.dat`, `c:\lfc\uno.dat`), "RenameFile moved a missing file")
+	data, ok := lf.ReadFile(`c:\lfc\sub\one.dat`)
+	assert.True(t, ok, "renamed file missing")
+	assert.Equal(t, "one", string(data))
+
+	assert.True(t, lf.RemoveFile(`c:\lfc\two.dat`), "RemoveFile failed")
+	assert.False(t, lf.RemoveFile(`c:\lfc\two.dat`), "RemoveFile removed a missing file")
+	assert.Nil(t, lf.FilesIn(`c:\lfc\`))
+	assert.Equal(t, []string{"one.dat", "three.dat"}, lf.FilesIn(`c:\lfc\sub\`))
+}
@@ -0,0 +1,86 @@
+package object
+
+import (
+	"strconv"
+	"strings"
+)
+
+// significant digits GW-BASIC shows for each precision
+const (
+	sglDigits = 7
+	dblDigits = 16
+)
+
+// FormatNumber converts a number to text the way STR$ does, a
+// positive number gets a leading space where the sign would go
+// returns false if obj isn't a number
+func FormatNumber(obj Object) (string, bool) {
+	if tv, ok := obj.(*TypedVar); ok {
+		obj = tv.Value
+	}
+
+	switch obj.(type) {
+	case *Integer, *IntDbl, *FloatSgl, *FloatDbl, *Fixed:
+	default:
+		return "", false
+	}
+
+	str := obj.Inspect()
+	if !strings.HasPrefix(str, "-") {
+		str = " " + str
+	}
+
+	return str, true
+}
+
+// formatFloat shows val with at most digits significant digits
+// it switches to scientific notation, using expChar, when the
+// number is too large or too small to show all its digits
+func formatFloat(val float64, digits int, expChar string) string {
+	if val == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if val < 0 {
+		sign = "-"
+		val = -val
+	}
+
+	str := strconv.FormatFloat(val, 'e', digits-1, 64)
+	i := strings.IndexByte(str, 'e')
+	exp, _ := strconv.Atoi(str[i+1:])
+	sig := strings.TrimRight(strings.Replace(str[:i], ".", "", 1), "0")
+
+	switch {
+	case (exp > digits-1) || (len(sig)-exp > digits+1):
+		mant := sig[:1]
+		if len(sig) > 1 {
+			mant += "." + sig[1:]
+		}
+		return sign + mant + expChar + formatExponent(exp)
+	case exp < 0:
+		// no zero in front of the decimal point
+		return sign + "." + strings.Repeat("0", -exp-1) + sig
+	case exp >= len(sig)-1:
+		return sign + sig + strings.Repeat("0", exp-len(sig)+1)
+	}
+
+	return sign + sig[:exp+1] + "." + sig[exp+1:]
+}
+
+// formatExponent gives the sign and at least two digits of exp
+func formatExponent(exp int) string {
+	sign := "+"
+	if exp < 0 {
+		sign = "-"
+		exp = -exp
+	}
+
+	str := strconv.Itoa(exp)
+	if len(str) < 2 {
+		str = "0" + str
+	}
+
+	return sign + str
+}
@@ -4,7 +4,7 @@ package object
 import (
 	"bytes"
 	"fmt"
-
+	"strconv"
 	"strings"
 
 	"github.com/navionguy/basicwasm/ast"
@@ -71,7 +71,7 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
 // Inspect returns value as a string
-func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) Inspect() string { return strconv.Itoa(int(i.Value)) }
 
 // IntDbl values
 type IntDbl struct {
@@ -82,7 +82,7 @@ type IntDbl struct {
 func (id *IntDbl) Type() ObjectType { return INTEGER_DBL }
 
 // Inspect returns value as a string
-func (id *IntDbl) Inspect() string { return fmt.Sprintf("%d", id.Value) }
+func (id *IntDbl) Inspect() string { return strconv.Itoa(int(id.Value)) }
 
 // Single precision floats
 type FloatSgl struct {
@@ -91,7 +91,7 @@ type FloatSgl struct {
 
 func (fs *FloatSgl) Type() ObjectType { return FLOATSGL_OBJ }
 func (fs *FloatSgl) Inspect() string {
-	return fmt.Sprintf("%E", fs.Value)
+	return formatFloat(float64(fs.Value), sglDigits, "E")
 }
 
 // Double precision floats
@@ -100,7 +100,7 @@ type FloatDbl struct {
 }
 
 func (fd *FloatDbl) Type() ObjectType { return FLOATDBL_OBJ }
-func (fd *FloatDbl) Inspect() string  { return fmt.Sprintf("%E", fd.Value) }
+func (fd *FloatDbl) Inspect() string  { return formatFloat(fd.Value, dblDigits, "D") }
 
 // Fixed decimal point value
 type Fixed struct {
@@ -108,7 +108,10 @@ type Fixed struct {
 }
 
 func (f *Fixed) Type() ObjectType { return FIXED_OBJ }
-func (f *Fixed) Inspect() string  { return f.Value.String() }
+func (f *Fixed) Inspect() string {
+	v, _ := f.Value.Float64()
+	return formatFloat(v, sglDigits, "E")
+}
 
 type Error struct {
 	Message string // text error message
@@ -158,6 +161,11 @@ type ForBlock struct {
 	Four *ast.ForStatment // the actual statment
 }
 
+type WhileBlock struct {
+	Code  ast.RetPoint        // the location in the AST of the WHILE statement
+	While *ast.WhileStatement // the actual statement
+}
+
 // String values
 type String struct {
 	Value string
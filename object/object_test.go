@@ -3,6 +3,7 @@ package object
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/navionguy/basicwasm/ast"
 	"github.com/navionguy/basicwasm/berrors"
@@ -68,6 +69,12 @@ func Test_CloseAllFiles(t *testing.T) {
 	env := newEnvironment()
 
 	env.CloseAllFiles()
+
+	// closing from inside a function closes the program's files
+	env.files[1] = &FileHandle{}
+	inner := NewEnclosedEnvironment(env)
+	inner.CloseAllFiles()
+	assert.Nil(t, env.GetFile(1), "enclosed CloseAllFiles left a file open")
 }
 
 func Test_CloseFile(t *testing.T) {
@@ -82,7 +89,7 @@ func Test_CloseFile(t *testing.T) {
 	for _, tt := range tests {
 		env := newEnvironment()
 		if tt.ok {
-			f := aFile{}
+			f := FileHandle{}
 			env.files[tt.num] = &f
 		}
 		rc := env.CloseFile(tt.num)
@@ -91,6 +98,116 @@ func Test_CloseFile(t *testing.T) {
 	}
 }
 
+func Test_OpenFile(t *testing.T) {
+	tests := []struct {
+		num  int16
+		name string
+		mode int
+		err  int
+	}{
+		{num: 1, name: `C:\OPEN1.DAT`, mode: OutputFile},
+		{num: 0, name: `C:\OPEN2.DAT`, mode: OutputFile, err: berrors.BadFileNum},
+		{num: MaxFiles + 1, name: `C:\OPEN2.DAT`, mode: OutputFile, err: berrors.BadFileNum},
+		{num: 1, name: `C:\OPEN3.DAT`, mode: InputFile, err: berrors.FileNotFound},
+		{num: 1, name: `C:\OPEN4.DAT`, mode: 9, err: berrors.BadFileMode},
+	}
+
+	for _, tt := range tests {
+		env := newEnvironment()
+		rc := env.OpenFile(tt.num, tt.name, tt.mode, 128)
+
+		if tt.err == 0 {
+			assert.Nilf(t, rc, "OpenFile(%s) failed", tt.name)
+			assert.NotNil(t, env.GetFile(tt.num))
+			continue
+		}
+
+		err, ok := rc.(*Error)
+		if assert.Truef(t, ok, "OpenFile(%s) didn't fail", tt.name) {
+			assert.Equalf(t, tt.err, err.Code, "OpenFile(%s) wrong error", tt.name)
+		}
+	}
+}
+
+func Test_FileHandle(t *testing.T) {
+	env := newEnvironment()
+	name := `C:\HANDLE.DAT`
+
+	assert.Nil(t, env.OpenFile(1, name, OutputFile, 128))
+	fh := env.GetFile(1)
+	fh.Println("one")
+	assert.Equal(t, 5, fh.LOF())
+	env.CloseFile(1)
+
+	// append should drop the CTRL-Z
+	CreateFileStore().StoreFile(name, []byte("one\r\n\x1a"))
+	assert.Nil(t, env.OpenFile(1, name, AppendFile, 128))
+	env.GetFile(1).Print("two")
+	env.CloseFile(1)
+
+	assert.Nil(t, env.OpenFile(1, name, InputFile, 128))
+	fh = env.GetFile(1)
+	assert.Equal(t, 8, fh.LOF())
+	assert.False(t, fh.EOF())
+	for !fh.EOF() {
+		fh.ReadByte()
+	}
+	assert.Equal(t, 0, fh.LOC())
+
+	// same file can't be opened twice, unless for input
+	assert.NotNil(t, env.OpenFile(2, name, OutputFile, 128))
+	assert.Nil(t, env.OpenFile(2, name, InputFile, 128))
+	assert.NotNil(t, env.OpenFile(2, name, InputFile, 128))
+}
+
+func Test_RandomRecords(t *testing.T) {
+	env := newEnvironment()
+	assert.Nil(t, env.OpenFile(1, `C:\RECORDS.DAT`, RandomFile, 4))
+	fh := env.GetFile(1)
+
+	fh.Field("A$", 0, 3)
+	fh.Field("B$", 3, 1)
+	fh.Field("A$", 1, 3)
+	assert.Equal(t, 2, len(fh.Fields))
+
+	fh2, fv := env.FindField("A$")
+	assert.Equal(t, fh, fh2)
+	fh.SetField(fv, []byte("xy"), true)
+	assert.Equal(t, " xy", fh.FieldValue(fv))
+
+	assert.Zero(t, fh.PutRecord(3))
+	assert.Equal(t, 12, fh.LOF())
+	assert.Equal(t, 3, fh.LOC())
+
+	assert.Zero(t, fh.GetRecord(1))
+	assert.Equal(t, "\x00\x00\x00", fh.FieldValue(fv))
+	assert.Zero(t, fh.GetRecord(3))
+	assert.Equal(t, " xy", fh.FieldValue(fv))
+	assert.False(t, fh.EOF())
+	assert.Zero(t, fh.GetRecord(0))
+	assert.True(t, fh.EOF())
+
+	// out of range records are refused before the file grows
+	assert.Equal(t, berrors.BadRecordNum, fh.PutRecord(MaxRecord+1))
+	assert.Equal(t, berrors.BadRecordNum, fh.PutRecord(-1))
+	assert.Equal(t, berrors.BadRecordNum, fh.GetRecord(MaxRecord+1))
+	assert.Equal(t, 12, fh.LOF())
+	assert.Equal(t, 4, fh.LOC())
+
+	fh2, fv = env.FindField("C$")
+	assert.Nil(t, fh2)
+	assert.Nil(t, fv)
+
+	env.UnField("A$")
+	fh2, fv = env.FindField("A$")
+	assert.Nil(t, fh2)
+	assert.Nil(t, fv)
+	assert.Equal(t, 1, len(fh.Fields))
+
+	assert.Equal(t, []byte("abc"), PadField([]byte("abcdef"), 3, false))
+	assert.Equal(t, []byte("ab "), PadField([]byte("ab"), 3, false))
+}
+
 func Test_ClearVars(t *testing.T) {
 	env := newEnvironment()
 
@@ -206,8 +323,8 @@ func Test_Integer(t *testing.T) {
 		{obj: &Builtin{}, exp: "builtin function", tp: "BUILTIN"},
 		{obj: &Null{}, exp: "null", tp: "NULL"},
 		{obj: &IntDbl{Value: 65999}, exp: "65999", tp: "INTDBL"},
-		{obj: &FloatSgl{Value: 3.14159}, exp: "3.141590E+00", tp: "FLOATSGL"},
-		{obj: &FloatDbl{Value: 3.14159}, exp: "3.141590E+00", tp: "FLOATDBL"},
+		{obj: &FloatSgl{Value: 3.14159}, exp: "3.14159", tp: "FLOATSGL"},
+		{obj: &FloatDbl{Value: 3.14159}, exp: "3.14159", tp: "FLOATDBL"},
 	}
 
 	for _, tt := range tests {
@@ -221,6 +338,46 @@ func Test_Integer(t *testing.T) {
 	}
 }
 
+func Test_FormatNumber(t *testing.T) {
+	fv, _ := decimal.NewFromString("14.25")
+
+	tests := []struct {
+		obj Object
+		exp string
+		ok  bool
+	}{
+		{obj: &Integer{Value: 0}, exp: " 0", ok: true},
+		{obj: &Integer{Value: -32768}, exp: "-32768", ok: true},
+		{obj: &IntDbl{Value: 123456789}, exp: " 123456789", ok: true},
+		{obj: &FloatSgl{Value: 1}, exp: " 1", ok: true},
+		{obj: &FloatSgl{Value: -0.5}, exp: "-.5", ok: true},
+		{obj: &FloatSgl{Value: 2.0 / 3}, exp: " .6666667", ok: true},
+		{obj: &FloatSgl{Value: 1234567}, exp: " 1234567", ok: true},
+		{obj: &FloatSgl{Value: 12345678}, exp: " 1.234568E+07", ok: true},
+		{obj: &FloatSgl{Value: 9999999.5}, exp: " 1E+07", ok: true},
+		{obj: &FloatSgl{Value: 0.0001}, exp: " .0001", ok: true},
+		{obj: &FloatSgl{Value: 1e-7}, exp: " .0000001", ok: true},
+		{obj: &FloatSgl{Value: 1e-8}, exp: " 1E-08", ok: true},
+		{obj: &FloatSgl{Value: 0.01234567}, exp: " 1.234567E-02", ok: true},
+		{obj: &FloatSgl{Value: -1.5e30}, exp: "-1.5E+30", ok: true},
+		{obj: &FloatSgl{Value: 0.1}, exp: " .1", ok: true},
+		{obj: &FloatDbl{Value: 0.1}, exp: " .1", ok: true},
+		{obj: &FloatDbl{Value: float64(float32(0.1))}, exp: " .1000000014901161", ok: true},
+		{obj: &FloatDbl{Value: 1234567890123456}, exp: " 1234567890123456", ok: true},
+		{obj: &FloatDbl{Value: 12345678901234567}, exp: " 1.234567890123457D+16", ok: true},
+		{obj: &FloatDbl{Value: -1.5e-20}, exp: "-1.5D-20", ok: true},
+		{obj: &Fixed{Value: fv}, exp: " 14.25", ok: true},
+		{obj: &TypedVar{Value: &FloatSgl{Value: 2.5}, TypeID: "!"}, exp: " 2.5", ok: true},
+		{obj: &String{Value: "5"}},
+	}
+
+	for _, tt := range tests {
+		str, ok := FormatNumber(tt.obj)
+		assert.Equal(t, tt.ok, ok, "FormatNumber(%T) ok", tt.obj)
+		assert.Equal(t, tt.exp, str, "FormatNumber(%T)", tt.obj)
+	}
+}
+
 func Test_Environment(t *testing.T) {
 	env := newEnvironment()
 	encenv := NewEnclosedEnvironment(env)
@@ -289,17 +446,43 @@ func Test_TermEnvironment(t *testing.T) {
 	}
 }
 
+func Test_Clock(t *testing.T) {
+	var trm mocks.MockTerm
+	env := NewTermEnvironment(trm)
+	start := time.Date(2025, 7, 4, 13, 2, 3, 0, time.UTC)
+	now := start
+	env.SetClock(func() time.Time { return now })
+
+	assert.Equal(t, start, env.Now(), "Now() should use the clock")
+
+	// setting the time moves the clock, and it keeps running
+	env.SetNow(start.Add(time.Hour))
+	now = now.Add(time.Minute)
+	assert.Equal(t, start.Add(time.Hour+time.Minute), env.Now(), "Now() after SetNow()")
+
+	encl := NewEnclosedEnvironment(env)
+	assert.Equal(t, env.Now(), encl.Now(), "enclosed environment should share the clock")
+
+	encl.SetNow(start)
+	assert.Equal(t, start, env.Now(), "SetNow() in enclosed environment should move the outer clock")
+}
+
 func TestRandom(t *testing.T) {
 	tests := []struct {
-		inp    int
+		inp    float64
 		exp    float32
-		rndMze int64
+		rndMze int16
 	}{
-		{0, 0.61560816, 0},
-		{0, 0.61560816, 0},
-		{1, 0.123114005, 0},
-		{0, 0.604660273, 1},
-		{1, 0.940509081, 0},
+		{0, 0.31163514, 0},
+		{1, 0.12135011, 0},
+		{0, 0.12135011, 0},
+		{1, 0.65186095, 0},
+		{1, 0.86886114, 0},
+		{0, 0.86886114, 0},
+		{-1, 0.2964058, 0},
+		{-1, 0.2964058, 0},
+		{1, 0.8436739, 0},
+		{0.5, 0.3299824, 0},
 	}
 
 	env := newEnvironment()
@@ -316,6 +499,120 @@ func TestRandom(t *testing.T) {
 	}
 }
 
+func TestDefType(t *testing.T) {
+	env := newEnvironment()
+	env.SetDefType('I', 'N', "%")
+	env.SetDefType('s', 's', "$")
+
+	tests := []struct {
+		name string
+		exp  string
+		def  Object
+	}{
+		{name: "I", exp: "%", def: &Integer{Value: 0}},
+		{name: "NUM", exp: "%", def: &Integer{Value: 0}},
+		{name: "S", exp: "$", def: &String{Value: ""}},
+		{name: "STR", exp: "$", def: &String{Value: ""}},
+		{name: "A", exp: "!", def: &FloatSgl{Value: 0}},
+		{name: "O", exp: "!", def: &FloatSgl{Value: 0}},
+		{name: "_", exp: "", def: &Integer{Value: 0}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, env.DefType(tt.name), "DefType(%s)", tt.name)
+		assert.Equal(t, tt.def, env.Get(tt.name), "Get(%s)", tt.name)
+	}
+
+	// enclosed environments share the settings
+	inner := NewEnclosedEnvironment(env)
+	assert.Equal(t, "%", inner.DefType("J"), "enclosed DefType(J)")
+
+	// DEFSNG puts things back to normal
+	env.SetDefType('A', 'Z', "!")
+	assert.Equal(t, "!", env.DefType("I"), "DefType(I) after DEFSNG")
+
+	env.SetDefType('A', 'Z', "#")
+	inner.ClearDefTypes()
+	assert.Equal(t, "!", env.DefType("X"), "DefType(X) after ClearDefTypes")
+}
+
+func TestOptionBase(t *testing.T) {
+	env := newEnvironment()
+	assert.Equal(t, int16(0), env.OptionBase(), "OptionBase() default")
+
+	assert.Nil(t, env.SetOptionBase(1), "SetOptionBase(1) with no arrays")
+	assert.Equal(t, int16(1), env.OptionBase(), "OptionBase() after SetOptionBase(1)")
+
+	// too late once an array exists
+	env.Set("A[]", &Array{})
+	inner := NewEnclosedEnvironment(env)
+	err, ok := inner.SetOptionBase(0).(*Error)
+	if assert.True(t, ok, "SetOptionBase(0) with an array") {
+		assert.Equal(t, berrors.DuplicateDefinition, err.Code)
+	}
+
+	// ERASE makes it legal again
+	assert.True(t, inner.Exists("a[]"), "Exists(a[])")
+	assert.True(t, inner.Erase("A[]"), "Erase(A[])")
+	assert.False(t, inner.Exists("A[]"), "Exists(A[]) after Erase")
+	assert.False(t, inner.Erase("A[]"), "Erase(A[]) twice")
+	assert.Nil(t, inner.SetOptionBase(0), "SetOptionBase(0) after Erase")
+
+	env.SetOptionBase(1)
+	env.ClearVars()
+	assert.Equal(t, int16(0), env.OptionBase(), "OptionBase() after ClearVars")
+}
+
+func TestRandomize(t *testing.T) {
+	tests := []struct {
+		seed int16
+		exp  float32
+	}{
+		{seed: 1, exp: 0.46244508},
+		{seed: 42, exp: 0.35119325},
+		{seed: -1, exp: 0.93128663},
+	}
+
+	for _, tt := range tests {
+		env := newEnvironment()
+		env.Randomize(tt.seed)
+		assert.Equal(t, tt.exp, env.Random(1).Value, "Random(1) after Randomize(%d)", tt.seed)
+
+		// starting over gives the same numbers
+		env.Random(1)
+		env.ClearRandom()
+		env.Randomize(tt.seed)
+		assert.Equal(t, tt.exp, env.Random(1).Value, "Random(1) after ClearRandom()")
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	tests := []struct {
+		inp Object
+		exp int16
+		err int
+	}{
+		{inp: &Integer{Value: 42}, exp: 42},
+		{inp: &Integer{Value: -5}, exp: -5},
+		{inp: &FloatSgl{Value: 1}, exp: -32512},
+		{inp: &FloatDbl{Value: 1}, exp: -32512},
+		{inp: &FloatSgl{Value: 1.5}, exp: -32448},
+		{inp: &IntDbl{Value: 1000}, exp: -30086},
+		{inp: &FloatSgl{Value: 0}, exp: 0},
+		{inp: &IntDbl{Value: -32768}, exp: -28544},
+		{inp: &IntDbl{Value: -40000}, err: berrors.Overflow},
+		{inp: &FloatSgl{Value: 32768}, err: berrors.Overflow},
+		{inp: &FloatDbl{Value: -32768.5}, err: berrors.Overflow},
+		{inp: &String{Value: "42"}, err: berrors.TypeMismatch},
+	}
+
+	for _, tt := range tests {
+		seed, err := RandomSeed(tt.inp)
+		assert.Equal(t, tt.err, err, "RandomSeed(%s) error", tt.inp.Inspect())
+		assert.Equal(t, tt.exp, seed, "RandomSeed(%s)", tt.inp.Inspect())
+	}
+}
+
 func TestReadOnly(t *testing.T) {
 	env := newEnvironment()
 
@@ -487,3 +784,46 @@ func Test_TypedValue(t *testing.T) {
 	assert.Equal(t, ObjectType(TYPED_OBJ), tv.Type())
 	assert.Equal(t, "5", tv.Inspect())
 }
+
+func TestDeviceWidth(t *testing.T) {
+	var mt mocks.MockTerm
+	mocks.InitMockTerm(&mt)
+	mt.SawWidth = new(int)
+	env := NewTermEnvironment(mt)
+
+	w, ok := env.DeviceWidth("scrn:")
+	assert.True(t, ok, "DeviceWidth(scrn:) ok")
+	assert.Equal(t, 80, w, "DeviceWidth(scrn:) default")
+
+	w, _ = env.DeviceWidth("COM1:")
+	assert.Equal(t, NoWrap, w, "DeviceWidth(COM1:) default")
+
+	_, ok = env.DeviceWidth("XYZ:")
+	assert.False(t, ok, "DeviceWidth(XYZ:) ok")
+
+	// the screen width goes on to the console
+	inner := NewEnclosedEnvironment(env)
+	assert.Nil(t, inner.SetDeviceWidth(ScreenDevice, 40), "SetDeviceWidth(SCRN:, 40)")
+	assert.Equal(t, 40, *mt.SawWidth, "console width")
+	w, _ = env.DeviceWidth(ScreenDevice)
+	assert.Equal(t, 40, w, "DeviceWidth(SCRN:) after SetDeviceWidth")
+
+	err, ok := env.SetDeviceWidth("XYZ:", 80).(*Error)
+	if assert.True(t, ok, "SetDeviceWidth(XYZ:)") {
+		assert.Equal(t, berrors.BadFileName, err.Code)
+	}
+
+	// files opened on a device start with its width
+	assert.Nil(t, env.SetDeviceWidth("lpt1:", 132), "SetDeviceWidth(lpt1:, 132)")
+	assert.Nil(t, env.OpenFile(1, `C:\LPT1:`, OutputFile, 128), "OpenFile(LPT1:)")
+	assert.Equal(t, 132, env.GetFile(1).Width, "LPT1: file width")
+	assert.Nil(t, env.OpenFile(2, `C:\WIDTH.TXT`, OutputFile, 128), "OpenFile(WIDTH.TXT)")
+	assert.Equal(t, NoWrap, env.GetFile(2).Width, "WIDTH.TXT file width")
+
+	// PRINT # keeps track of the column
+	fh := env.GetFile(2)
+	fh.Print("ABC")
+	assert.Equal(t, 3, fh.Column(), "Column() after ABC")
+	fh.Println("D")
+	assert.Equal(t, 0, fh.Column(), "Column() after Println")
+}
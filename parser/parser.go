@@ -19,28 +19,42 @@ const (
 	_ int = iota
 	// LOWEST defines the bottom of the priority stack
 	LOWEST
-	EQUALS      // ==
-	LESSGREATER // > or <
-	SUM         // +
-	PRODUCT     // *
-	PREFIX      // -X or !X
-	CALL        // myFunction(X)
+	IMPLIES    // IMP
+	EQUIVALENT // EQV
+	EXCLUSIVE  // XOR
+	LOGICALOR  // OR
+	LOGICALAND // AND
+	LOGICALNOT // NOT X
+	EQUALS     // = <> < > <= >=
+	SUM        // +
+	MODULUS    // MOD
+	INTDIVIDE  // \
+	PRODUCT    // *
+	PREFIX     // -X
+	POWER      // ^
+	CALL       // myFunction(X)
 	INDEX
 )
 
 var precedences = map[token.TokenType]int{
+	token.IMP:      IMPLIES,
+	token.EQV:      EQUIVALENT,
+	token.XOR:      EXCLUSIVE,
+	token.OR:       LOGICALOR,
+	token.AND:      LOGICALAND,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.LTE:      LESSGREATER,
+	token.LT:       EQUALS,
+	token.GT:       EQUALS,
+	token.GTE:      EQUALS,
+	token.LTE:      EQUALS,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
-	token.BSLASH:   PRODUCT,
+	token.MOD:      MODULUS,
+	token.BSLASH:   INTDIVIDE,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
-	token.MOD:      PRODUCT,
+	token.CARET:    POWER,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
 }
@@ -82,14 +96,17 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FLOAT, p.parseFloatingPointLiteral)
 	p.registerPrefix(token.FIXED, p.parseFixedPointLiteral)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.INPUT, p.parseInputFunction)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.INTD, p.parseIntDoubleLiteral)
 	p.registerPrefix(token.LIST, p.parseListExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.OFF, p.parseOffExpression)
 	p.registerPrefix(token.ON, p.parseOnExpression)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TIMER, p.parseTimerVar)
 	p.registerPrefix(token.USING, p.parseUsingExpression)
 
 	// and infix elements
@@ -106,6 +123,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.GTE, p.parseInfixExpression)
 	p.registerInfix(token.LTE, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.XOR, p.parseInfixExpression)
+	p.registerInfix(token.EQV, p.parseInfixExpression)
+	p.registerInfix(token.IMP, p.parseInfixExpression)
 	p.registerInfix(token.RPAREN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
@@ -222,10 +245,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseContCommand()
 	case token.DATA:
 		return p.parseDataStatement()
+	case token.DEFDBL, token.DEFINT, token.DEFSNG, token.DEFSTR:
+		return p.parseDefTypeStatement()
 	case token.DIM:
 		return p.parseDimStatement()
 	case token.END:
 		return p.parseEndStatement()
+	case token.ERASE:
+		return p.parseEraseStatement()
 	case token.EOL:
 		// EOF means that was the last line
 		if p.peekTokenIs(token.EOF) {
@@ -235,10 +262,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return nil
 	case token.ERROR:
 		return p.parseErrorStatement()
+	case token.FIELD:
+		return p.parseFieldStatement()
 	case token.FILES:
 		return p.parseFilesCommand()
 	case token.FOR:
 		return p.parseForStatement()
+	case token.GET:
+		return p.parseGetStatement()
 	case token.GOSUB:
 		return p.parseGosubStatement()
 	case token.GOTO:
@@ -247,8 +278,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseIfStatement()
 	case token.KEY:
 		return p.parseKeyStatement()
+	case token.INPUT:
+		return p.parseInputStatement()
+	case token.KILL:
+		return p.parseKillStatement()
 	case token.LET:
 		return p.parseLetStatement()
+	case token.LINE:
+		return p.parseLineStatement()
 	case token.LINENUM:
 		return p.parseLineNumber()
 	case token.LIST:
@@ -257,6 +294,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLocateStatement()
 	case token.LOAD:
 		return p.parseLoadCommand()
+	case token.LSET, token.RSET:
+		return p.parseLsetStatement()
+	case token.MKDIR:
+		return p.parseMkDirStatement()
+	case token.NAME:
+		return p.parseNameStatement()
 	case token.NEW:
 		return p.parseNewCommand()
 	case token.NEXT:
@@ -265,12 +308,18 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseOnStatement()
 	case token.OPEN:
 		return p.parseOpenStatement()
+	case token.OPTION:
+		return p.parseOptionBaseStatement()
 	case token.PALETTE:
 		return p.parsePaletteStatement()
 	case token.PRINT:
 		return p.parsePrintStatement()
+	case token.PUT:
+		return p.parsePutStatement()
 	case token.READ:
 		return p.parseReadStatement()
+	case token.RANDOMIZE:
+		return p.parseRandomizeStatement()
 	case token.REM:
 		return p.parseRemStatement()
 	case token.RESTORE:
@@ -279,18 +328,34 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseResumeStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.RMDIR:
+		return p.parseRmDirStatement()
 	case token.RUN:
 		return p.parseRunCommand()
+	case token.SAVE:
+		return p.parseSaveCommand()
 	case token.SCREEN:
 		return p.parseScreenCommand()
 	case token.STOP:
 		return p.parseStopStatement()
+	case token.SWAP:
+		return p.parseSwapStatement()
+	case token.TIMER:
+		return p.parseTimerStatement()
 	case token.TROFF:
 		return p.parseTroffCommand()
 	case token.TRON:
 		return p.parseTronCommand()
 	case token.VIEW:
 		return p.parseViewStatement()
+	case token.WEND:
+		return p.parseWendStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.WIDTH:
+		return p.parseWidthStatement()
+	case token.WRITE:
+		return p.parseWriteStatement()
 	default:
 		// we get here with things that appear to be identifiers
 		// first check, is it a builtin function?
@@ -298,10 +363,15 @@ func (p *Parser) parseStatement() ast.Statement {
 			exp := ast.ExpressionStatement{Expression: p.parseBuiltinExpression()}
 			return &exp
 		}
+		// MID$ can also be on the left of the equal sign
+		if strings.EqualFold(p.curToken.Literal, "MID") && p.peekTokenIs(token.TYPE_STR) {
+			return p.parseMidStatement()
+		}
 		if strings.ContainsAny(p.peekToken.Literal, "=[($%!#") {
 			stmt := p.parseImpliedLetStatement(p.curToken.Literal)
 
-			if !p.checkForFuncCall() {
+			// a builtin at the end of the value doesn't make this a call, X = RND
+			if (stmt == nil) || (stmt.Value != nil) || !p.checkForFuncCall() {
 				return stmt
 			}
 			// yikes!  It is actually a function call
@@ -495,8 +565,15 @@ func (p *Parser) parseCloseStatement() *ast.CloseStatement {
 	// load up any parameters
 	for !p.chkEndOfStatement() {
 		p.nextToken()
+		if p.curTokenIs(token.COMMA) {
+			p.nextToken()
+		}
 		stmt.Files = append(stmt.Files, p.parseFileNumber())
 	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
 	return &stmt
 }
 
@@ -637,6 +714,66 @@ func (p *Parser) parseDataElement(elem string) ast.Expression {
 	return exp.Expression
 }
 
+// type characters for each of the DEFtype statements
+var defTypeIDs = map[token.TokenType]string{
+	token.DEFDBL: "#",
+	token.DEFINT: "%",
+	token.DEFSNG: "!",
+	token.DEFSTR: "$",
+}
+
+// parseDefTypeStatement reads the letter ranges, DEFINT I-N, S
+func (p *Parser) parseDefTypeStatement() ast.Statement {
+	defer untrace(trace("parseDefTypeStatement"))
+	stmt := &ast.DefTypeStatement{Token: p.curToken, TypeID: defTypeIDs[p.curToken.Type]}
+
+	for !p.chkEndOfStatement() {
+		first, ok := p.parseDefTypeLetter()
+		if !ok {
+			return nil
+		}
+		last := first
+
+		if p.peekTokenIs(token.MINUS) {
+			p.nextToken()
+			last, ok = p.parseDefTypeLetter()
+			if !ok || (last < first) {
+				p.reportError(berrors.Syntax)
+				return nil
+			}
+		}
+		stmt.Ranges = append(stmt.Ranges, [2]byte{first, last})
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if len(stmt.Ranges) == 0 {
+		p.reportError(berrors.Syntax)
+		return nil
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseDefTypeLetter expects the next token to be a single letter
+func (p *Parser) parseDefTypeLetter() (byte, bool) {
+	p.nextToken()
+	lit := strings.ToUpper(p.curToken.Literal)
+
+	if !p.curTokenIs(token.IDENT) || (len(lit) != 1) || (lit[0] < 'A') || (lit[0] > 'Z') {
+		p.reportError(berrors.Syntax)
+		return 0, false
+	}
+
+	return lit[0], true
+}
+
 func (p *Parser) parseDimStatement() *ast.DimStatement {
 	defer untrace(trace("parseDimStatement"))
 	exp := &ast.DimStatement{Token: p.curToken, Vars: []*ast.Identifier{}}
@@ -665,6 +802,36 @@ func (p *Parser) parseEndStatement() *ast.EndStatement {
 	return stmt
 }
 
+// ERASE followed by the array names
+func (p *Parser) parseEraseStatement() ast.Statement {
+	defer untrace(trace("parseEraseStatement"))
+	stmt := ast.EraseStatement{Token: p.curToken}
+
+	for !p.chkEndOfStatement() {
+		p.nextToken()
+		if !p.curTokenIs(token.IDENT) {
+			p.reportError(berrors.Syntax)
+			return nil
+		}
+		stmt.Vars = append(stmt.Vars, p.innerParseIdentifier())
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if len(stmt.Vars) == 0 {
+		p.reportError(berrors.Syntax)
+		return nil
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &stmt
+}
+
 // user wants to trigger an error condition
 func (p *Parser) parseErrorStatement() *ast.ErrorStatement {
 	err := ast.ErrorStatement{Token: p.curToken}
@@ -701,11 +868,32 @@ func (p *Parser) parseFileNumber() ast.FileNumber {
 		stmt.Token.Literal = p.curToken.Literal
 		p.nextToken()
 	}
-	stmt.Numbr = p.parseIntegerLiteral()
+	stmt.Numbr = p.parseExpression(LOWEST)
 
 	return stmt
 }
 
+// parseFilePrefix handles the "#n," that sends a statement to a file
+// on entry curToken is the '#', on exit it is the comma
+func (p *Parser) parseFilePrefix() *ast.FileNumber {
+	fn := &ast.FileNumber{Token: p.curToken}
+	p.nextToken()
+	fn.Numbr = p.parseExpression(LOWEST)
+
+	if !p.peekTokenIs(token.COMMA) {
+		p.reportError(berrors.Syntax)
+		return fn
+	}
+	p.nextToken()
+
+	return fn
+}
+
+// peekFilePrefix returns true if a file number is next
+func (p *Parser) peekFilePrefix() bool {
+	return p.peekToken.Literal == token.HASHTAG
+}
+
 // parse the begining of a FOR loop
 func (p *Parser) parseForStatement() *ast.ForStatment {
 	defer untrace(trace("parseForStatement"))
@@ -737,6 +925,140 @@ func (p *Parser) parseForStatement() *ast.ForStatment {
 	return &four
 }
 
+// INPUT$ is a builtin function, not the INPUT statement
+func (p *Parser) parseInputFunction() ast.Expression {
+	p.curToken.Type = token.IDENT
+	return p.parseIdentifier()
+}
+
+// parse an INPUT statement
+// INPUT[;] ["prompt";|,] variable[, variable...]
+func (p *Parser) parseInputStatement() *ast.InputStatement {
+	defer untrace(trace("parseInputStatement"))
+	stmt := &ast.InputStatement{Token: p.curToken, QMark: true}
+	p.nextToken()
+
+	// reading from a file, no prompting
+	if p.curToken.Literal == token.HASHTAG {
+		stmt.FileNum = p.parseFilePrefix()
+		p.nextToken()
+		stmt.Vars = p.parseInputVars()
+		return stmt
+	}
+
+	// a leading semi-colon keeps the cursor on the same line
+	if p.curTokenIs(token.SEMICOLON) {
+		stmt.SameLine = true
+		p.nextToken()
+	}
+
+	if !p.parseInputPrompt(&stmt.Prompt, &stmt.QMark) {
+		return stmt
+	}
+
+	stmt.Vars = p.parseInputVars()
+
+	return stmt
+}
+
+// parse the optional prompt string and the seperator that follows it
+// returns false if it didn't make sense
+func (p *Parser) parseInputPrompt(prompt *string, qmark *bool) bool {
+	if !p.curTokenIs(token.STRING) {
+		return true
+	}
+
+	*prompt = p.curToken.Literal
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.SEMICOLON:
+		*qmark = true
+	case token.COMMA:
+		*qmark = false
+	default:
+		p.reportError(berrors.Syntax)
+		return false
+	}
+	p.nextToken()
+
+	return true
+}
+
+// parse the list of variables to receive the input
+func (p *Parser) parseInputVars() []*ast.Identifier {
+	var vars []*ast.Identifier
+
+	for done := false; !done; {
+		if !p.curTokenIs(token.IDENT) {
+			p.reportError(berrors.Syntax)
+			return vars
+		}
+		vars = append(vars, p.innerParseIdentifier())
+
+		done = !p.peekTokenIs(token.COMMA)
+		if !done {
+			p.nextToken()
+			p.nextToken()
+		}
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return vars
+}
+
+// parse a statement that starts with LINE
+// currently, that can only be LINE INPUT
+func (p *Parser) parseLineStatement() ast.Statement {
+	defer untrace(trace("parseLineStatement"))
+	if !p.peekTokenIs(token.INPUT) {
+		p.reportError(berrors.Syntax)
+		stmt := &ast.LineInputStatement{Token: p.curToken}
+		p.skipRestOfStatement()
+		return stmt
+	}
+
+	return p.parseLineInputStatement()
+}
+
+// parse LINE INPUT[;] ["prompt";] string-variable
+func (p *Parser) parseLineInputStatement() *ast.LineInputStatement {
+	defer untrace(trace("parseLineInputStatement"))
+	stmt := &ast.LineInputStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Token.Literal += " " + p.curToken.Literal // winds up "LINE INPUT"
+	p.nextToken()
+
+	if p.curToken.Literal == token.HASHTAG {
+		stmt.FileNum = p.parseFilePrefix()
+		p.nextToken()
+	}
+
+	if p.curTokenIs(token.SEMICOLON) {
+		stmt.SameLine = true
+		p.nextToken()
+	}
+
+	var qmark bool
+	if !p.parseInputPrompt(&stmt.Prompt, &qmark) {
+		return stmt
+	}
+
+	vars := p.parseInputVars()
+
+	// only one variable allowed
+	if len(vars) != 1 {
+		p.reportError(berrors.Syntax)
+		return stmt
+	}
+	stmt.Var = vars[0]
+
+	return stmt
+}
+
 // parse an Integer Literal
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
@@ -820,15 +1142,8 @@ func (p *Parser) parseHexConstant() ast.Expression {
 func (p *Parser) parseIntDoubleLiteral() ast.Expression {
 	defer untrace(trace("parseIntDoubleLiteral"))
 
-	value, err := strconv.Atoi(strings.TrimRight(p.curToken.Literal, "#"))
-
-	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer in line %d", p.curToken.Literal, p.curLine)
-		p.errors = append(p.errors, msg)
-		return nil
-	}
-
-	return p.buildDoubleIIntegerLiteral(value)
+	// a number ending in # is a double precision constant
+	return p.parseDoubleFloatingPointLiteral(strings.TrimRight(p.curToken.Literal, "#"))
 }
 
 func (p *Parser) buildDoubleIIntegerLiteral(value int) ast.Expression {
@@ -982,6 +1297,11 @@ func (p *Parser) parsePrintStatement() *ast.PrintStatement {
 	defer untrace(trace("parsePrintStatement"))
 	stmt := &ast.PrintStatement{Token: p.curToken}
 
+	if p.peekFilePrefix() {
+		p.nextToken()
+		stmt.FileNum = p.parseFilePrefix()
+	}
+
 	for !p.chkEndOfStatement() {
 		p.nextToken()
 		stmt.Items = append(stmt.Items, p.parseExpression(LOWEST))
@@ -1058,11 +1378,16 @@ func (p *Parser) parseGotoStatement() *ast.GotoStatement {
 }
 
 // Key statement can come in many forms
-func (p *Parser) parseKeyStatement() *ast.KeyStatement {
+func (p *Parser) parseKeyStatement() ast.Statement {
 	defer untrace(trace("parseKeyStatement"))
 
 	stmt := &ast.KeyStatement{Token: p.curToken}
 
+	// KEY(n) is the event trapping form
+	if p.peekTokenIs(token.LPAREN) {
+		return p.parseKeyTrapStatement()
+	}
+
 	// if there is a parameter, save it
 	if !p.chkEndOfStatement() {
 		p.nextToken()
@@ -1082,6 +1407,49 @@ func (p *Parser) parseKeyStatement() *ast.KeyStatement {
 	return stmt
 }
 
+// KEY(n) ON, KEY(n) OFF or KEY(n) STOP
+func (p *Parser) parseKeyTrapStatement() *ast.KeyTrapStatement {
+	defer untrace(trace("parseKeyTrapStatement"))
+
+	stmt := &ast.KeyTrapStatement{Token: p.curToken}
+	stmt.Key = p.parseTrapParam()
+	stmt.Action = p.parseTrapAction()
+
+	return stmt
+}
+
+// collects the (n) that follows KEY or TIMER
+func (p *Parser) parseTrapParam() ast.Expression {
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return exp
+}
+
+// gets the ON, OFF or STOP for a trap
+// returns empty string if it isn't there
+func (p *Parser) parseTrapAction() string {
+	action := ""
+	switch p.peekToken.Type {
+	case token.ON, token.OFF, token.STOP:
+		p.nextToken()
+		action = strings.ToUpper(p.curToken.Literal)
+	}
+
+	if p.peekTokenIs(token.COLON) { // if a colon follows consume it
+		p.nextToken()
+	}
+
+	return action
+}
+
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	defer untrace(trace("parseLetStatement"))
 
@@ -1185,6 +1553,64 @@ func (p *Parser) parseLoadCommandRunOption(cmd *ast.LoadCommand) *ast.LoadComman
 	return nil
 }
 
+// KILL "filespec"
+func (p *Parser) parseKillStatement() *ast.KillStatement {
+	defer untrace(trace("parseKillStatement"))
+	stmt := ast.KillStatement{Token: p.curToken}
+	stmt.Path = p.parsePathParameter()
+	return &stmt
+}
+
+// MKDIR "path"
+func (p *Parser) parseMkDirStatement() *ast.MkDirStatement {
+	defer untrace(trace("parseMkDirStatement"))
+	stmt := ast.MkDirStatement{Token: p.curToken}
+	stmt.Path = p.parsePathParameter()
+	return &stmt
+}
+
+// NAME "oldname" AS "newname"
+func (p *Parser) parseNameStatement() *ast.NameStatement {
+	defer untrace(trace("parseNameStatement"))
+	stmt := ast.NameStatement{Token: p.curToken, OldName: &ast.StringLiteral{}, NewName: &ast.StringLiteral{}}
+
+	if p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+		return &stmt
+	}
+
+	p.nextToken()
+	stmt.OldName = p.parseExpression(LOWEST)
+
+	if !strings.EqualFold(p.peekToken.Literal, token.AS) {
+		p.reportError(berrors.Syntax)
+		p.skipRestOfStatement()
+		return &stmt
+	}
+	p.nextToken()
+
+	stmt.NewName = p.parsePathParameter()
+
+	return &stmt
+}
+
+// parsePathParameter parses the file or directory name for the disk statements
+func (p *Parser) parsePathParameter() ast.Expression {
+	if p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+		return &ast.StringLiteral{}
+	}
+
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return exp
+}
+
 // parseNewCommand, a very simple thing to do
 func (p *Parser) parseNewCommand() *ast.NewCommand {
 	defer untrace(trace("parseNewCommand"))
@@ -1246,6 +1672,10 @@ func (p *Parser) parseOnStatement() ast.Statement {
 	switch p.peekToken.Type {
 	case token.ERROR:
 		return p.parseOnErrorStatement()
+	case token.KEY:
+		return p.parseOnKeyStatement()
+	case token.TIMER:
+		return p.parseOnTimerStatement()
 	}
 
 	// should be an expression followed by GOTO/GOSUB
@@ -1288,6 +1718,44 @@ func (p *Parser) parseOnErrorStatement() ast.Statement {
 	return oer
 }
 
+// parse ON KEY(n) GOSUB line
+func (p *Parser) parseOnKeyStatement() ast.Statement {
+	stmt := &ast.OnKeyStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Key = p.parseTrapParam()
+	stmt.Jump = p.parseTrapGosub()
+
+	return stmt
+}
+
+// parse ON TIMER(n) GOSUB line
+func (p *Parser) parseOnTimerStatement() ast.Statement {
+	stmt := &ast.OnTimerStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Interval = p.parseTrapParam()
+	stmt.Jump = p.parseTrapGosub()
+
+	return stmt
+}
+
+// gets the GOSUB line number for an event trap
+func (p *Parser) parseTrapGosub() int {
+	if !p.expectPeek(token.GOSUB) || !p.expectPeek(token.INT) {
+		return 0
+	}
+
+	line, err := strconv.Atoi(p.curToken.Literal)
+	if err != nil {
+		line = 0
+	}
+
+	if p.peekTokenIs(token.COLON) { // if a colon follows consume it
+		p.nextToken()
+	}
+
+	return line
+}
+
 // could be either ON exp GOTO, or ON exp GOSUB
 func (p *Parser) parseOnExpressionStatement() ast.Statement {
 	// create the statment and start building the parameters
@@ -1314,26 +1782,66 @@ func (p *Parser) parseOnExpressionStatement() ast.Statement {
 	return &stmt
 }
 
-// establish i/o with a file or device[ToDo]
-func (p *Parser) parseOpenStatement() *ast.OpenStatement {
-	stmt := ast.OpenStatement{Token: p.curToken}
+// OPTION BASE 0 or OPTION BASE 1
+func (p *Parser) parseOptionBaseStatement() ast.Statement {
+	defer untrace(trace("parseOptionBaseStatement"))
+	stmt := ast.OptionBaseStatement{Token: p.curToken}
 
+	if !p.peekTokenIs(token.IDENT) || !strings.EqualFold(p.peekToken.Literal, "BASE") {
+		p.reportError(berrors.Syntax)
+		return nil
+	}
 	p.nextToken()
-	switch p.curToken.Type {
-	case token.IDENT:
-		stmt.Verbose = false
-		stmt.Mode = p.curToken.Literal
-		p.nextToken()
+
+	if !p.peekTokenIs(token.INT) || ((p.peekToken.Literal != "0") && (p.peekToken.Literal != "1")) {
+		p.reportError(berrors.Syntax)
+		return nil
+	}
+	p.nextToken()
+	stmt.Base = int16(p.curToken.Literal[0] - '0')
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &stmt
+}
+
+// establish i/o with a file or device[ToDo]
+func (p *Parser) parseOpenStatement() *ast.OpenStatement {
+	stmt := ast.OpenStatement{Token: p.curToken}
+
+	p.nextToken()
+	switch {
+	case p.atEndOfStatement():
+		// no file name, leave it for the evaluator to reject
+	case p.curTokenIs(token.IDENT) && p.parseOpenIsBrief():
+		stmt.Verbose = false
+		stmt.Mode = p.curToken.Literal
+		p.nextToken()
+		p.parseOpenStatementBrief(&stmt)
+	case p.curTokenIs(token.STRING) && p.peekTokenIs(token.COMMA):
+		// OPEN "O", #1, "file" is the short form with the mode in quotes
+		stmt.Mode = p.curToken.Literal
+		stmt.QuotedMode = true
+		p.nextToken()
 		p.parseOpenStatementBrief(&stmt)
-	case token.STRING:
+	default:
+		// the file name can be any expression, OPEN F$ FOR INPUT AS #1
 		stmt.Verbose = true
-		stmt.FileName = p.curToken.Literal
+		stmt.FileName = p.parseExpression(LOWEST)
 		p.nextToken()
 		p.parseOpenStatementVerbose(&stmt)
 	}
 	return &stmt
 }
 
+// parseOpenIsBrief decides if the identifier after OPEN is the mode
+// of the short form, the mode is followed by the file number
+func (p *Parser) parseOpenIsBrief() bool {
+	return p.peekTokenIs(token.COMMA) || strings.EqualFold(p.peekToken.Literal, token.HASHTAG)
+}
+
 // open statement in the short form
 // short form looks like OPEN mode, [#]1, "Name.ext" [, reclen]
 // parseOpenStatement found the mode, so we move on from there
@@ -1342,7 +1850,7 @@ func (p *Parser) parseOpenStatementBrief(stmt *ast.OpenStatement) {
 	// if the mode parameter isn't followed by a comma
 	// it all becomes Trash
 	if !strings.EqualFold(p.curToken.Literal, token.COMMA) {
-		p.parseTrash(&stmt.Trash)
+		p.parseOpenTrash(stmt)
 		return
 	}
 	p.nextToken()
@@ -1354,30 +1862,33 @@ func (p *Parser) parseOpenStatementBrief(stmt *ast.OpenStatement) {
 	}
 
 	// evalutor will figure out if filenum is vlaid
-	stmt.FileNumber = ast.FileNumber{Token: p.curToken}
+	stmt.FileNumber = ast.FileNumber{Numbr: p.parseExpression(LOWEST)}
 	p.nextToken()
 
 	if !strings.EqualFold(p.curToken.Literal, token.COMMA) {
-		p.parseTrash(&stmt.Trash)
+		p.parseOpenTrash(stmt)
 		return
 	}
 	p.nextToken()
 
-	// filename
-
-	stmt.FileName = p.curToken.Literal
+	// filename, which can be any expression
+	stmt.FileName = p.parseExpression(LOWEST)
 	p.nextToken()
 
 	// if no comma, I'm either at end of statement
 	// or it is all Trash
 	if !strings.EqualFold(p.curToken.Literal, token.COMMA) {
-		p.parseTrash(&stmt.Trash)
+		p.parseOpenTrash(stmt)
 		return
 	}
 
 	// pick up record length
 	p.nextToken()
-	stmt.RecLen = p.curToken.Literal
+	p.parseOpenRecLen(stmt)
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
 }
 
 // the long version of file open
@@ -1422,7 +1933,7 @@ func (p *Parser) parseVerboseLock(stmt *ast.OpenStatement) {
 			p.nextToken()
 		}
 
-		stmt.FileNumber.Token = p.curToken
+		stmt.FileNumber.Numbr = p.parseExpression(LOWEST)
 		p.nextToken()
 	}
 
@@ -1430,7 +1941,7 @@ func (p *Parser) parseVerboseLock(stmt *ast.OpenStatement) {
 }
 
 // parseVerboseLen looks for a "LEN=nnn" modifier on the open
-// it then calls parseTrash to hoover up any left over tokens
+// it then calls parseOpenTrash to hoover up any left over tokens
 func (p *Parser) parseVerboseLen(stmt *ast.OpenStatement) {
 	// if there is a length parameter, consume it
 	if strings.EqualFold(p.curToken.Literal, token.LEN) {
@@ -1438,15 +1949,37 @@ func (p *Parser) parseVerboseLen(stmt *ast.OpenStatement) {
 
 		if !strings.EqualFold(p.curToken.Literal, `=`) {
 			stmt.Trash = append(stmt.Trash, ast.TrashStatement{Token: token.Token{Type: token.LEN}})
-			p.parseTrash(&stmt.Trash)
+			p.parseOpenTrash(stmt)
 			return
 		}
 		p.nextToken()
-		stmt.RecLen = p.curToken.Literal
+		p.parseOpenRecLen(stmt)
 		p.nextToken()
 	}
 
-	// call parseTrash to consume any left over tokens in the statement
+	// call parseOpenTrash to consume any left over tokens in the statement
+	p.parseOpenTrash(stmt)
+}
+
+// parseOpenRecLen picks up the record length, keeping any minus sign
+// so that the evaluator can reject it
+func (p *Parser) parseOpenRecLen(stmt *ast.OpenStatement) {
+	if p.curTokenIs(token.MINUS) {
+		p.nextToken()
+		stmt.RecLen = token.MINUS + p.curToken.Literal
+		return
+	}
+
+	stmt.RecLen = p.curToken.Literal
+}
+
+// parseOpenTrash collects anything left over at the end of an open
+// if another statement follows, there is nothing left to collect
+func (p *Parser) parseOpenTrash(stmt *ast.OpenStatement) {
+	if p.atEndOfStatement() {
+		return
+	}
+
 	p.parseTrash(&stmt.Trash)
 }
 
@@ -1553,6 +2086,22 @@ func (p *Parser) parseRestoreStatement() *ast.RestoreStatement {
 	return &stmt
 }
 
+// RANDOMIZE with an optional seed
+func (p *Parser) parseRandomizeStatement() *ast.RandomizeStatement {
+	stmt := ast.RandomizeStatement{Token: p.curToken}
+
+	if !p.peekTokenIs(token.LINENUM) && !p.peekTokenIs(token.EOF) && !p.peekTokenIs(token.EOL) && !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		stmt.Seed = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &stmt
+}
+
 // user wishes to resume excution
 func (p *Parser) parseResumeStatement() *ast.ResumeStatement {
 	stmt := ast.ResumeStatement{Token: p.curToken}
@@ -1604,6 +2153,14 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 }
 
 // Run commands come in two forms
+// RMDIR "path"
+func (p *Parser) parseRmDirStatement() *ast.RmDirStatement {
+	defer untrace(trace("parseRmDirStatement"))
+	stmt := ast.RmDirStatement{Token: p.curToken}
+	stmt.Path = p.parsePathParameter()
+	return &stmt
+}
+
 // RUN [line number][,r]
 // RUN filename[,r]
 func (p *Parser) parseRunCommand() *ast.RunCommand {
@@ -1650,6 +2207,42 @@ func (p *Parser) parseRunValidFlag(cmd ast.RunCommand) *ast.RunCommand {
 	return &cmd
 }
 
+// SAVE filename[,A|,P]
+func (p *Parser) parseSaveCommand() *ast.SaveCommand {
+	defer untrace(trace("parseSaveCommand"))
+	cmd := ast.SaveCommand{Token: p.curToken}
+
+	p.nextToken()
+	cmd.Path = p.parseExpression(LOWEST)
+
+	// if there isn't a comma, save it tokenized
+	if p.peekTokenIs(token.COMMA) {
+		p.parseSaveCommandFormat(&cmd)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &cmd
+}
+
+// parseSaveCommandFormat, only called if the format is present
+func (p *Parser) parseSaveCommandFormat(cmd *ast.SaveCommand) *ast.SaveCommand {
+	p.nextToken()
+	p.nextToken()
+
+	opt := strings.ToUpper(p.curToken.Literal)
+	if (opt == "A") || (opt == "P") {
+		cmd.Format = opt
+		return cmd
+	}
+
+	// only ASCII or protected are allowed
+	p.reportError(berrors.Syntax)
+	return cmd
+}
+
 // ScreenStatement allows user to configure screen mode for
 // different display adapters.  MDA,CGA,EGA and such
 func (p *Parser) parseScreenCommand() *ast.ScreenStatement {
@@ -1681,6 +2274,38 @@ func (p *Parser) parseScreenCommandParameters(stmt ast.ScreenStatement) *ast.Scr
 }
 
 // not much to do, just return a StopStatement object
+// TIMER ON, TIMER OFF or TIMER STOP
+func (p *Parser) parseTimerStatement() *ast.TimerStatement {
+	defer untrace(trace("parseTimerStatement"))
+
+	stmt := &ast.TimerStatement{Token: p.curToken}
+	stmt.Action = p.parseTrapAction()
+
+	return stmt
+}
+
+// SWAP needs two variables
+func (p *Parser) parseSwapStatement() ast.Statement {
+	defer untrace(trace("parseSwapStatement"))
+	stmt := ast.SwapStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Left = p.innerParseIdentifier()
+
+	if !p.expectPeek(token.COMMA) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Right = p.innerParseIdentifier()
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &stmt
+}
+
 func (p *Parser) parseStopStatement() *ast.StopStatement {
 	defer untrace(trace("parseStopStatement"))
 	stmt := ast.StopStatement{Token: p.curToken}
@@ -1697,9 +2322,19 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	defer untrace(trace("parseIdentifier"))
 	exp := p.innerParseIdentifier()
 
+	// some builtins are used like variables, DATE$ or RND
+	if builtins.NoArgs[exp.Value] || (builtins.OptionalArgs[exp.Value] && !p.peekTokenIs(token.LPAREN)) {
+		return &ast.BuiltinExpression{Token: exp.Token}
+	}
+
 	return exp
 }
 
+// TIMER in an expression is the builtin, not the statement
+func (p *Parser) parseTimerVar() ast.Expression {
+	return &ast.BuiltinExpression{Token: p.curToken}
+}
+
 // innerParseIdentifier is called from many other statements consume identifiers
 func (p *Parser) innerParseIdentifier() *ast.Identifier {
 	defer untrace(trace("innerParseIdentifier"))
@@ -2019,10 +2654,17 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	defer untrace(trace("parsePrefixExpression"))
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: strings.ToUpper(p.curToken.Literal),
 	}
+
+	// NOT takes in everything down to the relational operators
+	precedence := PREFIX
+	if p.curTokenIs(token.NOT) {
+		precedence = LOGICALNOT
+	}
+
 	p.nextToken()
-	expression.Right = p.parseExpression(PREFIX)
+	expression.Right = p.parseExpression(precedence)
 	return expression
 }
 
@@ -2030,7 +2672,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	defer untrace(trace("parseInfixExpression"))
 	expression := &ast.InfixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: strings.ToUpper(p.curToken.Literal),
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
@@ -2205,3 +2847,280 @@ func (p *Parser) parseViewPrintStatement() ast.Statement {
 
 	return &vp
 }
+
+// parse the WEND that closes a WHILE loop
+func (p *Parser) parseWendStatement() *ast.WendStatement {
+	defer untrace(trace("parseWendStatement"))
+	wend := ast.WendStatement{Token: p.curToken}
+
+	if !p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &wend
+}
+
+// parse the start of a WHILE loop and its condition
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	defer untrace(trace("parseWhileStatement"))
+	whl := ast.WhileStatement{Token: p.curToken}
+
+	if p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+		return &whl
+	}
+
+	p.nextToken()
+	whl.Condition = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &whl
+}
+
+// parse WIDTH size, WIDTH #n, size or WIDTH device, size
+func (p *Parser) parseWidthStatement() ast.Statement {
+	defer untrace(trace("parseWidthStatement"))
+	stmt := &ast.WidthStatement{Token: p.curToken}
+
+	if p.peekFilePrefix() {
+		p.nextToken()
+		stmt.FileNum = p.parseFilePrefix()
+	}
+
+	for !p.chkEndOfStatement() {
+		p.nextToken()
+		stmt.Params = append(stmt.Params, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	// a file only gets a size, the screen or a device can have two
+	most := 2
+	if stmt.FileNum != nil {
+		most = 1
+	}
+
+	if (len(stmt.Params) == 0) || (len(stmt.Params) > most) || p.curTokenIs(token.COMMA) {
+		p.reportError(berrors.Syntax)
+		return nil
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parse WRITE [#n,] expression list
+func (p *Parser) parseWriteStatement() *ast.WriteStatement {
+	defer untrace(trace("parseWriteStatement"))
+	stmt := &ast.WriteStatement{Token: p.curToken}
+
+	if p.peekFilePrefix() {
+		p.nextToken()
+		stmt.FileNum = p.parseFilePrefix()
+	}
+
+	// items can be separated by commas or semicolons
+	for !p.chkEndOfStatement() {
+		p.nextToken()
+		stmt.Items = append(stmt.Items, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.COMMA) && !p.peekTokenIs(token.SEMICOLON) {
+			break
+		}
+		p.nextToken()
+
+		// the list can't end with a separator
+		if p.chkEndOfStatement() {
+			p.reportError(berrors.Syntax)
+		}
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parse FIELD [#]n, width AS var$ [, width AS var$]...
+func (p *Parser) parseFieldStatement() *ast.FieldStatement {
+	defer untrace(trace("parseFieldStatement"))
+	stmt := &ast.FieldStatement{Token: p.curToken}
+
+	if p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+		return stmt
+	}
+	stmt.FileNum = p.parseRecordFile()
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		item := &ast.FieldItem{Width: p.parseExpression(LOWEST)}
+
+		if !strings.EqualFold(p.peekToken.Literal, token.AS) {
+			p.reportError(berrors.Syntax)
+			return stmt
+		}
+		p.nextToken()
+
+		if !p.peekTokenIs(token.IDENT) {
+			p.reportError(berrors.Syntax)
+			return stmt
+		}
+		p.nextToken()
+		item.Var = p.innerParseIdentifier()
+		stmt.Fields = append(stmt.Fields, item)
+	}
+
+	if (len(stmt.Fields) == 0) || !p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parse GET [#]n [, record]
+func (p *Parser) parseGetStatement() *ast.GetStatement {
+	defer untrace(trace("parseGetStatement"))
+	stmt := &ast.GetStatement{Token: p.curToken}
+
+	stmt.FileNum, stmt.Record = p.parseRecordParams()
+
+	return stmt
+}
+
+// parse PUT [#]n [, record]
+func (p *Parser) parsePutStatement() *ast.PutStatement {
+	defer untrace(trace("parsePutStatement"))
+	stmt := &ast.PutStatement{Token: p.curToken}
+
+	stmt.FileNum, stmt.Record = p.parseRecordParams()
+
+	return stmt
+}
+
+// parseRecordParams gets the file number and optional record number for GET and PUT
+func (p *Parser) parseRecordParams() (*ast.FileNumber, ast.Expression) {
+	if p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+		return nil, nil
+	}
+	fn := p.parseRecordFile()
+
+	var rec ast.Expression
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		rec = p.parseExpression(LOWEST)
+	}
+
+	if !p.chkEndOfStatement() {
+		p.reportError(berrors.Syntax)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return fn, rec
+}
+
+// parseRecordFile reads the [#]n that starts FIELD, GET and PUT
+// on entry curToken is the statement keyword
+func (p *Parser) parseRecordFile() *ast.FileNumber {
+	p.nextToken()
+	fn := &ast.FileNumber{}
+
+	if p.curToken.Literal == token.HASHTAG {
+		fn.Token = p.curToken
+		p.nextToken()
+	}
+	fn.Numbr = p.parseExpression(LOWEST)
+
+	return fn
+}
+
+// MID$(A$, start[, length]) = value
+func (p *Parser) parseMidStatement() ast.Statement {
+	defer untrace(trace("parseMidStatement"))
+	stmt := ast.MidStatement{Token: token.Token{Type: token.IDENT, Literal: "MID$"}}
+	p.nextToken()
+
+	if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.innerParseIdentifier()
+
+	if !p.expectPeek(token.COMMA) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Start = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Length = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	return &stmt
+}
+
+// parse LSET var$ = expression, or RSET
+func (p *Parser) parseLsetStatement() ast.Statement {
+	defer untrace(trace("parseLsetStatement"))
+	tk := p.curToken
+	name := &ast.Identifier{}
+	var val ast.Expression = &ast.StringLiteral{}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		name = p.innerParseIdentifier()
+	}
+
+	if !p.peekTokenIs(token.ASSIGN) {
+		p.reportError(berrors.Syntax)
+		p.skipRestOfStatement()
+	} else {
+		p.nextToken()
+		p.nextToken()
+		val = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+	}
+
+	if tk.Type == token.RSET {
+		return &ast.RsetStatement{Token: tk, Name: name, Value: val}
+	}
+	return &ast.LsetStatement{Token: tk, Name: name, Value: val}
+}
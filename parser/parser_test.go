@@ -91,8 +91,12 @@ func Test_BeepStatement(t *testing.T) {
 func Test_BuiltinExpression(t *testing.T) {
 	tests := []struct {
 		inp string
+		exp string
 	}{
-		{inp: `ABS(5)`},
+		{inp: `ABS(5)`, exp: " = ABS(5)"},
+		{inp: `LEN(DATE$)`, exp: " = LEN(DATE$)"},
+		{inp: `LEN(TIME$)`, exp: " = LEN(TIME$)"},
+		{inp: `INT(TIMER)`, exp: " = INT(TIMER)"},
 	}
 
 	for _, tt := range tests {
@@ -113,7 +117,7 @@ func Test_BuiltinExpression(t *testing.T) {
 		exp, ok := stmt.(*ast.ExpressionStatement)
 		assert.True(t, ok, "Test_BuiltinExpression didn't get ExpressionStatement")
 
-		assert.Equal(t, " = ABS(5)", exp.String(), "unexpected Builtin")
+		assert.Equal(t, tt.exp, exp.String(), "unexpected Builtin")
 	}
 }
 
@@ -493,6 +497,46 @@ func Test_DataStatement(t *testing.T) {
 	}
 }
 
+func Test_DefTypeStatement(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		tid string
+		err bool
+	}{
+		{inp: `10 DEFINT I-N`, exp: "DEFINT I-N", tid: "%"},
+		{inp: `10 DEFSTR S : PRINT`, exp: "DEFSTR S", tid: "$"},
+		{inp: `10 defdbl a-c, x, y-z`, exp: "DEFDBL A-C, X, Y-Z", tid: "#"},
+		{inp: `10 DEFSNG A-Z`, exp: "DEFSNG A-Z", tid: "!"},
+		{inp: `10 DEFINT`, err: true},
+		{inp: `10 DEFINT AB`, err: true},
+		{inp: `10 DEFINT N-I`, err: true},
+		{inp: `10 DEFINT A-5`, err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s didn't give an error", tt.inp)
+			continue
+		}
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		itr.Next()
+		stmt, ok := itr.Value().(*ast.DefTypeStatement)
+
+		if assert.Truef(t, ok, "%s didn't give a DefTypeStatement", tt.inp) {
+			assert.Equal(t, tt.exp, stmt.String(), "%s parsed incorrectly", tt.inp)
+			assert.Equal(t, tt.tid, stmt.TypeID, "%s has wrong type", tt.inp)
+		}
+	}
+}
+
 func TestDimStatement(t *testing.T) {
 	type dimensions struct {
 		id   string
@@ -571,6 +615,39 @@ func TestDimStatement(t *testing.T) {
 	}
 }
 
+func Test_EraseStatement(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool
+	}{
+		{inp: `10 ERASE A`, exp: "ERASE A"},
+		{inp: `10 ERASE A, B$ : PRINT`, exp: "ERASE A, B$"},
+		{inp: `10 ERASE`, err: true},
+		{inp: `10 ERASE 5`, err: true},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.inp))
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s didn't give an error", tt.inp)
+			continue
+		}
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		itr.Next()
+		stmt, ok := itr.Value().(*ast.EraseStatement)
+
+		if assert.Truef(t, ok, "%s didn't give an EraseStatement", tt.inp) {
+			assert.Equal(t, tt.exp, stmt.String(), "%s parsed incorrectly", tt.inp)
+		}
+	}
+}
+
 func Test_ErrorStatement(t *testing.T) {
 	tests := []struct {
 		inp string
@@ -637,12 +714,49 @@ func Test_KeyStatement(t *testing.T) {
 	}
 }
 
+func Test_KeyTrapStatement(t *testing.T) {
+	tests := []struct {
+		inp    string
+		key    string
+		action string
+		stmts  int
+	}{
+		{inp: `10 KEY(1) ON`, key: "1", action: "ON", stmts: 2},
+		{inp: `10 key(2) off`, key: "2", action: "OFF", stmts: 2},
+		{inp: `10 KEY(N+1) STOP : PRINT`, key: "N + 1", action: "STOP", stmts: 3},
+		{inp: `10 KEY(1)`, key: "1", stmts: 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		assert.Equal(t, tt.stmts, itr.Len(), "%s has wrong statement count", tt.inp)
+		itr.Next()
+		key, ok := itr.Value().(*ast.KeyTrapStatement)
+
+		assert.True(t, ok, "%s was not a KeyTrapStatement", tt.inp)
+		if ok {
+			assert.Equal(t, tt.key, key.Key.String(), "%s got the wrong key", tt.inp)
+			assert.Equal(t, tt.action, key.Action, "%s got the wrong action", tt.inp)
+		}
+	}
+}
+
 func Test_LetStatementImplied(t *testing.T) {
 	tests := []struct {
 		inp string
 		exp []string
 	}{
 		{inp: `10 X = 5: Y = 20`, exp: []string{` X = 5`, ` Y = 20`}},
+		{inp: `10 X = RND`, exp: []string{` X = RND`}},
+		{inp: `10 X = 5 * RND(1)`, exp: []string{` X = 5 * RND(1)`}},
+		{inp: `10 X = TIMER`, exp: []string{` X = TIMER`}},
 	}
 
 	for _, tt := range tests {
@@ -1033,6 +1147,11 @@ func TestOnStatement(t *testing.T) {
 		{inp: "10 ON ERROR GOTO 10000000000000000000", exp: "ON ERROR GOTO", jmp: 0},
 		{inp: "10 ON X GOTO 100, 200, 300", exp: "ON X GOTO 100, 200, 300"},
 		{inp: "10 ON X GOSUB 100, 200, 300", exp: "ON X GOSUB 100, 200, 300"},
+		{inp: "10 ON KEY(1) GOSUB 100", exp: "ON KEY(1) GOSUB 100", jmp: 100},
+		{inp: "10 on key(14) gosub 0", exp: "ON KEY(14) GOSUB 0"},
+		{inp: "10 ON KEY(1) GOTO 100", exp: "ON KEY(1) GOSUB 0"},
+		{inp: "10 ON TIMER(60) GOSUB 500", exp: "ON TIMER(60) GOSUB 500", jmp: 500},
+		{inp: "10 ON TIMER(N) GOSUB 500 : TIMER ON", exp: "ON TIMER(N) GOSUB 500", jmp: 500},
 	}
 
 	for _, tt := range tests {
@@ -1047,7 +1166,47 @@ func TestOnStatement(t *testing.T) {
 		case *ast.OnErrorGoto:
 			assert.EqualValues(t, tt.exp, stmt.String(), "ON ERROR parse fail")
 			assert.EqualValues(t, tt.jmp, stmt.Jump, "got the wrong line")
+		case *ast.OnKeyStatement:
+			assert.EqualValues(t, tt.exp, stmt.String(), "ON KEY parse fail")
+			assert.EqualValues(t, tt.jmp, stmt.Jump, "got the wrong line")
+		case *ast.OnTimerStatement:
+			assert.EqualValues(t, tt.exp, stmt.String(), "ON TIMER parse fail")
+			assert.EqualValues(t, tt.jmp, stmt.Jump, "got the wrong line")
+
+		}
+	}
+}
+
+func Test_OptionBaseStatement(t *testing.T) {
+	tests := []struct {
+		inp  string
+		base int16
+		err  bool
+	}{
+		{inp: `10 OPTION BASE 0`, base: 0},
+		{inp: `10 option base 1 : PRINT`, base: 1},
+		{inp: `10 OPTION BASE 2`, err: true},
+		{inp: `10 OPTION 1`, err: true},
+		{inp: `10 OPTION BASE X`, err: true},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.inp))
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s didn't give an error", tt.inp)
+			continue
+		}
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		itr.Next()
+		stmt, ok := itr.Value().(*ast.OptionBaseStatement)
 
+		if assert.Truef(t, ok, "%s didn't give an OptionBaseStatement", tt.inp) {
+			assert.Equal(t, tt.base, stmt.Base, "%s parsed incorrectly", tt.inp)
 		}
 	}
 }
@@ -1072,6 +1231,30 @@ func TestOpenStatement(t *testing.T) {
 		// this next one would eval to a syntax error
 		{inp: `60 open "test3.out" FOR OUTPUT ACCESS WRITE LOCK READ AS #3 LEN = 128`,
 			exp: `open "test3.out" FOR OUTPUT ACCESS WRITE LOCK READ AS # 3 LEN = 128`},
+		// another statement after the open isn't trash
+		{inp: `70 open "test4.out" FOR INPUT AS #4 : CLOSE`,
+			exp: `open "test4.out" FOR INPUT AS #4`},
+		{inp: `80 open i, #5, "test5.out" : CLOSE`,
+			exp: `open i, #5, "test5.out"`},
+		// the file number can be any expression
+		{inp: `90 open "test6.out" FOR INPUT AS #F + 1`,
+			exp: `open "test6.out" FOR INPUT AS #F + 1`},
+		{inp: `100 open o, #F * 2, "test7.out"`,
+			exp: `open o, #F * 2, "test7.out"`},
+		// a negative record length is left for the evaluator to reject
+		{inp: `110 open "test8.out" FOR RANDOM AS #1 LEN = -5`,
+			exp: `open "test8.out" FOR RANDOM AS #1 LEN = -5`},
+		{inp: `120 open r, #1, "test9.out", -5`,
+			exp: `open r, #1, "test9.out",-5`},
+		// so can the file name
+		{inp: `130 OPEN F$ FOR APPEND AS #1`,
+			exp: `OPEN F$ FOR APPEND AS #1`},
+		{inp: `140 OPEN "O",#1,N$`,
+			exp: `OPEN "O", #1, N$`},
+		{inp: `150 OPEN D$ + "\LOG.TXT" FOR OUTPUT AS #2 : CLOSE`,
+			exp: `OPEN D$ + "\LOG.TXT" FOR OUTPUT AS #2`},
+		{inp: `160 OPEN I, 3, F$(2), 64`,
+			exp: `OPEN I, 3, F$(2),64`},
 	}
 
 	for _, tt := range tests {
@@ -1212,6 +1395,36 @@ func Test_RemStatement(t *testing.T) {
 	}
 }
 
+func Test_RandomizeStatement(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+	}{
+		{inp: `10 RANDOMIZE`, exp: "RANDOMIZE"},
+		{inp: `10 RANDOMIZE : PRINT`, exp: "RANDOMIZE"},
+		{inp: `10 RANDOMIZE 42`, exp: "RANDOMIZE 42"},
+		{inp: `10 RANDOMIZE TIMER`, exp: "RANDOMIZE TIMER"},
+		{inp: `10 RANDOMIZE -X + 1 : PRINT`, exp: "RANDOMIZE -X + 1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		itr.Next()
+		stmt, ok := itr.Value().(*ast.RandomizeStatement)
+
+		assert.Truef(t, ok, "%s didn't give a RandomizeStatement", tt.inp)
+		if ok {
+			assert.Equal(t, tt.exp, stmt.String(), "%s parsed incorrectly", tt.inp)
+		}
+	}
+}
+
 func TestRestore(t *testing.T) {
 	rsTk := token.Token{Type: token.RESTORE, Literal: "RESTORE"}
 
@@ -1335,6 +1548,38 @@ func Test_StopStatement(t *testing.T) {
 	assert.Equal(t, token.STOP, stmt.Token.Literal)
 }
 
+func Test_TimerStatement(t *testing.T) {
+	tests := []struct {
+		inp    string
+		action string
+		stmts  int
+	}{
+		{inp: `10 TIMER ON`, action: "ON", stmts: 2},
+		{inp: `10 timer stop : PRINT`, action: "STOP", stmts: 3},
+		{inp: `10 TIMER OFF`, action: "OFF", stmts: 2},
+		{inp: `10 TIMER`, stmts: 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		checkParserErrors(t, p)
+
+		itr := env.StatementIter()
+		assert.Equal(t, tt.stmts, itr.Len(), "%s has wrong statement count", tt.inp)
+		itr.Next()
+		tm, ok := itr.Value().(*ast.TimerStatement)
+
+		assert.True(t, ok, "%s was not a TimerStatement", tt.inp)
+		if ok {
+			assert.Equal(t, tt.action, tm.Action, "%s got the wrong action", tt.inp)
+		}
+	}
+}
+
 func Test_StringLiteralExpression(t *testing.T) {
 	input := `10 "hello world"`
 	l := lexer.New(input)
@@ -1441,7 +1686,9 @@ func Test_UsingRunTime(t *testing.T) {
 func TestIntegerLiteralExpression(t *testing.T) {
 	intTok := token.Token{Type: token.INT, Literal: "5"}
 	dblTok := token.Token{Type: token.INTD, Literal: "65999"}
+	dblFltTok := token.Token{Type: token.INTD, Literal: "65999#"}
 	fltTok := token.Token{Type: token.FLOAT, Literal: "4294967295"}
+	fixDblTok := token.Token{Type: token.INTD, Literal: "1.5#"}
 
 	tests := []struct {
 		inp   string
@@ -1449,7 +1696,9 @@ func TestIntegerLiteralExpression(t *testing.T) {
 		lit   interface{}
 	}{
 		{`10 5`, 2, &ast.IntegerLiteral{Value: 5, Token: intTok}},
-		{`20 65999#`, 2, &ast.DblIntegerLiteral{Value: 65999, Token: dblTok}},
+		{`20 65999`, 2, &ast.DblIntegerLiteral{Value: 65999, Token: dblTok}},
+		{`25 65999#`, 2, &ast.FloatDoubleLiteral{Value: 65999, Token: dblFltTok}},
+		{`26 1.5#`, 2, &ast.FloatDoubleLiteral{Value: 1.5, Token: fixDblTok}},
 		{`30 4294967295`, 2, &ast.FloatSingleLiteral{Token: fltTok, Value: 4294967295}},
 	}
 
@@ -1730,6 +1979,62 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		checkParserErrors(t, p)
 	}
 }
+
+// groupOperators shows how an expression was grouped by wrapping
+// every operation in parens
+func groupOperators(exp ast.Expression) string {
+	switch node := exp.(type) {
+	case *ast.InfixExpression:
+		return "(" + groupOperators(node.Left) + " " + node.Operator + " " + groupOperators(node.Right) + ")"
+	case *ast.PrefixExpression:
+		if node.Operator == token.NOT {
+			return "(NOT " + groupOperators(node.Right) + ")"
+		}
+		return "(" + node.Operator + groupOperators(node.Right) + ")"
+	}
+
+	return exp.String()
+}
+
+func Test_LogicalPrecedence(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+	}{
+		{inp: "-2 ^ 2", exp: "(-(2 ^ 2))"},
+		{inp: "2 ^ 3 ^ 2", exp: "((2 ^ 3) ^ 2)"},
+		{inp: "a * b ^ c", exp: "(A * (B ^ C))"},
+		{inp: "a * b \\ c", exp: "((A * B) \\ C)"},
+		{inp: "a \\ b mod c", exp: "((A \\ B) MOD C)"},
+		{inp: "a mod b + c", exp: "((A MOD B) + C)"},
+		{inp: "a + b < c", exp: "((A + B) < C)"},
+		{inp: "a = b < c", exp: "((A = B) < C)"},
+		{inp: "not a = b", exp: "(NOT (A = B))"},
+		{inp: "not a and b", exp: "((NOT A) AND B)"},
+		{inp: "a and b or c", exp: "((A AND B) OR C)"},
+		{inp: "a or b and c", exp: "(A OR (B AND C))"},
+		{inp: "a or b xor c", exp: "((A OR B) XOR C)"},
+		{inp: "a xor b eqv c", exp: "((A XOR B) EQV C)"},
+		{inp: "a eqv b imp c", exp: "((A EQV B) IMP C)"},
+		{inp: "a imp b eqv c", exp: "(A IMP (B EQV C))"},
+		{inp: "x > 1 and y < 2 or not z", exp: "(((X > 1) AND (Y < 2)) OR (NOT Z))"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("10 Z = " + tt.inp))
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		checkParserErrors(t, p)
+		iter := env.StatementIter()
+		iter.Next()
+		let, ok := iter.Value().(*ast.LetStatement)
+		if assert.Truef(t, ok, "%s didn't parse to a LetStatement", tt.inp) {
+			assert.Equalf(t, tt.exp, groupOperators(let.Value), "%s grouped incorrectly", tt.inp)
+		}
+	}
+}
+
 func TestParsingIndexExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -2017,6 +2322,68 @@ func Test_RunCommand(t *testing.T) {
 	}
 }
 
+func Test_SaveCommand(t *testing.T) {
+	tests := []struct {
+		inp    string
+		format string
+		err    bool // I expect parsing to fail
+	}{
+		{inp: `SAVE "TESTFILE.BAS"`},
+		{inp: `SAVE "TESTFILE.BAS",a`, format: "A"},
+		{inp: `SAVE "TESTFILE.BAS",P`, format: "P"},
+		{inp: `SAVE "TESTFILE.BAS",R`, format: "", err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.Len(t, p.errors, 1, "test %s expected one error, got %d", tt.inp, len(p.errors))
+			continue
+		}
+		checkParserErrors(t, p)
+
+		cmd, ok := env.CmdLineIter().Value().(*ast.SaveCommand)
+
+		if assert.Truef(t, ok, "%s didn't parse to SaveCommand", tt.inp) {
+			assert.Equal(t, `"TESTFILE.BAS"`, cmd.Path.String())
+			assert.Equal(t, tt.format, cmd.Format)
+		}
+	}
+}
+
+// SAVE can be followed by more statements on the line
+func Test_SaveCommandStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp []string
+	}{
+		{inp: `20 SAVE "A": PRINT 1`, exp: []string{`SAVE "A"`, `PRINT 1 `}},
+		{inp: `20 SAVE "A",A: PRINT 1`, exp: []string{`SAVE "A",A`, `PRINT 1 `}},
+		{inp: `20 SAVE "A",P : X = 5`, exp: []string{`SAVE "A",P`, ` X = 5`}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		checkParserErrors(t, p)
+		itr := env.StatementIter()
+
+		assert.Equalf(t, len(tt.exp)+1, itr.Len(), "%s parsed to the wrong number of statements", tt.inp)
+
+		for _, e := range tt.exp {
+			assert.True(t, itr.Next())
+			assert.Equal(t, e, itr.Value().String())
+		}
+	}
+}
+
 func TestCheckForFuncCall(t *testing.T) {
 	tst := []struct {
 		inp string
@@ -2500,3 +2867,210 @@ func Test_ViewStatement(t *testing.T) {
 		assert.Equal(t, tt.inp, cmd.String())
 	}
 }
+
+func Test_WhileWendStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		err bool
+	}{
+		{inp: `10 WHILE X < 5 : X = X + 1 : WEND`},
+		{inp: `20 WHILE I : WEND`},
+		{inp: `30 WHILE`, err: true},
+		{inp: `40 WEND 5`, err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseProgram(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s parsed without an error", tt.inp)
+		} else {
+			checkParserErrors(t, p)
+		}
+	}
+}
+
+func Test_InputStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool
+	}{
+		{inp: `INPUT A`, exp: `INPUT A`},
+		{inp: `INPUT "Name"; N$`, exp: `INPUT "Name"; N$`},
+		{inp: `INPUT; "Values", A, B%, C$`, exp: `INPUT; "Values", A, B%, C$`},
+		{inp: `INPUT "Name" N$`, err: true},
+		{inp: `INPUT 5`, err: true},
+		{inp: `LINE INPUT A$`, exp: `LINE INPUT A$`},
+		{inp: `LINE INPUT; "Name"; N$`, exp: `LINE INPUT; "Name"; N$`},
+		{inp: `LINE INPUT A$, B$`, err: true},
+		{inp: `LINE 5`, err: true},
+		{inp: `A$ = INPUT$(1)`, exp: ` A$ = INPUT$(1)`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s parsed without an error", tt.inp)
+			continue
+		}
+
+		checkParserErrors(t, p)
+		assert.Equal(t, tt.exp, env.CmdLineIter().Value().String())
+	}
+}
+
+func Test_FileStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool
+	}{
+		{inp: `PRINT #1, "Hello"; A`, exp: `PRINT #1, "Hello";A `},
+		{inp: `PRINT #1 "Hello"`, err: true},
+		{inp: `WRITE A, "B"`, exp: `WRITE A, "B"`},
+		{inp: `WRITE #2, A, "B"`, exp: `WRITE #2, A, "B"`},
+		{inp: `WRITE #2, A; "B" : PRINT`, exp: `WRITE #2, A, "B"`},
+		{inp: `WRITE`, exp: `WRITE `},
+		{inp: `WRITE A,`, err: true},
+		{inp: `WIDTH 40`, exp: `WIDTH 40`},
+		{inp: `width 80, 25 : PRINT`, exp: `WIDTH 80, 25`},
+		{inp: `WIDTH #1, 132`, exp: `WIDTH #1, 132`},
+		{inp: `WIDTH "LPT1:", W`, exp: `WIDTH "LPT1:", W`},
+		{inp: `WIDTH`, err: true},
+		{inp: `WIDTH 40,`, err: true},
+		{inp: `WIDTH #1, 80, 25`, err: true},
+		{inp: `WIDTH "LPT1:", 80, 25`, err: true},
+		{inp: `INPUT #1, A, B$`, exp: `INPUT #1, A, B$`},
+		{inp: `LINE INPUT #F, A$`, exp: `LINE INPUT #F, A$`},
+		{inp: `CLOSE #1, #2`, exp: `CLOSE #1, #2`},
+		{inp: `CLOSE #F + 1, 3`, exp: `CLOSE #F + 1, 3`},
+		{inp: `OPEN "O", #1, "test.dat"`, exp: `OPEN "O", #1, "test.dat"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s parsed without an error", tt.inp)
+			continue
+		}
+
+		checkParserErrors(t, p)
+		assert.Equal(t, tt.exp, env.CmdLineIter().Value().String())
+	}
+}
+
+func Test_RecordStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool
+	}{
+		{inp: `FIELD #1, 20 AS N$, 2 AS A$`, exp: `FIELD #1, 20 AS N$, 2 AS A$`},
+		{inp: `FIELD 1, 20 AS N$`, exp: `FIELD 1, 20 AS N$`},
+		{inp: `FIELD #1`, err: true},
+		{inp: `FIELD #1, 20 N$`, err: true},
+		{inp: `FIELD #1, 20 AS 5`, err: true},
+		{inp: `GET #1, R + 1`, exp: `GET #1, R + 1`},
+		{inp: `GET #1`, exp: `GET #1`},
+		{inp: `GET`, err: true},
+		{inp: `PUT 2, 5`, exp: `PUT 2, 5`},
+		{inp: `PUT #2, 5 6`, err: true},
+		{inp: `LSET N$ = "Fred"`, exp: `LSET N$ = "Fred"`},
+		{inp: `RSET A$ = MKI$(5)`, exp: `RSET A$ = MKI$(5)`},
+		{inp: `LSET N$ "Fred"`, err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s parsed without an error", tt.inp)
+			continue
+		}
+
+		checkParserErrors(t, p)
+		assert.Equal(t, tt.exp, env.CmdLineIter().Value().String())
+	}
+}
+
+func Test_StringStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool
+	}{
+		{inp: `SWAP A, B`, exp: `SWAP A, B`},
+		{inp: `swap A$(I), A$(J) : PRINT`, exp: `SWAP A$(I), A$(J)`},
+		{inp: `SWAP A`, err: true},
+		{inp: `SWAP A, 5`, err: true},
+		{inp: `MID$(A$, 3, 2) = "XY"`, exp: `MID$(A$, 3, 2) = "XY"`},
+		{inp: `mid$(N$(2), I + 1) = B$ : PRINT`, exp: `MID$(N$(2), I + 1) = B$`},
+		{inp: `MID$(A$) = "XY"`, err: true},
+		{inp: `MID$(A$, 3) "XY"`, err: true},
+		{inp: `MID$("ABC", 3) = "XY"`, err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.NotZerof(t, len(p.Errors()), "%s parsed without an error", tt.inp)
+			continue
+		}
+
+		checkParserErrors(t, p)
+		assert.Equal(t, tt.exp, env.CmdLineIter().Value().String())
+	}
+}
+
+func Test_DiskStatements(t *testing.T) {
+	tests := []struct {
+		inp string
+		exp string
+		err bool // I expect parsing to fail
+	}{
+		{inp: `KILL "*.BAK"`, exp: `KILL "*.BAK"`},
+		{inp: `kill F$ : PRINT`, exp: `KILL F$`},
+		{inp: `MKDIR "GAMES"`, exp: `MKDIR "GAMES"`},
+		{inp: `RMDIR "GAMES"`, exp: `RMDIR "GAMES"`},
+		{inp: `NAME "OLD.BAS" as "NEW.BAS"`, exp: `NAME "OLD.BAS" AS "NEW.BAS"`},
+		{inp: `NAME A$ AS B$ : PRINT`, exp: `NAME A$ AS B$`},
+		{inp: `NAME "OLD.BAS" "NEW.BAS"`, err: true},
+		{inp: `NAME`, err: true},
+		{inp: `KILL`, err: true},
+		{inp: `RMDIR`, err: true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.inp)
+		p := New(l)
+		env := object.NewTermEnvironment(mocks.MockTerm{})
+		p.ParseCmd(env)
+
+		if tt.err {
+			assert.Len(t, p.errors, 1, "test %s expected one error, got %d", tt.inp, len(p.errors))
+			continue
+		}
+		checkParserErrors(t, p)
+
+		assert.Equalf(t, tt.exp, env.CmdLineIter().Value().String(), "%s parsed incorrectly", tt.inp)
+	}
+}
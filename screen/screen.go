@@ -0,0 +1,466 @@
+package screen
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Rows is the number of text rows on the screen
+const Rows = 25
+
+// DefaultAttr is white on black, the attribute the screen starts with
+const DefaultAttr = 0x07
+
+// the ANSI color order is different from the CGA order that
+// attributes use, this maps one to the other
+var ansiToCGA = [8]byte{0, 4, 2, 6, 1, 5, 3, 7}
+
+// Cell is one character position on the screen
+type Cell struct {
+	Char byte // CP437 character code
+	Attr byte // foreground in bits 0-3, background 4-6, blink in bit 7
+}
+
+// Screen is an in-memory text mode screen that implements object.Console
+// every cell holds a character and its attribute
+type Screen struct {
+	cols   int    // 80 or 40 characters wide
+	cells  []Cell // Rows * cols cells, row by row
+	row    int    // cursor row, top of the screen is zero
+	col    int    // cursor column, left edge is zero
+	top    int    // first row of the VIEW PRINT window
+	bottom int    // last row of the VIEW PRINT window
+	attr   byte   // attribute given to characters as they are printed
+	esc    []byte // escape sequence collected so far
+	keys   []byte // keystrokes waiting to be read
+	brk    bool   // CTRL-C has been typed
+	bells  int    // number of times the bell has sounded
+	logs   []string
+}
+
+// New creates a blank screen cols characters wide
+// anything other than 40 gets 80 columns
+func New(cols int) *Screen {
+	scr := &Screen{}
+	scr.SetWidth(cols)
+
+	return scr
+}
+
+// SetWidth changes the screen width to 40 or 80 columns
+// the screen is cleared and the VIEW PRINT window removed
+func (scr *Screen) SetWidth(cols int) {
+	if cols != 40 {
+		cols = 80
+	}
+
+	scr.cols = cols
+	scr.cells = make([]Cell, Rows*cols)
+	scr.top, scr.bottom = 0, Rows-1
+	scr.attr = DefaultAttr
+	scr.row, scr.col = 0, 0
+	scr.esc = nil
+	scr.clearRows(0, Rows-1)
+}
+
+// Width returns the number of columns
+func (scr *Screen) Width() int {
+	return scr.cols
+}
+
+// Cell returns the cell at row, col
+// NOTE: the upper left cell is 0,0
+func (scr *Screen) Cell(row, col int) Cell {
+	if !scr.onScreen(row, col) {
+		return Cell{}
+	}
+
+	return scr.cells[row*scr.cols+col]
+}
+
+// Line returns the text of row with the trailing spaces removed
+func (scr *Screen) Line(row int) string {
+	return strings.TrimRight(scr.Read(0, row, scr.cols), " ")
+}
+
+// String returns the text of the whole screen, one line per row
+func (scr *Screen) String() string {
+	var lines []string
+
+	for row := 0; row < Rows; row++ {
+		lines = append(lines, scr.Line(row))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Cls clears the VIEW PRINT window and puts the cursor at its top
+func (scr *Screen) Cls() {
+	scr.clearRows(scr.top, scr.bottom)
+	scr.row, scr.col = scr.top, 0
+}
+
+// Print puts msg on the screen at the cursor position
+func (scr *Screen) Print(msg string) {
+	for _, r := range msg {
+		if (r < 0x80) || (len(scr.esc) > 0) {
+			scr.printByte(byte(r))
+			continue
+		}
+
+		bt, ok := charmap.CodePage437.EncodeRune(r)
+		if !ok {
+			bt = '?'
+		}
+		scr.putChar(bt)
+	}
+}
+
+// Println prints msg and then moves to the start of the next line
+func (scr *Screen) Println(msg string) {
+	scr.Print(msg + "\r\n")
+}
+
+// printByte acts on a single byte of output
+func (scr *Screen) printByte(bt byte) {
+	if len(scr.esc) > 0 {
+		scr.escape(bt)
+		return
+	}
+
+	switch bt {
+	case 0x1b:
+		scr.esc = []byte{bt}
+	case '\r':
+		scr.col = 0
+	case '\n':
+		scr.lineFeed()
+	case '\b':
+		if scr.col > 0 {
+			scr.col--
+		}
+	case '\a':
+		scr.SoundBell()
+	default:
+		scr.putChar(bt)
+	}
+}
+
+// putChar stores bt at the cursor and moves the cursor on
+// at the right edge the cursor wraps to the next line
+func (scr *Screen) putChar(bt byte) {
+	if scr.col >= scr.cols {
+		scr.col = 0
+		scr.lineFeed()
+	}
+
+	scr.cells[scr.row*scr.cols+scr.col] = Cell{Char: bt, Attr: scr.attr}
+	scr.col++
+}
+
+// lineFeed moves the cursor down a row
+// at the bottom of the VIEW PRINT window the window scrolls up
+func (scr *Screen) lineFeed() {
+	scr.col = 0
+	if scr.row != scr.bottom {
+		if scr.row < Rows-1 {
+			scr.row++
+		}
+		return
+	}
+
+	copy(scr.cells[scr.top*scr.cols:], scr.cells[(scr.top+1)*scr.cols:(scr.bottom+1)*scr.cols])
+	scr.clearRows(scr.bottom, scr.bottom)
+}
+
+// clearRows blanks every cell from row first to row last
+func (scr *Screen) clearRows(first, last int) {
+	for i := first * scr.cols; i < (last+1)*scr.cols; i++ {
+		scr.cells[i] = Cell{Char: ' ', Attr: scr.attr}
+	}
+}
+
+// escape collects an escape sequence, acting on it once it is complete
+// only CSI sequences, ESC [ params final, mean anything
+func (scr *Screen) escape(bt byte) {
+	scr.esc = append(scr.esc, bt)
+
+	if len(scr.esc) == 2 {
+		if bt != '[' {
+			scr.esc = nil
+		}
+		return
+	}
+
+	if (bt < '@') || (bt > '~') {
+		return
+	}
+
+	params := string(scr.esc[2 : len(scr.esc)-1])
+	scr.esc = nil
+	scr.csi(params, bt)
+}
+
+// csi carries out a complete control sequence
+func (scr *Screen) csi(params string, final byte) {
+	// sequences with intermediate bytes aren't supported
+	if strings.IndexFunc(params, func(r rune) bool { return (r < '0') || (r > '?') }) != -1 {
+		return
+	}
+
+	args := strings.Split(params, ";")
+	arg := func(i int, def int) int {
+		if i >= len(args) {
+			return def
+		}
+		n, err := strconv.Atoi(args[i])
+		if (err != nil) || (n == 0) {
+			return def
+		}
+		return n
+	}
+
+	switch final {
+	case 'A':
+		scr.moveTo(scr.row-arg(0, 1), scr.col)
+	case 'B':
+		scr.moveTo(scr.row+arg(0, 1), scr.col)
+	case 'C':
+		scr.moveTo(scr.row, scr.col+arg(0, 1))
+	case 'D':
+		scr.moveTo(scr.row, scr.col-arg(0, 1))
+	case 'H', 'f':
+		scr.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'd':
+		scr.moveTo(arg(0, 1)-1, scr.col)
+	case '`', 'G':
+		scr.moveTo(scr.row, arg(0, 1)-1)
+	case 'J':
+		scr.eraseDisplay(arg(0, 0))
+	case 'K':
+		scr.eraseLine(arg(0, 0))
+	case 'P':
+		scr.deleteChars(arg(0, 1))
+	case 'm':
+		for i := range args {
+			scr.setAttr(arg(i, 0))
+		}
+	case 'r':
+		scr.setWindow(arg(0, 1)-1, arg(1, Rows)-1)
+	}
+}
+
+// moveTo puts the cursor at row, col keeping it on the screen
+func (scr *Screen) moveTo(row, col int) {
+	scr.row = clamp(row, 0, Rows-1)
+	scr.col = clamp(col, 0, scr.cols-1)
+}
+
+// eraseDisplay blanks from the cursor to the end of the screen (0),
+// from the start of the screen to the cursor (1) or everything (2)
+func (scr *Screen) eraseDisplay(mode int) {
+	pos := scr.row*scr.cols + scr.col
+	first, last := pos, len(scr.cells)-1
+
+	switch mode {
+	case 1:
+		first, last = 0, pos
+	case 2:
+		first = 0
+	}
+
+	scr.eraseCells(first, last)
+}
+
+// eraseLine blanks from the cursor to the end of the line (0),
+// from the start of the line to the cursor (1) or the whole line (2)
+func (scr *Screen) eraseLine(mode int) {
+	start := scr.row * scr.cols
+	pos := start + clamp(scr.col, 0, scr.cols-1)
+	first, last := pos, start+scr.cols-1
+
+	switch mode {
+	case 1:
+		first, last = start, pos
+	case 2:
+		first = start
+	}
+
+	scr.eraseCells(first, last)
+}
+
+// eraseCells blanks the cells from first to last
+func (scr *Screen) eraseCells(first, last int) {
+	for i := first; i <= last; i++ {
+		scr.cells[i] = Cell{Char: ' ', Attr: scr.attr}
+	}
+}
+
+// deleteChars removes count characters at the cursor, the rest of
+// the line slides left and blanks fill in at the right edge
+func (scr *Screen) deleteChars(count int) {
+	col := clamp(scr.col, 0, scr.cols-1)
+	line := scr.cells[scr.row*scr.cols : (scr.row+1)*scr.cols]
+	count = clamp(count, 0, scr.cols-col)
+
+	copy(line[col:], line[col+count:])
+	for i := scr.cols - count; i < scr.cols; i++ {
+		line[i] = Cell{Char: ' ', Attr: scr.attr}
+	}
+}
+
+// setAttr applies one SGR parameter to the current attribute
+func (scr *Screen) setAttr(code int) {
+	switch {
+	case code == 0:
+		scr.attr = DefaultAttr
+	case code == 5:
+		scr.attr |= 0x80
+	case code == 25:
+		scr.attr &^= 0x80
+	case (code >= 30) && (code <= 37):
+		scr.attr = (scr.attr & 0xf0) | ansiToCGA[code-30]
+	case code == 39:
+		scr.attr = (scr.attr & 0xf0) | (DefaultAttr & 0x0f)
+	case (code >= 90) && (code <= 97):
+		scr.attr = (scr.attr & 0xf0) | ansiToCGA[code-90] | 0x08
+	case (code >= 40) && (code <= 47):
+		scr.attr = (scr.attr & 0x8f) | (ansiToCGA[code-40] << 4)
+	case code == 49:
+		scr.attr = (scr.attr & 0x8f) | (DefaultAttr & 0x70)
+	case (code >= 100) && (code <= 107):
+		// there is no bright background in text mode
+		scr.attr = (scr.attr & 0x8f) | (ansiToCGA[code-100] << 4)
+	}
+}
+
+// setWindow limits scrolling to rows top through bottom, the same
+// as xterm the cursor goes to the home position
+func (scr *Screen) setWindow(top, bottom int) {
+	if (top < 0) || (bottom >= Rows) || (top >= bottom) {
+		return
+	}
+
+	scr.top, scr.bottom = top, bottom
+	scr.row, scr.col = 0, 0
+}
+
+// Window returns the first and last rows of the VIEW PRINT window
+func (scr *Screen) Window() (int, int) {
+	return scr.top, scr.bottom
+}
+
+// Locate moves the cursor to row, col
+// NOTE: the upper left screen position is 1,1
+func (scr *Screen) Locate(row, col int) {
+	scr.moveTo(row-1, col-1)
+}
+
+// GetCursor returns the cursor position
+// NOTE: the upper left screen position is 0,0
+func (scr *Screen) GetCursor() (int, int) {
+	return scr.row, clamp(scr.col, 0, scr.cols-1)
+}
+
+// Read returns the characters in length cells starting at row, col
+// reading stops at the end of the row
+func (scr *Screen) Read(col, row, length int) string {
+	if !scr.onScreen(row, col) {
+		return ""
+	}
+
+	var bts []byte
+	for ; (length > 0) && (col < scr.cols); length-- {
+		bts = append(bts, scr.cells[row*scr.cols+col].Char)
+		col++
+	}
+
+	var out strings.Builder
+	for _, bt := range bts {
+		out.WriteRune(charmap.CodePage437.DecodeByte(bt))
+	}
+
+	return out.String()
+}
+
+// TypeKeys queues up keystrokes for ReadKeys to return
+func (scr *Screen) TypeKeys(keys string) {
+	scr.keys = append(scr.keys, keys...)
+}
+
+// ReadKeys returns up to count of the keys that have been typed
+// when no keys are left it returns an empty slice
+func (scr *Screen) ReadKeys(count int) []byte {
+	if count > len(scr.keys) {
+		count = len(scr.keys)
+	}
+
+	keys := scr.keys[:count]
+	scr.keys = scr.keys[count:]
+
+	return keys
+}
+
+// InKey returns the next key that has been typed, an extended key
+// comes back with both of its bytes, empty string if none are left
+func (scr *Screen) InKey() string {
+	count := 1
+	if (len(scr.keys) > 1) && (scr.keys[0] == 0) {
+		count = 2
+	}
+
+	return string(scr.ReadKeys(count))
+}
+
+// Break makes the next BreakCheck return true, as if CTRL-C was typed
+func (scr *Screen) Break() {
+	scr.brk = true
+}
+
+// BreakCheck returns true if CTRL-C has been typed
+// the flag is cleared before returning
+func (scr *Screen) BreakCheck() bool {
+	brk := scr.brk
+	scr.brk = false
+
+	return brk
+}
+
+// SoundBell counts the bell, there is nothing to hear
+func (scr *Screen) SoundBell() {
+	scr.bells++
+}
+
+// Bells returns how many times the bell has sounded
+func (scr *Screen) Bells() int {
+	return scr.bells
+}
+
+// Log keeps msg, see Logs
+func (scr *Screen) Log(msg string) {
+	scr.logs = append(scr.logs, msg)
+}
+
+// Logs returns all the messages that have been logged
+func (scr *Screen) Logs() []string {
+	return scr.logs
+}
+
+// onScreen returns true if row, col is a valid cell
+func (scr *Screen) onScreen(row, col int) bool {
+	return (row >= 0) && (row < Rows) && (col >= 0) && (col < scr.cols)
+}
+
+// clamp keeps val between low and high
+func clamp(val, low, high int) int {
+	if val < low {
+		return low
+	}
+	if val > high {
+		return high
+	}
+
+	return val
+}
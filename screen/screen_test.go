@@ -0,0 +1,260 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/navionguy/basicwasm/cli"
+	"github.com/navionguy/basicwasm/lexer"
+	"github.com/navionguy/basicwasm/object"
+	"github.com/navionguy/basicwasm/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// make sure the screen can stand in for the terminal
+var _ object.Console = New(80)
+
+func Test_Print(t *testing.T) {
+	tests := []struct {
+		cols  int
+		inp   []string
+		lines []string // expected text of the first rows
+		row   int
+		col   int
+	}{
+		{inp: []string{"HELLO"}, lines: []string{"HELLO"}, col: 5},
+		{inp: []string{"HI\r\n", "THERE"}, lines: []string{"HI", "THERE"}, row: 1, col: 5},
+		{inp: []string{"ABC\rX"}, lines: []string{"XBC"}, col: 1},
+		{inp: []string{"ABC\b\bX"}, lines: []string{"AXC"}, col: 2},
+		{inp: []string{strings.Repeat("X", 81)}, lines: []string{strings.Repeat("X", 80), "X"}, row: 1, col: 1},
+		{cols: 40, inp: []string{strings.Repeat("X", 41)}, lines: []string{strings.Repeat("X", 40), "X"}, row: 1, col: 1},
+		{inp: []string{"\x1b[3d\x1b[5`HI"}, lines: []string{"", "", "    HI"}, row: 2, col: 6},
+		{inp: []string{"\x1b[2;3HHI"}, lines: []string{"", "  HI"}, row: 1, col: 4},
+		{inp: []string{"ABCDEF\x1b[3D\x1b[P"}, lines: []string{"ABCEF"}, col: 3},
+		{inp: []string{"ABCDEF\x1b[4D\x1b[2P"}, lines: []string{"ABEF"}, col: 2},
+		{inp: []string{"ABCDEF\x1b[3D\x1b[K"}, lines: []string{"ABC"}, col: 3},
+		{inp: []string{"AB\r\nCD\x1b[2J"}, lines: []string{"", ""}, row: 1, col: 2},
+		{inp: []string{"\x1b", "[3", "dX"}, lines: []string{"", "", "X"}, row: 2, col: 1},
+		{inp: []string{"\x1b[80'~X"}, lines: []string{"X"}, col: 1},
+		{inp: []string{"\x1bcX"}, lines: []string{"X"}, col: 1},
+		{inp: []string{"█░"}, lines: []string{"█░"}, col: 2},
+	}
+
+	for _, tt := range tests {
+		scr := New(tt.cols)
+		for _, msg := range tt.inp {
+			scr.Print(msg)
+		}
+
+		for i, line := range tt.lines {
+			assert.Equal(t, line, scr.Line(i), "Print(%q) row %d", tt.inp, i)
+		}
+
+		row, col := scr.GetCursor()
+		assert.Equal(t, tt.row, row, "Print(%q) cursor row", tt.inp)
+		assert.Equal(t, tt.col, col, "Print(%q) cursor col", tt.inp)
+	}
+}
+
+func Test_Scrolling(t *testing.T) {
+	scr := New(80)
+	for i := 1; i <= 30; i++ {
+		scr.Println(strings.Repeat("X", i))
+	}
+
+	row, _ := scr.GetCursor()
+	assert.Equal(t, Rows-1, row, "cursor should stay on the bottom row")
+	assert.Equal(t, strings.Repeat("X", 7), scr.Line(0), "top row after scrolling")
+	assert.Equal(t, strings.Repeat("X", 30), scr.Line(Rows-2), "last row printed")
+	assert.Equal(t, "", scr.Line(Rows-1), "bottom row should be empty")
+}
+
+func Test_ViewPrint(t *testing.T) {
+	scr := New(80)
+	scr.Locate(1, 1)
+	scr.Print("TOP")
+	scr.Locate(25, 1)
+	scr.Print("BOTTOM")
+
+	// VIEW PRINT 5 TO 7
+	scr.Print("\x1b[5;7r")
+	top, bottom := scr.Window()
+	assert.Equal(t, 4, top, "window top")
+	assert.Equal(t, 6, bottom, "window bottom")
+
+	scr.Cls()
+	for i := 1; i <= 5; i++ {
+		scr.Println(strings.Repeat("V", i))
+	}
+
+	assert.Equal(t, "TOP", scr.Line(0), "outside the window shouldn't change")
+	assert.Equal(t, "VVVV", scr.Line(4), "first window row")
+	assert.Equal(t, "VVVVV", scr.Line(5), "second window row")
+	assert.Equal(t, "", scr.Line(6), "last window row")
+	assert.Equal(t, "", scr.Line(7), "below the window shouldn't change")
+	assert.Equal(t, "BOTTOM", scr.Line(24), "outside the window shouldn't change")
+
+	// VIEW PRINT off
+	scr.Print("\x1b[1;24r")
+	top, bottom = scr.Window()
+	assert.Equal(t, 0, top, "window top")
+	assert.Equal(t, 23, bottom, "window bottom")
+
+	// a bad window is ignored
+	scr.Print("\x1b[9;3r")
+	top, bottom = scr.Window()
+	assert.Equal(t, 0, top, "window top")
+	assert.Equal(t, 23, bottom, "window bottom")
+}
+
+func Test_Attributes(t *testing.T) {
+	tests := []struct {
+		inp  string
+		attr byte
+	}{
+		{inp: "X", attr: DefaultAttr},
+		{inp: "\x1b[34mX", attr: 0x01},
+		{inp: "\x1b[91mX", attr: 0x0c},
+		{inp: "\x1b[44mX", attr: 0x17},
+		{inp: "\x1b[33;42mX", attr: 0x26},
+		{inp: "\x1b[5mX", attr: 0x87},
+		{inp: "\x1b[5m\x1b[25mX", attr: DefaultAttr},
+		{inp: "\x1b[31;41m\x1b[0mX", attr: DefaultAttr},
+		{inp: "\x1b[31;41m\x1b[39;49mX", attr: DefaultAttr},
+		{inp: "\x1b[104mX", attr: 0x17},
+	}
+
+	for _, tt := range tests {
+		scr := New(80)
+		scr.Print(tt.inp)
+
+		cell := scr.Cell(0, 0)
+		assert.Equal(t, byte('X'), cell.Char, "Print(%q) character", tt.inp)
+		assert.Equal(t, tt.attr, cell.Attr, "Print(%q) attribute", tt.inp)
+	}
+}
+
+func Test_Read(t *testing.T) {
+	tests := []struct {
+		col int
+		row int
+		len int
+		exp string
+	}{
+		{col: 0, row: 0, len: 5, exp: "HELLO"},
+		{col: 6, row: 0, len: 5, exp: "WORLD"},
+		{col: 0, row: 0, len: 13, exp: "HELLO WORLD  "},
+		{col: 78, row: 0, len: 10, exp: "  "},
+		{col: 0, row: 1, len: 3, exp: "░  "},
+		{col: 80, row: 0, len: 1},
+		{col: 0, row: 25, len: 1},
+		{col: -1, row: 0, len: 1},
+	}
+
+	scr := New(80)
+	scr.Println("HELLO WORLD")
+	scr.Print("░")
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, scr.Read(tt.col, tt.row, tt.len), "Read(%d, %d, %d)", tt.col, tt.row, tt.len)
+	}
+
+	assert.Equal(t, Cell{}, scr.Cell(25, 0), "Cell off the screen")
+	assert.Equal(t, "HELLO WORLD\n░"+strings.Repeat("\n", Rows-2), scr.String(), "String()")
+}
+
+func Test_Locate(t *testing.T) {
+	tests := []struct {
+		row  int
+		col  int
+		erow int
+		ecol int
+	}{
+		{row: 1, col: 1},
+		{row: 10, col: 20, erow: 9, ecol: 19},
+		{row: 25, col: 80, erow: 24, ecol: 79},
+		{row: 30, col: 90, erow: 24, ecol: 79},
+		{row: 0, col: 0},
+	}
+
+	for _, tt := range tests {
+		scr := New(80)
+		scr.Locate(tt.row, tt.col)
+
+		row, col := scr.GetCursor()
+		assert.Equal(t, tt.erow, row, "Locate(%d, %d) row", tt.row, tt.col)
+		assert.Equal(t, tt.ecol, col, "Locate(%d, %d) col", tt.row, tt.col)
+	}
+}
+
+func Test_SetWidth(t *testing.T) {
+	scr := New(40)
+	assert.Equal(t, 40, scr.Width(), "New(40)")
+	scr.Print("\x1b[34m\x1b[5;7rHELLO")
+
+	scr.SetWidth(132)
+	assert.Equal(t, 80, scr.Width(), "SetWidth(132)")
+	assert.Equal(t, "", scr.Line(0), "SetWidth should clear the screen")
+	assert.Equal(t, Cell{Char: ' ', Attr: DefaultAttr}, scr.Cell(0, 79), "SetWidth should reset the attribute")
+	top, bottom := scr.Window()
+	assert.Equal(t, 0, top, "SetWidth should remove the window")
+	assert.Equal(t, Rows-1, bottom, "SetWidth should remove the window")
+}
+
+func Test_Keys(t *testing.T) {
+	scr := New(80)
+	scr.TypeKeys("AB")
+	scr.TypeKeys("C")
+
+	assert.Equal(t, []byte("AB"), scr.ReadKeys(2), "ReadKeys(2)")
+	assert.Equal(t, []byte("C"), scr.ReadKeys(5), "ReadKeys(5)")
+	assert.Empty(t, scr.ReadKeys(1), "ReadKeys with none left")
+
+	scr.TypeKeys("\x00HX")
+	assert.Equal(t, "\x00H", scr.InKey(), "InKey of an extended key")
+	assert.Equal(t, "X", scr.InKey(), "InKey of a plain key")
+	assert.Equal(t, "", scr.InKey(), "InKey with none left")
+
+	assert.False(t, scr.BreakCheck(), "BreakCheck before Break")
+	scr.Break()
+	assert.True(t, scr.BreakCheck(), "BreakCheck after Break")
+	assert.False(t, scr.BreakCheck(), "BreakCheck should clear the flag")
+}
+
+func Test_BellAndLog(t *testing.T) {
+	scr := New(80)
+	scr.SoundBell()
+	scr.Print("\a")
+	scr.Log("one")
+	scr.Log("two")
+
+	assert.Equal(t, 2, scr.Bells(), "Bells()")
+	assert.Equal(t, []string{"one", "two"}, scr.Logs(), "Logs()")
+	assert.Equal(t, "", scr.Line(0), "the bell doesn't print")
+}
+
+func Test_RunProgram(t *testing.T) {
+	tests := []struct {
+		src   string
+		keys  string
+		lines []string
+	}{
+		{src: `10 CLS : LOCATE 3, 5 : PRINT "HI"`, lines: []string{"", "", "    HI"}},
+		{src: `10 PRINT "A" : PRINT "B" : PRINT CSRLIN`, lines: []string{"A", "B", " 3"}},
+		{src: `10 INPUT "NAME"; N$ : PRINT "HI "; N$`, keys: "BOB\r", lines: []string{"NAME? BOB", "HI BOB"}},
+		{src: `10 VIEW PRINT 2 TO 4 : CLS : PRINT "IN"`, lines: []string{"", "IN"}},
+	}
+
+	for _, tt := range tests {
+		scr := New(80)
+		scr.TypeKeys(tt.keys)
+		env := object.NewTermEnvironment(scr)
+		p := parser.New(lexer.New(tt.src))
+		p.ParseProgram(env)
+
+		assert.True(t, cli.RunProgram(env), "RunProgram(%s) failed", tt.src)
+		for i, line := range tt.lines {
+			assert.Equal(t, line, scr.Line(i), "RunProgram(%s) row %d", tt.src, i)
+		}
+	}
+}
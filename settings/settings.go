@@ -7,6 +7,7 @@ const (
 	KeyMacs   = "keymacs" // all defined func key macros
 	OnError   = "onerror" // line number of error handler
 	OnKeys    = "onkeys"  // all defined ON KEY settings
+	OnTimer   = "ontimer" // ON TIMER setting
 	Palette   = "palette" // color palette currently in use
 	Restart   = "restart" // location to restart execution in the source
 	Screen    = "screen"  // screen mode settings
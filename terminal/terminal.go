@@ -59,6 +59,13 @@ func (t *Terminal) Cls() {
 	t.Print("\x1B[80'~") // clear to end of line
 }
 
+// SetWidth resizes the terminal to 40 or 80 columns and clears it
+// NOTE: the page gives xterm two more columns than the screen uses
+func (t *Terminal) SetWidth(cols int) {
+	t.term.Call("resize", cols+2, 25)
+	t.Cls()
+}
+
 // GetCursor retrieves the current cursor position
 // NOTE: Cursor position is based on the upper left
 // position being 0,0
@@ -103,6 +110,11 @@ func (t *Terminal) ReadKeys(count int) []byte {
 	return keys
 }
 
+// InKey returns the next keystroke, if there is one
+func (t *Terminal) InKey() string {
+	return t.kbuff.ReadKey()
+}
+
 // returns true if CTRL+C has been seen
 // if it has flag is cleared before returning
 func (t *Terminal) BreakCheck() bool {
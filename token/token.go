@@ -27,6 +27,7 @@ const (
 	ASTERISK = "*"
 	SLASH    = "/"
 	BSLASH   = "\\"
+	CARET    = "^"
 
 	LT = "<"
 	GT = ">"
@@ -61,70 +62,99 @@ const (
 	OCTAL = "&O"
 
 	// Keywords
-	ACCESS  = "ACCESS"
-	ALL     = "ALL"
-	APPEND  = "APPEND"
-	AS      = "AS"
-	AUTO    = "AUTO"
-	BEEP    = "BEEP"
-	BUILTIN = "BUILTIN"
-	CHAIN   = "CHAIN"
-	CHDIR   = "CHDIR"
-	CLEAR   = "CLEAR"
-	CLOSE   = "CLOSE"
-	CLS     = "CLS"
-	COLOR   = "COLOR"
-	COMMON  = "COMMON"
-	CONT    = "CONT"
-	CSRLIN  = "CSRLIN"
-	DATA    = "DATA"
-	DEF     = "DEF"
-	DIM     = "DIM"
-	ELSE    = "ELSE"
-	END     = "END"
-	ERROR   = "ERROR"
-	FALSE   = "FALSE"
-	FILES   = "FILES"
-	FOR     = "FOR"
-	GOSUB   = "GOSUB"
-	GOTO    = "GOTO"
-	IF      = "IF"
-	INPUT   = "INPUT"
-	KEY     = "KEY"
-	LEN     = "LEN"
-	LET     = "LET"
-	LIST    = "LIST"
-	LOAD    = "LOAD"
-	LOCATE  = "LOCATE"
-	LOCK    = "LOCK"
-	MERGE   = "MERGE"
-	MOD     = "MOD"
-	NEW     = "NEW"
-	NEXT    = "NEXT"
-	OFF     = "OFF"
-	ON      = "ON"
-	OPEN    = "OPEN"
-	OUTPUT  = "OUTPUT"
-	PALETTE = "PALETTE"
-	PRINT   = "PRINT"
-	RANDOM  = "RANDOM"
-	READ    = "READ"
-	REM     = "REM"
-	RESTORE = "RESTORE"
-	RESUME  = "RESUME"
-	RETURN  = "RETURN"
-	RUN     = "RUN"
-	SCREEN  = "SCREEN"
-	SHARED  = "SHARED"
-	STOP    = "STOP"
-	THEN    = "THEN"
-	TO      = "TO"
-	TRON    = "TRON"
-	TROFF   = "TROFF"
-	TRUE    = "TRUE"
-	USING   = "USING"
-	VIEW    = "VIEW"
-	WRITE   = "WRITE"
+	ACCESS    = "ACCESS"
+	ALL       = "ALL"
+	AND       = "AND"
+	APPEND    = "APPEND"
+	AS        = "AS"
+	AUTO      = "AUTO"
+	BEEP      = "BEEP"
+	BUILTIN   = "BUILTIN"
+	CHAIN     = "CHAIN"
+	CHDIR     = "CHDIR"
+	CLEAR     = "CLEAR"
+	CLOSE     = "CLOSE"
+	CLS       = "CLS"
+	COLOR     = "COLOR"
+	COMMON    = "COMMON"
+	CONT      = "CONT"
+	CSRLIN    = "CSRLIN"
+	DATA      = "DATA"
+	DEF       = "DEF"
+	DEFDBL    = "DEFDBL"
+	DEFINT    = "DEFINT"
+	DEFSNG    = "DEFSNG"
+	DEFSTR    = "DEFSTR"
+	DIM       = "DIM"
+	ELSE      = "ELSE"
+	END       = "END"
+	EQV       = "EQV"
+	ERASE     = "ERASE"
+	ERROR     = "ERROR"
+	FALSE     = "FALSE"
+	FIELD     = "FIELD"
+	FILES     = "FILES"
+	FOR       = "FOR"
+	GET       = "GET"
+	GOSUB     = "GOSUB"
+	GOTO      = "GOTO"
+	IF        = "IF"
+	IMP       = "IMP"
+	INPUT     = "INPUT"
+	KEY       = "KEY"
+	KILL      = "KILL"
+	LEN       = "LEN"
+	LET       = "LET"
+	LINE      = "LINE"
+	LIST      = "LIST"
+	LOAD      = "LOAD"
+	LOCATE    = "LOCATE"
+	LOCK      = "LOCK"
+	LSET      = "LSET"
+	MERGE     = "MERGE"
+	MKDIR     = "MKDIR"
+	MOD       = "MOD"
+	NAME      = "NAME"
+	NEW       = "NEW"
+	NEXT      = "NEXT"
+	NOT       = "NOT"
+	OFF       = "OFF"
+	ON        = "ON"
+	OPEN      = "OPEN"
+	OPTION    = "OPTION"
+	OR        = "OR"
+	OUTPUT    = "OUTPUT"
+	PALETTE   = "PALETTE"
+	PRINT     = "PRINT"
+	PUT       = "PUT"
+	RANDOM    = "RANDOM"
+	RANDOMIZE = "RANDOMIZE"
+	READ      = "READ"
+	REM       = "REM"
+	RESTORE   = "RESTORE"
+	RESUME    = "RESUME"
+	RETURN    = "RETURN"
+	RMDIR     = "RMDIR"
+	RSET      = "RSET"
+	RUN       = "RUN"
+	SAVE      = "SAVE"
+	SCREEN    = "SCREEN"
+	SHARED    = "SHARED"
+	STOP      = "STOP"
+	SWAP      = "SWAP"
+	THEN      = "THEN"
+	TIMER     = "TIMER"
+	TO        = "TO"
+	TRON      = "TRON"
+	TROFF     = "TROFF"
+	TRUE      = "TRUE"
+	USING     = "USING"
+	VIEW      = "VIEW"
+	WEND      = "WEND"
+	WHILE     = "WHILE"
+	WIDTH     = "WIDTH"
+	WRITE     = "WRITE"
+	XOR       = "XOR"
 )
 
 type Token struct {
@@ -133,60 +163,91 @@ type Token struct {
 }
 
 var keywords = map[string]TokenType{
-	"all":     ALL,
-	"auto":    AUTO,
-	"beep":    BEEP,
-	"builtin": BUILTIN,
-	"chain":   CHAIN,
-	"chdir":   CHDIR,
-	"clear":   CLEAR,
-	"close":   CLOSE,
-	"cls":     CLS,
-	"color":   COLOR,
-	"common":  COMMON,
-	"cont":    CONT,
-	"csrlin":  CSRLIN,
-	"data":    DATA,
-	"def":     DEF,
-	"dim":     DIM,
-	"else":    ELSE,
-	"end":     END,
-	"error":   ERROR,
-	"false":   FALSE,
-	"files":   FILES,
-	"for":     FOR,
-	"gosub":   GOSUB,
-	"goto":    GOTO,
-	"if":      IF,
-	"key":     KEY,
-	"let":     LET,
-	"list":    LIST,
-	"load":    LOAD,
-	"locate":  LOCATE,
-	"merge":   MERGE,
-	"mod":     MOD,
-	"new":     NEW,
-	"next":    NEXT,
-	"off":     OFF,
-	"on":      ON,
-	"open":    OPEN,
-	"palette": PALETTE,
-	"print":   PRINT,
-	"read":    READ,
-	"rem":     REM,
-	"restore": RESTORE,
-	"resume":  RESUME,
-	"return":  RETURN,
-	"run":     RUN,
-	"screen":  SCREEN,
-	"stop":    STOP,
-	"then":    THEN,
-	"to":      TO,
-	"tron":    TRON,
-	"troff":   TROFF,
-	"true":    TRUE,
-	"using":   USING,
-	"view":    VIEW,
+	"all":       ALL,
+	"and":       AND,
+	"auto":      AUTO,
+	"beep":      BEEP,
+	"builtin":   BUILTIN,
+	"chain":     CHAIN,
+	"chdir":     CHDIR,
+	"clear":     CLEAR,
+	"close":     CLOSE,
+	"cls":       CLS,
+	"color":     COLOR,
+	"common":    COMMON,
+	"cont":      CONT,
+	"csrlin":    CSRLIN,
+	"data":      DATA,
+	"def":       DEF,
+	"defdbl":    DEFDBL,
+	"defint":    DEFINT,
+	"defsng":    DEFSNG,
+	"defstr":    DEFSTR,
+	"dim":       DIM,
+	"else":      ELSE,
+	"end":       END,
+	"eqv":       EQV,
+	"erase":     ERASE,
+	"error":     ERROR,
+	"false":     FALSE,
+	"field":     FIELD,
+	"files":     FILES,
+	"for":       FOR,
+	"get":       GET,
+	"gosub":     GOSUB,
+	"goto":      GOTO,
+	"if":        IF,
+	"imp":       IMP,
+	"input":     INPUT,
+	"key":       KEY,
+	"kill":      KILL,
+	"let":       LET,
+	"line":      LINE,
+	"list":      LIST,
+	"load":      LOAD,
+	"locate":    LOCATE,
+	"lset":      LSET,
+	"merge":     MERGE,
+	"mkdir":     MKDIR,
+	"mod":       MOD,
+	"name":      NAME,
+	"new":       NEW,
+	"next":      NEXT,
+	"not":       NOT,
+	"off":       OFF,
+	"on":        ON,
+	"open":      OPEN,
+	"option":    OPTION,
+	"or":        OR,
+	"palette":   PALETTE,
+	"print":     PRINT,
+	"put":       PUT,
+	"randomize": RANDOMIZE,
+	"read":      READ,
+	"rem":       REM,
+	"restore":   RESTORE,
+	"resume":    RESUME,
+	"return":    RETURN,
+	"rmdir":     RMDIR,
+	"rset":      RSET,
+	"run":       RUN,
+	"save":      SAVE,
+	"screen":    SCREEN,
+	"stop":      STOP,
+	"swap":      SWAP,
+	"then":      THEN,
+	"timer":     TIMER,
+	"to":        TO,
+	"tron":      TRON,
+	"troff":     TROFF,
+	"true":      TRUE,
+	"using":     USING,
+	"view":      VIEW,
+	"wend":      WEND,
+	"while":     WHILE,
+	"width":     WIDTH,
+	"write":     WRITE,
+	"xor":       XOR,
 }
 
 // LookupIdent returns a TokenType object